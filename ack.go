@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// WithMaxDeliveries makes ConsumeAck give up on an event after n failed deliveries and reject it
+// onto the dead-letter conveyor (or count it as dropped without one). Zero, the default, keeps
+// redelivering until the handler succeeds.
+func WithMaxDeliveries(n int) ConsumeOption {
+	return func(c *consumeConfig) {
+		c.maxDeliveries = n
+	}
+}
+
+// unacked is an event waiting to be redelivered, with how often it has been delivered so far
+type unacked[T any] struct {
+	ev         Event[T]
+	deliveries int
+}
+
+// ackTracker holds one ConsumeAck consumer's failed events until they are redelivered. It stands
+// in for putting an event back on the front of its conveyor, which a channel cannot do.
+type ackTracker[T any] struct {
+	redeliver []unacked[T] // FIFO
+}
+
+func (a *ackTracker[T]) nack(u unacked[T]) { a.redeliver = append(a.redeliver, u) }
+
+// next pops the oldest event awaiting redelivery
+func (a *ackTracker[T]) next() (unacked[T], bool) {
+	if len(a.redeliver) == 0 {
+		return unacked[T]{}, false
+	}
+	u := a.redeliver[0]
+	a.redeliver = a.redeliver[1:]
+	return u, true
+}
+
+// ConsumeAck consumes a specific conveyor with at-least-once delivery: an event is done only
+// when handler returns nil. If handler returns an error or panics, the event is kept by the
+// consumer and redelivered before any new event is taken off the conveyor, so a failing event
+// holds back the rest of its conveyor until it succeeds or WithMaxDeliveries gives up on it.
+// Redelivered events therefore overtake nothing, but a handler must tolerate seeing an event
+// more than once. Redeliveries skip the TTL check and are not counted as consumed again. Once
+// the conveyor is closed, ConsumeAck finishes its pending redeliveries before returning.
+func (bus *MainBus[T]) ConsumeAck(line int, wg *sync.WaitGroup, handler func(Event[T]) error, opts ...ConsumeOption) {
+	defer wg.Done()
+	cfg := newConsumeConfig(opts)
+	self := bus.attachAs(line, cfg.name)
+	defer self.detach()
+	var acks ackTracker[T]
+	// attempt delivers u once through run, keeping it for redelivery if it fails
+	attempt := func(u unacked[T], run func(func(Event[T]))) {
+		called, ok := false, false
+		run(func(ev Event[T]) {
+			called = true
+			ok = handler(ev) == nil
+		})
+		if ok {
+			delivered(u.ev)
+		}
+		if !called || ok {
+			return // expired before reaching the handler, or acknowledged
+		}
+		if u.deliveries++; cfg.maxDeliveries > 0 && u.deliveries >= cfg.maxDeliveries {
+			bus.rejectOrDrop(line, u.ev, "delivery attempts exhausted")
+			return
+		}
+		bus.logEvent(slog.LevelDebug, "redelivering event", line, u.ev, slog.Int("deliveries", u.deliveries))
+		acks.nack(u)
+	}
+	c := bus.conveyor(line)
+	for bus.waitResumed(context.Background(), line) {
+		if u, ok := acks.next(); ok {
+			attempt(u, func(h func(Event[T])) { bus.handle(line, u.ev, h) })
+			continue
+		}
+		ev, ok := <-c
+		if !ok && bus.reopened(line, &c) {
+			continue
+		}
+		if !ok || bus.poisoned(line, ev) {
+			return
+		}
+		// the conveyor may have been paused while this receive was waiting
+		bus.waitResumed(context.Background(), line)
+		attempt(unacked[T]{ev: ev}, func(h func(Event[T])) { bus.dispatch(line, ev, bus.enriched(h), false) })
+		self.done()
+	}
+}
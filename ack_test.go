@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestConsumeAckRedelivers(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	bus.RemoveConveyor(1)
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	bus.Close()
+
+	deliveries := map[int]int{}
+	var order []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeAck(0, &wg, func(ev Event[int]) error {
+		// every event fails its first delivery: even IDs with an error, odd ones by panicking
+		if deliveries[ev.ID]++; deliveries[ev.ID] == 1 {
+			if ev.ID%2 == 0 {
+				return errors.New("database busy")
+			}
+			panic("handler crashed")
+		}
+		order = append(order, ev.ID)
+		return nil
+	})
+	for id := 0; id < 4; id++ {
+		if deliveries[id] != 2 {
+			t.Fatalf("event %d delivered %d times, want 2", id, deliveries[id])
+		}
+	}
+	for i, id := range order {
+		if id != i {
+			t.Fatalf("acknowledged in order %v, want conveyor order", order)
+		}
+	}
+	if c := bus.Metrics().Consumed[0]; c != 4 {
+		t.Fatalf("Consumed = %d, want 4 despite redeliveries", c)
+	}
+}
+
+func TestConsumeAckMaxDeliveries(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithDeadLetter(1))
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[int]{ID: 7})
+	calls := 0
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeAck(0, &wg, func(Event[int]) error {
+		calls++
+		return errors.New("poison")
+	}, WithMaxDeliveries(3))
+	dl := <-bus.deadLetters
+	bus.Close()
+	wg.Wait()
+	if dl.Event.ID != 7 || calls != 3 {
+		t.Fatalf("dead letter %+v after %d calls, want event 7 after 3", dl, calls)
+	}
+}
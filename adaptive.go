@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultAdaptiveSustain is how long the WithAdaptiveOverflow watermarks must hold before the
+// overflow policy switches, unless WithAdaptiveSustain says otherwise
+const DefaultAdaptiveSustain = time.Second
+
+// adaptiveSamples is how many fill samples the adaptive overflow monitor takes per sustain period
+const adaptiveSamples = 5
+
+// adaptiveConfig holds the WithAdaptiveOverflow watermarks
+type adaptiveConfig struct {
+	high, low float64
+}
+
+// WithOverflowModeCallback calls f with the overflow policy now in effect whenever
+// WithAdaptiveOverflow degrades the bus to OverflowDropOldest or restores its own policy. f runs
+// on the monitor goroutine.
+func WithOverflowModeCallback(f func(policy OverflowPolicy)) Option {
+	return func(c *busConfig) {
+		c.onOverflowMode = f
+	}
+}
+
+// WithAdaptiveOverflow degrades the bus under sustained overload, trading completeness for
+// liveness: a monitor samples the average fill of the live buffered conveyors, their buffered
+// events over their capacity, and once it has been above highWater at every sample for the
+// sustain period, DefaultAdaptiveSustain unless set with WithAdaptiveSustain, Produce switches
+// to OverflowDropOldest, so producers stop blocking and the freshest events get through; a
+// producer already waiting on a full conveyor waits on. Once the average has been below lowWater
+// for the sustain period the configured overflow policy, OverflowBlock by default, applies
+// again. Both watermarks are fractions of the buffer with 0 <= lowWater < highWater <= 1. Each
+// switch is logged and reported to the WithOverflowModeCallback callback, and BusMetrics tells
+// the current mode and how often the bus degraded. The monitor stops when the bus closes.
+func WithAdaptiveOverflow(highWater, lowWater float64) Option {
+	return func(c *busConfig) {
+		c.adaptive = &adaptiveConfig{high: highWater, low: lowWater}
+	}
+}
+
+// WithAdaptiveSustain sets how long the WithAdaptiveOverflow watermarks must hold before the
+// overflow policy switches
+func WithAdaptiveSustain(d time.Duration) Option {
+	return func(c *busConfig) {
+		c.adaptiveSustain = d
+	}
+}
+
+// validateAdaptive checks the WithAdaptiveOverflow settings
+func validateAdaptive(cfg busConfig) error {
+	a := cfg.adaptive
+	if a == nil {
+		return nil
+	}
+	switch {
+	case a.low < 0 || a.low >= a.high || a.high > 1:
+		return fmt.Errorf("%w: adaptive overflow watermarks %v and %v", ErrInvalidConfig, a.high, a.low)
+	case cfg.adaptiveSustain < 0:
+		return fmt.Errorf("%w: adaptive overflow sustain %v is negative", ErrInvalidConfig, cfg.adaptiveSustain)
+	}
+	return nil
+}
+
+// overflowPolicy returns the overflow policy in effect: OverflowDropOldest while
+// WithAdaptiveOverflow has degraded the bus, the configured one otherwise
+func (bus *MainBus[T]) overflowPolicy() OverflowPolicy {
+	if bus.degraded.Load() {
+		return OverflowDropOldest
+	}
+	return bus.overflow
+}
+
+// startAdaptiveOverflow starts the monitor if the bus was built WithAdaptiveOverflow
+func (bus *MainBus[T]) startAdaptiveOverflow(cfg busConfig) {
+	a := cfg.adaptive
+	if a == nil {
+		return
+	}
+	sustain := cfg.adaptiveSustain
+	if sustain == 0 {
+		sustain = DefaultAdaptiveSustain
+	}
+	go bus.runAdaptiveOverflow(*a, sustain, max(sustain/adaptiveSamples, time.Millisecond), cfg.onOverflowMode)
+}
+
+// runAdaptiveOverflow samples the average fill every interval until the bus closes, switching
+// the overflow mode once a watermark has held for sustain
+func (bus *MainBus[T]) runAdaptiveOverflow(a adaptiveConfig, sustain, interval time.Duration, onChange func(OverflowPolicy)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var since time.Time // when the fill last crossed the watermark that ends the current mode
+	for {
+		select {
+		case <-ticker.C:
+		case <-bus.closing:
+			return
+		}
+		fill, ok := bus.averageFill()
+		degraded := bus.degraded.Load()
+		crossing := ok && (!degraded && fill > a.high || degraded && fill < a.low)
+		if !crossing {
+			since = time.Time{}
+			continue
+		}
+		now := bus.now()
+		if since.IsZero() {
+			since = now
+		}
+		if now.Sub(since) < sustain {
+			continue
+		}
+		since = time.Time{}
+		bus.degraded.Store(!degraded)
+		if !degraded {
+			bus.degradations.Add(1)
+			bus.logger.Warn("overflow degraded to dropping the oldest events", "resource", bus.Resource, "fill", fill)
+		} else {
+			bus.logger.Info("overflow policy restored", "resource", bus.Resource, "fill", fill)
+		}
+		if onChange != nil {
+			onChange(bus.overflowPolicy())
+		}
+	}
+}
+
+// averageFill returns the mean fill of the live buffered conveyors, or false if there are none
+func (bus *MainBus[T]) averageFill() (float64, bool) {
+	var sum float64
+	n := 0
+	t := bus.table()
+	for _, line := range t.live {
+		b := t.belts[line]
+		if c := b.capacity(); c > 0 {
+			sum += float64(b.depth()) / float64(c)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveOverflowSwitchesAndReverts(t *testing.T) {
+	modes := make(chan OverflowPolicy, 4)
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin),
+		WithAdaptiveOverflow(0.75, 0.25), WithAdaptiveSustain(20*time.Millisecond),
+		WithOverflowModeCallback(func(p OverflowPolicy) { modes <- p }))
+	defer bus.Close()
+	for id := range 8 {
+		bus.Produce(Event[int]{ID: id})
+	}
+	select {
+	case p := <-modes:
+		if p != OverflowDropOldest {
+			t.Fatalf("degraded to policy %v, want OverflowDropOldest", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("full bus never degraded")
+	}
+	if m := bus.Metrics(); !m.OverflowDegraded || m.Degradations != 1 {
+		t.Fatalf("metrics degraded %v after %d degradations, want true after 1", m.OverflowDegraded, m.Degradations)
+	}
+	// while degraded a full bus drops the oldest event rather than blocking
+	done := make(chan error, 1)
+	go func() { done <- bus.Produce(Event[int]{ID: 8}) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("produce blocked on a degraded bus")
+	}
+	if m := bus.Metrics(); m.Dropped[0]+m.Dropped[1] != 1 {
+		t.Fatalf("dropped %v, want one event evicted", m.Dropped)
+	}
+
+	var wg sync.WaitGroup
+	for line := range 2 {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	select {
+	case p := <-modes:
+		if p != OverflowBlock {
+			t.Fatalf("restored policy %v, want OverflowBlock", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("drained bus never reverted")
+	}
+	if m := bus.Metrics(); m.OverflowDegraded || m.Degradations != 1 {
+		t.Fatalf("metrics degraded %v after %d degradations, want false after 1", m.OverflowDegraded, m.Degradations)
+	}
+	bus.Close()
+	wg.Wait()
+}
+
+func TestAdaptiveOverflowIgnoresBriefSpikes(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2), WithStrategy(StrategyRoundRobin),
+		WithAdaptiveOverflow(0.5, 0.1), WithAdaptiveSustain(time.Hour))
+	defer bus.Close()
+	for id := range 4 {
+		bus.Produce(Event[int]{ID: id})
+	}
+	time.Sleep(50 * time.Millisecond)
+	if bus.Metrics().OverflowDegraded {
+		t.Fatal("degraded before the watermark held for the sustain period")
+	}
+}
+
+func TestAdaptiveOverflowInvalid(t *testing.T) {
+	for _, opt := range []Option{
+		WithAdaptiveOverflow(0.5, 0.5),
+		WithAdaptiveOverflow(1.5, 0.5),
+		WithAdaptiveOverflow(0.5, -0.1),
+	} {
+		if _, err := NewMainBusChecked[int]("iron", opt); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("got %v, want ErrInvalidConfig", err)
+		}
+	}
+	if _, err := NewMainBusChecked[int]("iron", WithAdaptiveOverflow(0.8, 0.2), WithAdaptiveSustain(-time.Second)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("negative sustain: %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import "fmt"
+
+// AdminOp is the operation of an AdminCommand
+type AdminOp int
+
+const (
+	// AdminPause pauses a conveyor as Pause does
+	AdminPause AdminOp = iota
+	// AdminResume resumes a conveyor as Resume does
+	AdminResume
+	// AdminResize resizes a conveyor's buffer to Size as ResizeBuffer does
+	AdminResize
+	// AdminClose closes a conveyor as CloseConveyor does
+	AdminClose
+)
+
+func (op AdminOp) String() string {
+	switch op {
+	case AdminPause:
+		return "pause"
+	case AdminResume:
+		return "resume"
+	case AdminResize:
+		return "resize"
+	case AdminClose:
+		return "close"
+	default:
+		return "unknown"
+	}
+}
+
+// AdminCommand is a runtime change to one conveyor, sent on the channel Admin returns
+type AdminCommand struct {
+	Op   AdminOp
+	Line int
+	Size int // the new buffer size for AdminResize
+	// Reply receives the outcome of the command, nil on success, unless it is nil itself. The
+	// control goroutine waits until the outcome is taken, so give it room for one error.
+	Reply chan<- error
+}
+
+// Admin returns a channel taking AdminCommands, for operating the conveyors in-band, from a
+// control plane or an ops console, without calling the bus methods from everywhere. The
+// commands are carried out one at a time, in the order they arrive, by a control goroutine the
+// first call starts, so runtime changes never race with each other. A line out of range or an
+// unknown operation is refused with an error wrapping ErrInvalidLine or ErrInvalidConfig rather
+// than a panic. The control goroutine returns once the bus is closed, after which a send blocks
+// unless it is part of a select; the channel is never closed.
+func (bus *MainBus[T]) Admin() chan<- AdminCommand {
+	bus.adminOnce.Do(func() {
+		bus.admin = make(chan AdminCommand)
+		go bus.runAdmin()
+	})
+	return bus.admin
+}
+
+// runAdmin carries out admin commands until the bus closes
+func (bus *MainBus[T]) runAdmin() {
+	for {
+		select {
+		case cmd := <-bus.admin:
+			err := bus.execute(cmd)
+			if cmd.Reply == nil {
+				continue
+			}
+			select {
+			case cmd.Reply <- err:
+			case <-bus.closing:
+				return
+			}
+		case <-bus.closing:
+			return
+		}
+	}
+}
+
+// execute carries out one admin command
+func (bus *MainBus[T]) execute(cmd AdminCommand) error {
+	if n := len(bus.table().belts); cmd.Line < 0 || cmd.Line >= n {
+		return fmt.Errorf("main bus %q: %s conveyor %d: %w", bus.Resource, cmd.Op, cmd.Line, ErrInvalidLine)
+	}
+	switch cmd.Op {
+	case AdminPause:
+		bus.Pause(cmd.Line)
+	case AdminResume:
+		bus.Resume(cmd.Line)
+	case AdminResize:
+		return bus.ResizeBuffer(cmd.Line, cmd.Size)
+	case AdminClose:
+		return bus.CloseConveyor(cmd.Line)
+	default:
+		return fmt.Errorf("main bus %q: %w: admin operation %d", bus.Resource, ErrInvalidConfig, cmd.Op)
+	}
+	return nil
+}
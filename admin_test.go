@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// command sends cmd on the admin channel of bus and returns its outcome
+func command(t *testing.T, bus *MainBus[int], cmd AdminCommand) error {
+	t.Helper()
+	reply := make(chan error, 1)
+	cmd.Reply = reply
+	bus.Admin() <- cmd
+	select {
+	case err := <-reply:
+		return err
+	case <-time.After(time.Second):
+		t.Fatalf("no reply to %s", cmd.Op)
+		return nil
+	}
+}
+
+func TestAdminPauseStopsDelivery(t *testing.T) {
+	bus := NewMainBus[int]("copper")
+	bus.RemoveConveyor(1)
+	var delivered atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(Event[int]) { delivered.Add(1) })
+
+	if err := command(t, bus, AdminCommand{Op: AdminPause, Line: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if !bus.Paused(0) {
+		t.Fatal("conveyor not paused")
+	}
+	for id := 1; id <= 3; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := delivered.Load(); n != 0 {
+		t.Fatalf("%d events delivered while paused", n)
+	}
+	if err := command(t, bus, AdminCommand{Op: AdminResize, Line: 0, Size: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if got := bus.Capacity(0); got != 8 {
+		t.Fatalf("capacity = %d, want 8", got)
+	}
+	if err := command(t, bus, AdminCommand{Op: AdminResume, Line: 0}); err != nil {
+		t.Fatal(err)
+	}
+	settleDepth(t, bus, 0)
+
+	if err := command(t, bus, AdminCommand{Op: AdminClose, Line: 0}); !errors.Is(err, ErrNoConveyors) {
+		t.Fatalf("closing the last conveyor = %v, want ErrNoConveyors", err)
+	}
+	if err := command(t, bus, AdminCommand{Op: AdminPause, Line: 5}); !errors.Is(err, ErrInvalidLine) {
+		t.Fatalf("pausing line 5 = %v, want ErrInvalidLine", err)
+	}
+	bus.Close()
+	wg.Wait()
+	if n := delivered.Load(); n != 3 {
+		t.Fatalf("delivered %d events, want 3", n)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultAutoscalePeriod is how long saturation must stay past a watermark before WithAutoscale
+// adds or removes a conveyor, unless WithAutoscalePeriod says otherwise
+const DefaultAutoscalePeriod = 5 * time.Second
+
+// autoscaleSamples is how many saturation samples the controller takes per period
+const autoscaleSamples = 5
+
+// ConsumerFactory starts the consumers of a conveyor added by the autoscaler, typically by
+// launching a goroutine that calls one of the bus Consume methods for line
+type ConsumerFactory func(line int)
+
+// autoscaleConfig holds the WithAutoscale settings
+type autoscaleConfig struct {
+	min, max            int
+	highWater, lowWater float64
+}
+
+// WithAutoscale runs a controller that adds a conveyor, starting its consumers with the
+// WithConsumerFactory factory, while the average saturation of the live conveyors (buffered
+// events over total capacity) stays above highWater, and removes the newest one while it stays
+// below lowWater, keeping between min and max conveyors. Flapping is avoided by hysteresis on
+// two counts: the gap between the watermarks, which must satisfy 0 <= lowWater < highWater <= 1,
+// and the autoscale period, for which saturation must stay past a watermark at every sample
+// before the controller acts, and which then starts over. Added conveyors get the bus buffer
+// size; a removed one forwards its events as RemoveConveyor does. The controller starts with
+// the first consumer attached to the bus, so the factory may use the bus variable safely, and
+// stops when the bus closes. The conveyor count the bus starts with must lie between min and
+// max.
+func WithAutoscale(min, max int, highWater, lowWater float64) Option {
+	return func(c *busConfig) {
+		c.autoscale = &autoscaleConfig{min: min, max: max, highWater: highWater, lowWater: lowWater}
+	}
+}
+
+// WithConsumerFactory sets how the autoscaler starts consumers on the conveyors it adds. It is
+// required with WithAutoscale.
+func WithConsumerFactory(f ConsumerFactory) Option {
+	return func(c *busConfig) {
+		c.consumerFactory = f
+	}
+}
+
+// WithAutoscalePeriod sets how long saturation must stay past a watermark before the autoscaler
+// acts; see WithAutoscale
+func WithAutoscalePeriod(d time.Duration) Option {
+	return func(c *busConfig) {
+		c.autoscalePeriod = d
+	}
+}
+
+// validateAutoscale checks the WithAutoscale settings against the conveyor count built
+func validateAutoscale(cfg busConfig) error {
+	a := cfg.autoscale
+	if a == nil {
+		return nil
+	}
+	lines := busLines(cfg.lines)
+	switch {
+	case a.min < 1 || a.max < a.min:
+		return fmt.Errorf("%w: autoscale bounds [%d, %d]", ErrInvalidConfig, a.min, a.max)
+	case lines < a.min || lines > a.max:
+		return fmt.Errorf("%w: %d conveyors outside autoscale bounds [%d, %d]", ErrInvalidConfig, lines, a.min, a.max)
+	case a.lowWater < 0 || a.lowWater >= a.highWater || a.highWater > 1:
+		return fmt.Errorf("%w: autoscale watermarks %v and %v", ErrInvalidConfig, a.highWater, a.lowWater)
+	case cfg.consumerFactory == nil:
+		return fmt.Errorf("%w: autoscale needs WithConsumerFactory", ErrInvalidConfig)
+	case cfg.autoscalePeriod < 0:
+		return fmt.Errorf("%w: autoscale period %v is negative", ErrInvalidConfig, cfg.autoscalePeriod)
+	}
+	return nil
+}
+
+// autoscaler adds and removes conveyors of one bus; see WithAutoscale
+type autoscaler struct {
+	autoscaleConfig
+	factory ConsumerFactory
+	period  time.Duration
+	buffer  int
+	once    sync.Once // starts the controller
+}
+
+// newAutoscaler returns the autoscaler for cfg, or nil without WithAutoscale
+func newAutoscaler(cfg busConfig) *autoscaler {
+	if cfg.autoscale == nil {
+		return nil
+	}
+	period := cfg.autoscalePeriod
+	if period == 0 {
+		period = DefaultAutoscalePeriod
+	}
+	return &autoscaler{autoscaleConfig: *cfg.autoscale, factory: cfg.consumerFactory, period: period, buffer: cfg.buffer}
+}
+
+// startAutoscale starts the controller the first time it is called, if the bus autoscales
+func (bus *MainBus[T]) startAutoscale() {
+	a := bus.autoscaler
+	if a == nil {
+		return
+	}
+	a.once.Do(func() { go bus.runAutoscale(a) })
+}
+
+// runAutoscale samples the saturation autoscaleSamples times per period and scales once it has
+// been past a watermark at a whole period's samples in a row
+func (bus *MainBus[T]) runAutoscale(a *autoscaler) {
+	ticker := time.NewTicker(max(a.period/autoscaleSamples, time.Millisecond))
+	defer ticker.Stop()
+	above, below := 0, 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-bus.closing:
+			return
+		}
+		switch fill := bus.saturation(); {
+		case fill > a.highWater:
+			above, below = above+1, 0
+		case fill < a.lowWater:
+			above, below = 0, below+1
+		default:
+			above, below = 0, 0
+		}
+		live := len(bus.table().live)
+		switch {
+		case above >= autoscaleSamples:
+			above = 0
+			if live < a.max {
+				bus.scaleUp(a)
+			}
+		case below >= autoscaleSamples:
+			below = 0
+			if live > a.min {
+				bus.scaleDown()
+			}
+		}
+	}
+}
+
+// scaleUp adds a conveyor and starts its consumers
+func (bus *MainBus[T]) scaleUp(a *autoscaler) {
+	line := bus.AddConveyor(a.buffer)
+	if line < 0 {
+		return
+	}
+	a.factory(line)
+	bus.logger.Info("autoscaled conveyors", "resource", bus.Resource, "added", line, "lines", len(bus.table().live))
+}
+
+// scaleDown removes the newest live conveyor
+func (bus *MainBus[T]) scaleDown() {
+	live := bus.table().live
+	line := live[len(live)-1]
+	if err := bus.RemoveConveyor(line); err != nil {
+		bus.logger.Warn("autoscale removal failed", "resource", bus.Resource, "line", line, "error", err)
+		return
+	}
+	bus.logger.Info("autoscaled conveyors", "resource", bus.Resource, "removed", line, "lines", len(live)-1)
+}
+
+// saturation returns the events buffered on the live conveyors over their total capacity, 0 if
+// they are all unbuffered
+func (bus *MainBus[T]) saturation() float64 {
+	t := bus.table()
+	depth, capacity := 0, 0
+	for _, line := range t.live {
+		b := t.belts[line]
+		depth += b.depth()
+		capacity += b.capacity()
+	}
+	if capacity == 0 {
+		return 0
+	}
+	return float64(depth) / float64(capacity)
+}
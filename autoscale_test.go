@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitLive[T any](t *testing.T, bus *MainBus[T], want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bus.table().live) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("live conveyors = %d, want %d", len(bus.table().live), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutoscaleUpAndDown(t *testing.T) {
+	gate := make(chan struct{})
+	handler := func(Event[int]) { <-gate }
+	var wg sync.WaitGroup
+	var bus *MainBus[int]
+	var mu sync.Mutex
+	var spawned []int
+	bus = NewMainBus[int](Iron, WithLines(2), WithBuffer(8), WithStrategy(StrategyLeastLoaded),
+		WithAutoscale(2, 4, 0.5, 0.1), WithAutoscalePeriod(50*time.Millisecond),
+		WithConsumerFactory(func(line int) {
+			mu.Lock()
+			spawned = append(spawned, line)
+			mu.Unlock()
+			wg.Add(1)
+			go bus.ConsumeWith(line, &wg, handler)
+		}))
+	for line := range 2 {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, handler)
+	}
+	// least-loaded routing fills every added conveyor too, keeping saturation high
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		for i := range 80 {
+			bus.Produce(Event[int]{ID: i + 1})
+		}
+	}()
+
+	waitLive(t, bus, 4)
+	time.Sleep(150 * time.Millisecond)
+	if n := len(bus.table().live); n != 4 {
+		t.Fatalf("live conveyors = %d after reaching max, want 4", n)
+	}
+	close(gate)
+	<-produced
+	waitLive(t, bus, 2)
+	bus.Close()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(spawned) != 2 || spawned[0] != 2 || spawned[1] != 3 {
+		t.Errorf("factory called for %v, want [2 3]", spawned)
+	}
+	var consumed uint64
+	for _, c := range bus.Metrics().Consumed {
+		consumed += c
+	}
+	if consumed != 80 {
+		t.Errorf("consumed %d events, want 80", consumed)
+	}
+}
+
+func TestAutoscaleValidation(t *testing.T) {
+	factory := WithConsumerFactory(func(int) {})
+	for name, opts := range map[string][]Option{
+		"no factory":      {WithAutoscale(2, 4, 0.8, 0.2)},
+		"inverted bounds": {WithAutoscale(4, 2, 0.8, 0.2), factory},
+		"lines below min": {WithLines(2), WithAutoscale(4, 8, 0.8, 0.2), factory},
+		"inverted marks":  {WithAutoscale(2, 4, 0.2, 0.8), factory},
+		"high above 1":    {WithAutoscale(2, 4, 1.5, 0.2), factory},
+		"negative period": {WithAutoscale(2, 4, 0.8, 0.2), factory, WithAutoscalePeriod(-time.Second)},
+	} {
+		if _, err := NewMainBusChecked[int](Iron, append([]Option{WithLines(2)}, opts...)...); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", name, err)
+		}
+	}
+	bus, err := NewMainBusChecked[int](Iron, WithLines(2), WithAutoscale(2, 4, 0.8, 0.2), factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Close()
+}
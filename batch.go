@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ProduceBatch produces evs in order, returning how many were accepted. It follows the bus
+// strategy, overflow policy, rate limit and middleware for every event exactly as Produce does,
+// so round robin advances once per event, but builds the middleware chain and reads the conveyor
+// layout once for the whole batch. Events dropped by OverflowDropNewest are skipped and reported
+// as ErrEventDropped once the rest of the batch is in; any other error stops the batch.
+func (bus *MainBus[T]) ProduceBatch(evs []Event[T]) (accepted int, err error) {
+	if bus.isClosed() {
+		return 0, ErrBusClosed
+	}
+	ctx := context.Background()
+	t := bus.table()
+	produce := bus.chain(func(ev Event[T]) error {
+		if err := bus.validate(ev); err != nil {
+			return err
+		}
+		if err := bus.limiter.wait(ctx); err != nil {
+			return err
+		}
+		if err := bus.admit(ctx); err != nil {
+			bus.limiter.cancel()
+			return err
+		}
+		ev, span := bus.startProduceSpan(ctx, withFate(ev))
+		line, err := bus.routeIn(ctx, t, ev)
+		bus.endProduceSpan(span, line, err)
+		if err != nil {
+			bus.vacate()
+			return err
+		}
+		bus.mirror(ev)
+		return nil
+	})
+	dropped := false
+	for _, ev := range evs {
+		switch err := produce(ev); {
+		case err == nil:
+			accepted++
+		case errors.Is(err, ErrEventDropped):
+			dropped = true
+		default:
+			return accepted, err
+		}
+	}
+	if dropped {
+		return accepted, ErrEventDropped
+	}
+	return accepted, nil
+}
+
+// routeIn places ev on a conveyor of the layout t, falling back to route once t has gone stale
+func (bus *MainBus[T]) routeIn(ctx context.Context, t *lineTable[T], ev Event[T]) (int, error) {
+	if bus.isClosed() {
+		return -1, ErrBusClosed
+	}
+	if len(t.live) > 0 {
+		line, _ := bus.selectLine(t, ev)
+		if err := bus.send(ctx, line, t.belts[line], ev, true); err != errBeltClosed {
+			return line, err
+		}
+	}
+	return bus.route(ctx, ev, true)
+}
+
+// ConsumeBatch consumes a specific conveyor, handing events to handler in batches of up to
+// maxBatch. A partial batch is flushed once maxWait has passed since its first event, before the
+// consumer blocks on a paused conveyor, and when the conveyor is closed. Each event goes through
+// the same pipeline as ConsumeWith (TTL, latency and consumed counts) before joining the batch.
+// A panicking handler is recovered and logged, and the batch is lost. maxBatch below one is
+// treated as one.
+func (bus *MainBus[T]) ConsumeBatch(line int, wg *sync.WaitGroup, handler func([]Event[T]), maxBatch int, maxWait time.Duration) {
+	defer wg.Done()
+	self := bus.attach(line)
+	defer self.detach()
+	maxBatch = max(maxBatch, 1)
+	c := bus.conveyor(line)
+	batch := make([]Event[T], 0, maxBatch)
+	add := func(ev Event[T]) { batch = append(batch, ev) }
+	flush := func() {
+		if len(batch) > 0 {
+			bus.handleBatch(line, batch, handler)
+			batch = make([]Event[T], 0, maxBatch)
+		}
+	}
+	timer := time.NewTimer(maxWait)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		if bus.Paused(line) {
+			flush()
+			bus.waitResumed(context.Background(), line)
+		}
+		select {
+		case ev, ok := <-c:
+			if !ok && bus.reopened(line, &c) {
+				continue
+			}
+			if !ok || bus.poisoned(line, ev) {
+				flush()
+				return
+			}
+			if ev.barrier != nil {
+				flush() // the barrier is reached once the events before it are handled
+			}
+			bus.deliver(line, ev, add)
+			self.done()
+			switch {
+			case len(batch) >= maxBatch:
+				timer.Stop()
+				flush()
+			case len(batch) == 1:
+				timer.Reset(maxWait)
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// handleBatch runs handler for one batch, recovering a panic like handle does for single events
+func (bus *MainBus[T]) handleBatch(line int, batch []Event[T], handler func([]Event[T])) {
+	defer func() {
+		if r := recover(); r != nil {
+			bus.logger.Error("recovered batch handler panic", "resource", bus.Resource, "line", line, "events", len(batch), "panic", r)
+		}
+	}()
+	handler(batch)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProduceBatchRoundRobin(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(10), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	evs := make([]Event[int], 10)
+	accepted, err := bus.ProduceBatch(evs)
+	if err != nil || accepted != 10 {
+		t.Fatalf("ProduceBatch = %d, %v; want 10, nil", accepted, err)
+	}
+	for line, want := range []uint64{3, 3, 2, 2} {
+		if got := bus.Metrics().Produced[line]; got != want {
+			t.Fatalf("conveyor %d got %d events, want %d", line, got, want)
+		}
+	}
+	bus.Produce(Event[int]{})
+	if bus.Metrics().Produced[2] != 3 {
+		t.Fatal("round robin did not continue after the batch")
+	}
+}
+
+func TestProduceBatchOverflowAndClosed(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2), WithStrategy(StrategyRoundRobin), WithOverflowPolicy(OverflowDropNewest))
+	accepted, err := bus.ProduceBatch(make([]Event[int], 6))
+	if accepted != 4 || !errors.Is(err, ErrEventDropped) {
+		t.Fatalf("ProduceBatch = %d, %v; want 4, ErrEventDropped", accepted, err)
+	}
+	bus.Close()
+	if accepted, err := bus.ProduceBatch(make([]Event[int], 1)); accepted != 0 || !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("ProduceBatch on closed bus = %d, %v", accepted, err)
+	}
+}
+
+func TestConsumeBatchFlushes(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(20))
+	bus.RemoveConveyor(1)
+	batches := make(chan []Event[int], 10)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeBatch(0, &wg, func(evs []Event[int]) { batches <- evs }, 4, 50*time.Millisecond)
+
+	next := func() []Event[int] {
+		select {
+		case evs := <-batches:
+			return evs
+		case <-time.After(2 * time.Second):
+			t.Fatal("no batch flushed")
+			return nil
+		}
+	}
+
+	// size-triggered: eight events flush as two full batches without waiting for maxWait
+	start := time.Now()
+	for i := 0; i < 8; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	for b := 0; b < 2; b++ {
+		if evs := next(); len(evs) != 4 || evs[0].ID != 4*b {
+			t.Fatalf("batch %d = %v, want 4 events from ID %d", b, evs, 4*b)
+		}
+	}
+	if time.Since(start) >= 50*time.Millisecond {
+		t.Fatal("full batches waited for maxWait")
+	}
+
+	// time-triggered: a partial batch is flushed once maxWait passes
+	start = time.Now()
+	bus.Produce(Event[int]{ID: 8})
+	bus.Produce(Event[int]{ID: 9})
+	if evs := next(); len(evs) != 2 || time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("partial batch %v flushed after %v", evs, time.Since(start))
+	}
+
+	// close flushes what is left
+	bus.Produce(Event[int]{ID: 10})
+	bus.Close()
+	wg.Wait()
+	if evs := next(); len(evs) != 1 || evs[0].ID != 10 {
+		t.Fatalf("final batch = %v, want event 10", evs)
+	}
+}
+
+// benchmarkProduce measures producing b.N events in slices of batch, draining the conveyors
+// between slices so the buffers never fill
+func benchmarkProduce(b *testing.B, batch int, useBatch bool) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(batch), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	bus.Use(TimestampMiddleware[int])
+	evs := make([]Event[int], batch)
+	b.ReportAllocs()
+	for done := 0; done < b.N; done += batch {
+		if useBatch {
+			bus.ProduceBatch(evs)
+		} else {
+			for _, ev := range evs {
+				bus.Produce(ev)
+			}
+		}
+		b.StopTimer()
+		for _, c := range bus.Conveyors {
+			for len(c) > 0 {
+				<-c
+			}
+		}
+		b.StartTimer()
+	}
+}
+
+func BenchmarkProducePerEvent(b *testing.B) { benchmarkProduce(b, 256, false) }
+func BenchmarkProduceBatch(b *testing.B)    { benchmarkProduce(b, 256, true) }
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrInvalidLine is wrapped by the errors of operations on a conveyor line that is out of range
+// or no longer live
+var ErrInvalidLine = errors.New("main bus: no such live conveyor")
+
+// ErrNoConveyors is wrapped by the errors of operations that find, or would leave, no live
+// conveyor
+var ErrNoConveyors = errors.New("main bus: no live conveyor")
+
+// errBeltClosed is returned by send when the chosen conveyor was closed underneath the producer,
+// which should then route the event to another conveyor
+var errBeltClosed = errors.New("conveyor closed")
+
+// belt bundles a conveyor with the bookkeeping kept for it
+type belt[T any] struct {
+	ch      atomic.Pointer[Conveyor[T]] // producers send here, see c; replaced by ResizeBuffer
+	piped   Conveyor[T]                 // fed by the pump when built WithBufferFactory, see out
+	buf     Buffer[T]                   // between c and out; nil unless built WithBufferFactory
+	merged  func()                      // called for each event buf merges into another, see pump
+	pumped  atomic.Int32
+	sweeps  chan chan []Event[T] // asks the pump for everything it holds, see sweep
+	stopped chan struct{}        // closed once the pump has returned
+	stats   lineStats
+	gate    lineGate
+	latency latencyHist
+	types   typeCounts // see WithTypeStats
+
+	consumers atomic.Int32 // attached handler-based consumers
+	label     string       // set by WithConveyorLabels; "" when the conveyor has none
+	namesMu   sync.Mutex
+	names     []string     // names of the attached consumers that gave one
+	fullSince atomic.Int64 // UnixNano when c last became full; 0 while it is not full
+	offset    atomic.Int64 // highest event ID consumed; see Checkpoint
+	seek      atomic.Int64 // events with IDs up to this are skipped; 0 skips none
+	head      atomic.Int64 // highest event ID produced; see Lag
+	first     atomic.Int64 // first event ID produced
+	unordered atomic.Bool  // an event without a positive ID was produced, so Lag uses the depth
+
+	mu      sync.RWMutex  // held for reading while sending on c, for writing while closing it
+	closed  bool          // c has been closed; guarded by mu
+	closing chan struct{} // closed just before c is, releasing producers blocked on it
+	once    sync.Once
+
+	finished   chan struct{} // closed once c is closed and drained and no consumer is left, see Done
+	finishOnce sync.Once
+	removed    bool // taken out of routing; guarded by the bus mu
+}
+
+func newBelt[T any](buffer int, factory BufferFactory[T], merged func()) *belt[T] {
+	b := &belt[T]{closing: make(chan struct{}), finished: make(chan struct{}), merged: merged}
+	c := make(Conveyor[T], buffer)
+	b.ch.Store(&c)
+	b.gate.cond = sync.NewCond(&b.gate.mu)
+	if factory != nil {
+		b.buf = factory(buffer)
+		if cb, ok := b.buf.(*CoalescingBuffer[T]); ok {
+			cb.merged = &b.stats.coalesced
+		}
+		b.piped = make(Conveyor[T])
+		b.sweeps = make(chan chan []Event[T])
+		b.stopped = make(chan struct{})
+		go b.pump()
+	}
+	return b
+}
+
+// c returns the channel producers send on. It only changes under mu held for writing.
+func (b *belt[T]) c() Conveyor[T] {
+	return *b.ch.Load()
+}
+
+// out returns the channel consumers receive on: c itself unless built WithBufferFactory
+func (b *belt[T]) out() Conveyor[T] {
+	if b.buf != nil {
+		return b.piped
+	}
+	return b.c()
+}
+
+// close closes the conveyor, first waking any producer blocked sending on it. It reports false
+// if the conveyor was already closed.
+func (b *belt[T]) close() bool {
+	b.once.Do(func() { close(b.closing) })
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return false
+	}
+	b.closed = true
+	close(b.c())
+	b.mu.Unlock()
+	b.finish()
+	return true
+}
+
+// finish closes finished if the conveyor is closed, holds nothing and no consumer is attached.
+// It is called whenever one of those may have become true.
+func (b *belt[T]) finish() {
+	b.mu.RLock()
+	closed := b.closed
+	b.mu.RUnlock()
+	if closed && b.consumers.Load() == 0 && b.depth() == 0 {
+		b.finishOnce.Do(func() { close(b.finished) })
+	}
+}
+
+// lineTable is an immutable snapshot of the bus layout, replaced whenever conveyors are added
+// or removed so producers and consumers can read it without locking
+type lineTable[T any] struct {
+	belts []*belt[T] // every conveyor ever created, indexed by line; lines are never renumbered
+	live  []int      // lines that still receive produced events
+
+	ringOnce sync.Once
+	ring     *hashRing // StrategyConsistentHash ring over live; see ringOf
+}
+
+// table returns the current layout
+func (bus *MainBus[T]) table() *lineTable[T] {
+	return bus.lines.Load()
+}
+
+// belt returns the bookkeeping for a line, panicking with a descriptive message if it does not exist
+func (bus *MainBus[T]) belt(line int) *belt[T] {
+	belts := bus.table().belts
+	if line < 0 || line >= len(belts) {
+		panic(fmt.Sprintf("main bus %q: conveyor %d out of range [0, %d)", bus.Resource, line, len(belts)))
+	}
+	return belts[line]
+}
+
+// conveyor returns the conveyor at line, panicking if it does not exist
+func (bus *MainBus[T]) conveyor(line int) Conveyor[T] {
+	return bus.belt(line).out()
+}
+
+// Receiver returns the conveyor at line as a receive-only channel, for consumers that need it in
+// their own select statements; callers cannot send on it or close it. Events read from it bypass
+// the consume pipeline: they are not counted in Metrics or Checkpoint, skip the TTL, Pause, SeekTo
+// and tracing, and their delivery callbacks never run. A line out of range yields nil, which
+// blocks forever and so is never chosen by a select. A removed conveyor yields its closed channel.
+// ResizeBuffer closes the channel it replaces, so call Receiver again after one.
+func (bus *MainBus[T]) Receiver(line int) <-chan Event[T] {
+	belts := bus.table().belts
+	if line < 0 || line >= len(belts) {
+		return nil
+	}
+	return belts[line].out()
+}
+
+// Done returns a channel closed once the conveyor at line is closed, every event buffered there
+// is taken and the consumers attached to it have returned, for shutdown sequencing finer than the
+// bus-wide WaitGroup: wait for one stage's conveyor before closing the next stage. The conveyor
+// is closed by Close, Drain, CloseConveyor or RemoveConveyor; consumers that return for another
+// reason, on a cancelled context or a Poison pill, leave it open and Done pending, and a closed
+// conveyor still holding events waits for a consumer to take them. A conveyor closed with nothing
+// buffered and no consumer is done at once. A line out of range yields nil, which blocks forever.
+func (bus *MainBus[T]) Done(line int) <-chan struct{} {
+	belts := bus.table().belts
+	if line < 0 || line >= len(belts) {
+		return nil
+	}
+	return belts[line].finished
+}
+
+// publish installs a new layout. Callers must hold bus.mu for writing.
+func (bus *MainBus[T]) publish(belts []*belt[T]) {
+	t := &lineTable[T]{belts: belts}
+	conveyors := make([]Conveyor[T], len(belts))
+	for i, b := range belts {
+		conveyors[i] = b.out()
+		if !b.removed {
+			t.live = append(t.live, i)
+		}
+	}
+	bus.lines.Store(t)
+	bus.Conveyors = conveyors
+}
+
+// AddConveyor adds a conveyor with the given buffer size and returns its line index. Producers
+// start routing to it immediately; start a consumer for the new line to drain it. It returns -1 if
+// the bus is closed. AddConveyor, RemoveConveyor, producers and consumers may run concurrently,
+// but the exported Conveyors slice is replaced on every change and must not be read at the same time.
+func (bus *MainBus[T]) AddConveyor(buffer int) int {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		return -1
+	}
+	old := bus.table().belts
+	belts := append(append(make([]*belt[T], 0, len(old)+1), old...), newBelt[T](buffer, bus.buffers, bus.vacate))
+	bus.publish(belts)
+	return len(belts) - 1
+}
+
+// RemoveConveyor takes a conveyor out of routing and closes it. Its line index is retired rather
+// than reused, so other lines keep their numbers. The conveyor's consumer finishes the event it is
+// handling and exits once the buffer is empty; events still buffered are forwarded to the remaining
+// conveyors (blocking while they are full), or to the dead-letter conveyor if the bus closes
+// meanwhile. The last remaining conveyor cannot be removed.
+func (bus *MainBus[T]) RemoveConveyor(line int) error {
+	b, err := bus.retire(line)
+	if err != nil {
+		return err
+	}
+	for ev := range b.out() {
+		_, err := bus.route(context.Background(), ev, false)
+		bus.forwarded(line, ev, err)
+	}
+	b.finish()
+	return nil
+}
+
+// RemoveConveyorMigrating is RemoveConveyor keeping the consumer capacity of the bus, for
+// scaling down without silently losing processing power. Before removing the conveyor it counts
+// the consumers attached there, and it calls factory that many times with the live conveyor that
+// has the fewest consumers (the lowest line on a tie), which it returns, so each gets a
+// replacement there. The removed conveyor's own consumers finish as for RemoveConveyor, and
+// its buffered events that they do not take are forwarded to that target conveyor, blocking
+// while it is full, so the replacements handle them; events keyed for StrategyHashKey go to the
+// conveyor of their key instead, and if the target is removed meanwhile, events are routed by
+// the bus strategy. A nil factory is an error, and the conveyor is left alone.
+func (bus *MainBus[T]) RemoveConveyorMigrating(line int, factory ConsumerFactory) (int, error) {
+	if factory == nil {
+		return -1, fmt.Errorf("main bus %q: migrating conveyor %d needs a consumer factory", bus.Resource, line)
+	}
+	consumers := 0
+	if belts := bus.table().belts; line >= 0 && line < len(belts) {
+		consumers = int(belts[line].consumers.Load())
+	}
+	b, err := bus.retire(line)
+	if err != nil {
+		return -1, err
+	}
+	t := bus.table()
+	target := t.live[0]
+	for _, l := range t.live {
+		if t.belts[l].consumers.Load() < t.belts[target].consumers.Load() {
+			target = l
+		}
+	}
+	for range consumers {
+		factory(target)
+	}
+	for ev := range b.out() {
+		err := errBeltClosed
+		if !bus.keyed(ev) {
+			err = bus.send(context.Background(), target, t.belts[target], ev, false)
+		}
+		if err == errBeltClosed {
+			_, err = bus.route(context.Background(), ev, false)
+		}
+		bus.forwarded(line, ev, err)
+	}
+	b.finish()
+	return target, nil
+}
+
+// forwarded handles the outcome err of forwarding ev off removed conveyor line: an event that
+// could not be placed is dead-lettered, or dropped if that fails too
+func (bus *MainBus[T]) forwarded(line int, ev Event[T], err error) {
+	if err == nil {
+		return
+	}
+	bus.vacate()
+	if errors.Is(err, ErrEventDropped) {
+		return
+	}
+	if rerr := bus.Reject(ev, "conveyor removed"); rerr != nil && !errors.Is(rerr, ErrDeadLetterFull) {
+		bus.drop(slog.LevelWarn, line, ev, "conveyor removed")
+	}
+}
+
+// CloseConveyor takes a conveyor out of routing and closes it, leaving the rest of the bus
+// running, for maintenance of the consumers of one line. Unlike RemoveConveyor it keeps the
+// events already buffered there: the conveyor's consumers deliver them and then exit as they
+// would on Close, so it needs a consumer to empty it. Its line index is retired as by
+// RemoveConveyor. Closing a conveyor already closed or removed, or the last live one, returns
+// an error.
+func (bus *MainBus[T]) CloseConveyor(line int) error {
+	_, err := bus.retire(line)
+	return err
+}
+
+// retire takes a live conveyor out of routing and closes it, refusing the last one
+func (bus *MainBus[T]) retire(line int) (*belt[T], error) {
+	bus.mu.Lock()
+	t := bus.table()
+	if line < 0 || line >= len(t.belts) || t.belts[line].removed {
+		bus.mu.Unlock()
+		return nil, fmt.Errorf("main bus %q: conveyor %d: %w", bus.Resource, line, ErrInvalidLine)
+	}
+	if len(t.live) == 1 {
+		bus.mu.Unlock()
+		return nil, fmt.Errorf("main bus %q: cannot remove the last conveyor: %w", bus.Resource, ErrNoConveyors)
+	}
+	b := t.belts[line]
+	b.removed = true
+	bus.publish(t.belts)
+	bus.mu.Unlock()
+
+	b.close()
+	return b, nil
+}
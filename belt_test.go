@@ -0,0 +1,128 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCloseConveyorKeepsOthersRunning(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i}) // one on each conveyor
+	}
+	if err := bus.CloseConveyor(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.CloseConveyor(1); err == nil {
+		t.Fatal("closing conveyor 1 twice succeeded")
+	}
+
+	// the closed conveyor's consumer delivers its buffered event and exits
+	var wg sync.WaitGroup
+	wg.Add(1)
+	got := make(chan Event[int], 1)
+	go bus.ConsumeWith(1, &wg, func(ev Event[int]) { got <- ev })
+	wg.Wait()
+	if ev := <-got; ev.ID != 1 {
+		t.Fatalf("closed conveyor delivered event %d, want 1", ev.ID)
+	}
+
+	for i := 4; i < 10; i++ {
+		line, err := bus.ProduceReturn(Event[int]{ID: i})
+		if err != nil || line == 1 {
+			t.Fatalf("ProduceReturn %d = %d, %v; want a conveyor other than 1", i, line, err)
+		}
+	}
+	delivered := 0
+	for _, line := range []int{0, 2, 3} {
+		evs, err := CollectN(bus, line, 3, time.Second)
+		if err != nil {
+			t.Fatalf("conveyor %d: %v", line, err)
+		}
+		delivered += len(evs)
+	}
+	if delivered != 9 {
+		t.Fatalf("open conveyors delivered %d events, want 9", delivered)
+	}
+}
+
+func TestCloseConveyorRefusesLast(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2))
+	defer bus.Close()
+	if err := bus.CloseConveyor(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.CloseConveyor(1); err == nil {
+		t.Fatal("closed the last conveyor")
+	}
+	if err := bus.Produce(Event[int]{ID: 1}); err != nil {
+		t.Fatalf("Produce after closing one conveyor: %v", err)
+	}
+}
+
+func TestRemoveConveyorMigratingKeepsConsumers(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(8))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	handledOn := make(map[int][]int) // line consumed from to event IDs
+	var gate sync.WaitGroup
+	gate.Add(1)
+	holding := make(chan struct{})
+	start := func(line int) {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(ev Event[int]) {
+			if ev.ID == 1 {
+				close(holding)
+				gate.Wait() // the removed conveyor's consumer is busy while it is removed
+			}
+			mu.Lock()
+			handledOn[line] = append(handledOn[line], ev.ID)
+			mu.Unlock()
+		})
+	}
+	for line := range bus.Conveyors {
+		start(line)
+	}
+	if err := bus.WaitForConsumers(4, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	p := ProducerPool(bus, 2)[1]
+	for id := 1; id <= 5; id++ {
+		p.Send(Event[int]{ID: id})
+	}
+	<-holding
+
+	var started []int
+	target, err := bus.RemoveConveyorMigrating(1, func(line int) {
+		started = append(started, line)
+		start(line)
+	})
+	if err != nil {
+		t.Fatalf("RemoveConveyorMigrating: %v", err)
+	}
+	if target != 0 || !slices.Equal(started, []int{0}) {
+		t.Fatalf("target %d, replacements started on %v; want one replacement on conveyor 0", target, started)
+	}
+	gate.Done()
+	for deadline := time.Now().Add(time.Second); bus.Consumers() != 4; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d consumers on the live conveyors, want the 4 there were before removal", bus.Consumers())
+		}
+	}
+	bus.Close()
+	wg.Wait()
+	// the busy consumer finished its event; the rest went to the target's consumers
+	if got := handledOn[1]; !slices.Equal(got, []int{1}) {
+		t.Fatalf("removed conveyor's consumer handled %v, want only event 1", got)
+	}
+	slices.Sort(handledOn[0])
+	if got := handledOn[0]; !slices.Equal(got, []int{2, 3, 4, 5}) {
+		t.Fatalf("target conveyor's consumers handled %v, want the forwarded events 2 to 5", got)
+	}
+	if _, err := bus.RemoveConveyorMigrating(2, nil); err == nil {
+		t.Fatal("RemoveConveyorMigrating with a nil factory succeeded")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import "time"
+
+// WithCircuitBreaker opens the consumer's circuit after threshold consecutive handler errors.
+// While open, events skip the handler and are rejected with the reason "circuit open". After
+// cooldown the circuit is half-open: the next event is handed to the handler as a trial, and
+// its success closes the circuit while an error opens it for another cooldown. onChange, if not
+// nil, is called from the consumer goroutine with every new state. A threshold below one
+// disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration, onChange func(BreakerState)) ConsumeOption {
+	return func(c *consumeConfig) {
+		c.breakerThreshold = threshold
+		c.breakerCooldown = cooldown
+		c.onBreakerChange = onChange
+	}
+}
+
+// BreakerState is the state of a consumer's circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed passes every event to the handler (the normal state)
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects events without calling the handler until the cooldown ends
+	BreakerOpen
+	// BreakerHalfOpen hands one trial event to the handler to test recovery
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker tracks consecutive handler failures for one consumer goroutine. A nil breaker always
+// allows.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(BreakerState)
+	clock     Clock
+
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// newBreaker returns the breaker configured by cfg, or nil if it is disabled
+func newBreaker(cfg consumeConfig, clock Clock) *breaker {
+	if cfg.breakerThreshold < 1 {
+		return nil
+	}
+	return &breaker{threshold: cfg.breakerThreshold, cooldown: cfg.breakerCooldown, onChange: cfg.onBreakerChange, clock: clock}
+}
+
+// allow reports whether the next event may go to the handler, moving an open circuit to
+// half-open once its cooldown has passed
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	if b.state == BreakerOpen {
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.set(BreakerHalfOpen)
+	}
+	return true
+}
+
+// record notes the outcome of a handler call let through by allow
+func (b *breaker) record(ok bool) {
+	if b == nil {
+		return
+	}
+	switch {
+	case ok:
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.set(BreakerClosed)
+		}
+	case b.state == BreakerHalfOpen:
+		b.trip()
+	default:
+		if b.failures++; b.failures >= b.threshold {
+			b.trip()
+		}
+	}
+}
+
+// trip opens the circuit for a cooldown
+func (b *breaker) trip() {
+	b.failures = 0
+	b.openedAt = b.clock.Now()
+	b.set(BreakerOpen)
+}
+
+func (b *breaker) set(s BreakerState) {
+	b.state = s
+	if b.onChange != nil {
+		b.onChange(s)
+	}
+}
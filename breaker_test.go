@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithDeadLetter(16))
+	bus.RemoveConveyor(1)
+	var failing atomic.Bool
+	failing.Store(true)
+	var calls atomic.Int32
+	ok := make(chan int, 16)
+	var states []BreakerState
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWithReject(0, &wg, func(ev Event[int]) error {
+		calls.Add(1)
+		if failing.Load() {
+			return errors.New("downstream unavailable")
+		}
+		ok <- ev.ID
+		return nil
+	}, WithCircuitBreaker(3, 50*time.Millisecond, func(s BreakerState) { states = append(states, s) }))
+
+	// expect produces one event and waits until it was dead-lettered with reason, or handled
+	expect := func(id int, reason string) {
+		t.Helper()
+		bus.Produce(Event[int]{ID: id})
+		select {
+		case dl := <-bus.deadLetters:
+			if dl.Event.ID != id || dl.Reason != reason {
+				t.Fatalf("event %d: dead letter %+v, want reason %q", id, dl, reason)
+			}
+		case got := <-ok:
+			if got != id || reason != "" {
+				t.Fatalf("event %d handled, want dead letter %q", got, reason)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d went nowhere", id)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		expect(i, "downstream unavailable")
+	}
+	expect(3, "circuit open")
+	expect(4, "circuit open")
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("handler called %d times while open, want 3", n)
+	}
+
+	// a failed trial reopens the circuit
+	time.Sleep(60 * time.Millisecond)
+	expect(5, "downstream unavailable")
+	expect(6, "circuit open")
+
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(false)
+	expect(7, "")
+	expect(8, "")
+
+	bus.Close()
+	wg.Wait()
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(states) != len(want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %v, want %v", states, want)
+		}
+	}
+}
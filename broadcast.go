@@ -0,0 +1,85 @@
+package main
+
+import "context"
+
+// Broadcast sends a copy of ev to every live conveyor, so each consumer receives exactly one copy.
+// It is meant for control events such as flush markers or epoch boundaries, and blocks on each
+// conveyor in turn until it has room.
+func (bus *MainBus[T]) Broadcast(ev Event[T]) error {
+	return bus.BroadcastContext(context.Background(), ev)
+}
+
+// BroadcastContext is Broadcast with cancellation. If ctx is done part-way through, the
+// conveyors already visited keep their copy and ctx.Err() is returned.
+func (bus *MainBus[T]) BroadcastContext(ctx context.Context, ev Event[T]) error {
+	return bus.broadcast(ctx, ev, nil)
+}
+
+// BroadcastIf is Broadcast limited to the live conveyors pred selects, for control events meant
+// for some consumers only, such as a flush for the conveyors feeding one kind of consumer; pred
+// can match on Label. Paused conveyors are not skipped: their copy waits on the conveyor until
+// it is resumed, so a pred wanting them left out should check Paused. If pred selects no
+// conveyor, nothing is sent and the event's fate stays unsettled.
+func (bus *MainBus[T]) BroadcastIf(ev Event[T], pred func(line int) bool) error {
+	return bus.broadcast(context.Background(), ev, pred)
+}
+
+// broadcast sends a copy of ev to the live conveyors pred selects, or all of them if pred is nil
+func (bus *MainBus[T]) broadcast(ctx context.Context, ev Event[T], pred func(int) bool) error {
+	if bus.isClosed() {
+		return ErrBusClosed
+	}
+	ev = withFate(ev) // the first copy settled decides the fate
+	t := bus.table()
+	for _, line := range t.live {
+		if pred != nil && !pred(line) {
+			continue
+		}
+		if err := bus.sendBlocking(ctx, line, t.belts[line], ev); err != nil && err != errBeltClosed {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendBlocking waits for room on one conveyor regardless of the overflow policy
+func (bus *MainBus[T]) sendBlocking(ctx context.Context, line int, b *belt[T], ev Event[T]) error {
+	if err := bus.hold.enter(ctx, bus.done); err != nil {
+		return err
+	}
+	defer bus.hold.leave()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errBeltClosed
+	}
+	select {
+	case b.c() <- ev:
+		bus.onProduced(line, ev.ID)
+		bus.occupy(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-bus.done:
+		return ErrBusClosed
+	case <-b.closing:
+		return errBeltClosed
+	}
+}
+
+// TryBroadcast offers a copy of ev to every conveyor without blocking and returns how many
+// conveyors accepted it
+func (bus *MainBus[T]) TryBroadcast(ev Event[T]) int {
+	if bus.isClosed() {
+		return 0
+	}
+	t := bus.table()
+	accepted := 0
+	for _, line := range t.live {
+		if bus.offer(line, t.belts[line], ev, false) {
+			bus.occupy(1)
+			accepted++
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBroadcastIfReachesSelectedConveyors(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(4), WithBuffer(4),
+		WithConveyorLabels([]string{"smelter-a", "press", "smelter-b", "press"}))
+	defer bus.Close()
+	bus.Pause(2)
+	smelters := func(line int) bool { return strings.HasPrefix(bus.Label(line), "smelter") }
+	if err := bus.BroadcastIf(Event[string]{ID: 1, Value: "flush"}, smelters); err != nil {
+		t.Fatalf("BroadcastIf: %v", err)
+	}
+	for line, want := range []int{1, 0, 1, 0} {
+		if d := bus.Depth(line); d != want {
+			t.Fatalf("conveyor %d (%s) holds %d copies, want %d", line, bus.Label(line), d, want)
+		}
+	}
+	// the paused smelter keeps its copy for when it resumes
+	if ev := <-bus.Conveyors[2]; ev.Value != "flush" {
+		t.Fatalf("paused conveyor got %+v, want the flush", ev)
+	}
+	if err := bus.BroadcastIf(Event[string]{ID: 2}, func(int) bool { return false }); err != nil || bus.TotalDepth() != 1 {
+		t.Fatalf("BroadcastIf selecting nothing = %v with %d events buffered, want nil and 1", err, bus.TotalDepth())
+	}
+}
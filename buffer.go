@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// Buffer holds the events waiting on a conveyor built WithBufferFactory, so a conveyor can
+// serve them in another order than FIFO (LIFO, by priority) or merge them (coalescing).
+//
+// A conveyor calls Push and Pop from a single goroutine of its own, never concurrently with
+// each other, so they need no locking between themselves; Len and Cap are called from any
+// goroutine at any time, concurrently with Push and Pop, and must be safe for that. None of the
+// methods may block. The conveyor only pushes while Len is below Cap; Push reports false when
+// the buffer still has no room for ev, in which case the conveyor holds ev back and offers it
+// again after the next Pop. Pop reports false when the buffer is empty. Len is the number of
+// events held; Cap the most it holds, used for Capacity, saturation and backpressure.
+type Buffer[T any] interface {
+	Push(ev Event[T]) bool
+	Pop() (Event[T], bool)
+	Len() int
+	Cap() int
+}
+
+// BufferFactory builds the Buffer of one conveyor, given the conveyor's WithBuffer size
+type BufferFactory[T any] func(capacity int) Buffer[T]
+
+// WithBufferFactory gives every conveyor, including those added later, a Buffer built by f
+// between its producers and its consumers, generalizing the conveyor beyond a FIFO channel while
+// the produce and consume API stays the same. Producers still send on a channel of the
+// WithBuffer size, from which a goroutine per conveyor moves events into the Buffer as long as
+// it has room and hands them to consumers in the order Pop returns them; the overflow policy
+// applies once both the Buffer and that channel are full, and OverflowDropOldest then evicts
+// the oldest event not yet in the Buffer. The next event is popped as soon as the previous one
+// is taken, so it is committed ahead of events pushed while it waits for a consumer. Depth and
+// Capacity count the Buffer, the channel and that event together. Conveyors with a Buffer are
+// left alone by Rebalance, and Inspect reports nothing for them. The factory must be for the bus
+// event type.
+func WithBufferFactory[T any](f BufferFactory[T]) Option {
+	return func(c *busConfig) {
+		c.bufferFactory = f
+	}
+}
+
+// bufferFactoryFor returns the buffer factory of cfg, or an error if it is for another event type
+func bufferFactoryFor[T any](cfg busConfig) (BufferFactory[T], error) {
+	if cfg.bufferFactory == nil {
+		return nil, nil
+	}
+	f, ok := cfg.bufferFactory.(BufferFactory[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: buffer factory %T does not build Buffer[%T]", ErrInvalidConfig, cfg.bufferFactory, *new(T))
+	}
+	return f, nil
+}
+
+// pump moves events from the conveyor's channel into its buffer and from the buffer to its
+// consumers, closing out once the channel is closed and everything taken from it is handed over
+func (b *belt[T]) pump() {
+	defer close(b.stopped)
+	defer close(b.piped)
+	in := b.c()
+	var head, pending Event[T]
+	held, waiting := false, false // head is popped for consumers; pending awaits buffer room
+	for {
+		if !held {
+			if head, held = b.buf.Pop(); !held && waiting {
+				// a buffer refusing an event while empty could never take it
+				head, held, waiting = pending, true, false
+				b.pumped.Add(-1)
+			}
+			if held {
+				b.pumped.Add(1)
+			}
+		}
+		recv, send := in, b.piped
+		if waiting || b.buf.Len() >= b.buf.Cap() {
+			recv = nil
+		}
+		if !held {
+			send = nil
+		}
+		if recv == nil && send == nil && !waiting {
+			return
+		}
+		select {
+		case ev, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			if !b.push(ev) {
+				pending, waiting = ev, true
+				b.pumped.Add(1)
+			}
+		case send <- head:
+			held = false
+			b.pumped.Add(-1)
+			if waiting && b.push(pending) {
+				waiting = false
+				b.pumped.Add(-1)
+			}
+		case reply := <-b.sweeps:
+			var evs []Event[T]
+			if held {
+				evs, held = append(evs, head), false
+			}
+			for ev, ok := b.buf.Pop(); ok; ev, ok = b.buf.Pop() {
+				evs = append(evs, ev)
+			}
+			if waiting {
+				evs, waiting = append(evs, pending), false
+			}
+			b.pumped.Store(0)
+			for len(in) > 0 {
+				evs = append(evs, <-in)
+			}
+			reply <- evs
+		}
+	}
+}
+
+// push pushes ev into the buffer, calling merged for each event the buffer no longer holds
+// separately afterwards
+func (b *belt[T]) push(ev Event[T]) bool {
+	n := b.buf.Len()
+	if !b.buf.Push(ev) {
+		return false
+	}
+	for range n + 1 - b.buf.Len() {
+		b.merged()
+	}
+	return true
+}
+
+// sweep takes every event a conveyor built WithBufferFactory holds, in the order consumers would
+// get them, or nothing once its pump has returned
+func (b *belt[T]) sweep() []Event[T] {
+	reply := make(chan []Event[T], 1)
+	select {
+	case b.sweeps <- reply:
+		return <-reply
+	case <-b.stopped:
+		return nil
+	}
+}
+
+// depth returns the number of events waiting on the conveyor
+func (b *belt[T]) depth() int {
+	if b.buf == nil {
+		return len(b.c())
+	}
+	return len(b.c()) + b.buf.Len() + int(b.pumped.Load())
+}
+
+// capacity returns how many events the conveyor holds before producers meet the overflow policy
+func (b *belt[T]) capacity() int {
+	if b.buf == nil {
+		return cap(b.c())
+	}
+	return cap(b.c()) + b.buf.Cap() + 1 // the event popped for consumers
+}
+
+// ChannelBuffer is a FIFO Buffer backed by a channel, behaving like a conveyor built without
+// WithBufferFactory; it is a starting point for wrappers adding behaviour around FIFO order
+type ChannelBuffer[T any] struct {
+	c chan Event[T]
+}
+
+// NewChannelBuffer returns a FIFO buffer holding up to capacity events, at least one. It is a
+// BufferFactory.
+func NewChannelBuffer[T any](capacity int) Buffer[T] {
+	return &ChannelBuffer[T]{c: make(chan Event[T], max(capacity, 1))}
+}
+
+// Push implements Buffer
+func (cb *ChannelBuffer[T]) Push(ev Event[T]) bool {
+	select {
+	case cb.c <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pop implements Buffer
+func (cb *ChannelBuffer[T]) Pop() (Event[T], bool) {
+	select {
+	case ev := <-cb.c:
+		return ev, true
+	default:
+		return Event[T]{}, false
+	}
+}
+
+// Len implements Buffer
+func (cb *ChannelBuffer[T]) Len() int { return len(cb.c) }
+
+// Cap implements Buffer
+func (cb *ChannelBuffer[T]) Cap() int { return cap(cb.c) }
+
+// CoalescingBuffer is a Buffer that keeps one event per key: an event pushed while another with
+// its key is waiting replaces it in place, so the merged event keeps the older one's turn. Events
+// are otherwise served oldest first. This conflated queue suits state updates where only the
+// latest value matters, since a slow consumer always gets the freshest one. The replaced events
+// are dropped on purpose: each counts as dropped for its OnDropped callback and in
+// BusMetrics.Coalesced on its conveyor.
+type CoalescingBuffer[T any] struct {
+	key      func(Event[T]) string
+	order    []string // keys in arrival order
+	events   map[string]Event[T]
+	capacity int
+	n        atomic.Int64   // len(events), for Len from other goroutines
+	merged   *atomic.Uint64 // the conveyor's coalesced count; nil outside a conveyor
+	pills    int            // pills and barriers pushed, each keyed apart from events and the others
+}
+
+// NewCoalescingBuffer returns a buffer coalescing events by ID, holding up to capacity distinct
+// IDs, at least one. It is a BufferFactory.
+func NewCoalescingBuffer[T any](capacity int) Buffer[T] {
+	return newCoalescingBuffer(capacity, func(ev Event[T]) string { return strconv.Itoa(ev.ID) })
+}
+
+// newCoalescingBuffer returns a buffer coalescing events by key
+func newCoalescingBuffer[T any](capacity int, key func(Event[T]) string) *CoalescingBuffer[T] {
+	return &CoalescingBuffer[T]{key: key, events: make(map[string]Event[T]), capacity: max(capacity, 1)}
+}
+
+// WithCoalesceKey turns every conveyor into a conflated queue: an event produced while another
+// with the same key(ev) is still buffered overwrites it instead of queuing behind it, as
+// CoalescingBuffer describes, so the intermediate updates are dropped and counted. It is
+// WithBufferFactory with a CoalescingBuffer, so the two options replace each other, whichever
+// comes last, and the conveyor behaves like any built WithBufferFactory. The event handed to the
+// next consumer as soon as the previous one is taken can no longer be overwritten. The key
+// func must be for the bus event type.
+func WithCoalesceKey[T any](key func(Event[T]) string) Option {
+	return WithBufferFactory(func(capacity int) Buffer[T] { return newCoalescingBuffer(capacity, key) })
+}
+
+// Push implements Buffer, merging ev into a waiting event with the same key
+func (cb *CoalescingBuffer[T]) Push(ev Event[T]) bool {
+	var k string
+	if ev.poison || ev.barrier != nil {
+		// a pill or barrier merges with nothing
+		cb.pills++
+		k = "\x00pill " + strconv.Itoa(cb.pills)
+	} else {
+		k = cb.key(ev)
+	}
+	if old, ok := cb.events[k]; ok {
+		cb.events[k] = ev
+		if cb.merged != nil {
+			cb.merged.Add(1)
+		}
+		dropped(old, "coalesced into a newer event")
+		return true
+	}
+	if len(cb.events) >= cb.capacity {
+		return false
+	}
+	cb.events[k] = ev
+	cb.order = append(cb.order, k)
+	cb.n.Add(1)
+	return true
+}
+
+// Pop implements Buffer
+func (cb *CoalescingBuffer[T]) Pop() (Event[T], bool) {
+	if len(cb.order) == 0 {
+		return Event[T]{}, false
+	}
+	k := cb.order[0]
+	cb.order = cb.order[1:]
+	ev := cb.events[k]
+	delete(cb.events, k)
+	cb.n.Add(-1)
+	return ev, true
+}
+
+// Len implements Buffer
+func (cb *CoalescingBuffer[T]) Len() int { return int(cb.n.Load()) }
+
+// Cap implements Buffer
+func (cb *CoalescingBuffer[T]) Cap() int { return cb.capacity }
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lifoBuffer serves the newest event first
+type lifoBuffer struct {
+	mu     sync.Mutex
+	events []Event[int]
+	cap    int
+}
+
+func newLIFO(capacity int) Buffer[int] { return &lifoBuffer{cap: max(capacity, 1)} }
+
+func (l *lifoBuffer) Push(ev Event[int]) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.events) >= l.cap {
+		return false
+	}
+	l.events = append(l.events, ev)
+	return true
+}
+
+func (l *lifoBuffer) Pop() (Event[int], bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.events) == 0 {
+		return Event[int]{}, false
+	}
+	ev := l.events[len(l.events)-1]
+	l.events = l.events[:len(l.events)-1]
+	return ev, true
+}
+
+func (l *lifoBuffer) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.events)
+}
+
+func (l *lifoBuffer) Cap() int { return l.cap }
+
+// settle waits until the pump of line has moved everything off its channel
+func settle(t *testing.T, bus *MainBus[int], line int) {
+	t.Helper()
+	b := bus.belt(line)
+	for deadline := time.Now().Add(time.Second); len(b.c()) > 0 || b.pumped.Load() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("pump did not settle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func ExampleWithBufferFactory() {
+	bus := NewMainBus[string]("iron", WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin),
+		WithBufferFactory[string](NewCoalescingBuffer[string]))
+	bus.RemoveConveyor(1)
+	// the first reading is handed out at once; the three for sensor 2 that queue behind it merge
+	for _, ev := range []Event[string]{{ID: 1, Value: "idle"}, {ID: 2, Value: "10°"}, {ID: 2, Value: "11°"}, {ID: 2, Value: "12°"}} {
+		bus.Produce(ev)
+	}
+	time.Sleep(10 * time.Millisecond) // let the conveyor move them into its buffer
+	bus.Close()
+	for ev := range bus.Receiver(0) {
+		fmt.Println(ev.ID, ev.Value)
+	}
+	// Output:
+	// 1 idle
+	// 2 12°
+}
+
+func TestBufferFactoryLIFO(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin), WithBufferFactory[int](newLIFO))
+	bus.RemoveConveyor(1)
+	for i := 1; i <= 5; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	settle(t, bus, 0)
+	// one event waits popped for a consumer; the rest are still in the buffer
+	if d, c := bus.Depth(0), bus.Capacity(0); d != 5 || c != 17 {
+		t.Fatalf("depth %d of %d, want 5 of 8+8+1", d, c)
+	}
+	var got []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(ev Event[int]) { got = append(got, ev.ID) })
+	if err := bus.Drain(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	// the pump popped 1 before the others arrived; the rest come out newest first
+	if !slices.Equal(got, []int{1, 5, 4, 3, 2}) {
+		t.Fatalf("consumed %v, want [1 5 4 3 2]", got)
+	}
+	if m := bus.Metrics(); m.Consumed[0] != 5 || m.Depth[0] != 0 {
+		t.Fatalf("consumed %d with depth %d, want 5 and 0", m.Consumed[0], m.Depth[0])
+	}
+}
+
+func TestBufferFactoryOverflow(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(1), WithStrategy(StrategyRoundRobin),
+		WithOverflowPolicy(OverflowDropNewest), WithBufferFactory[int](NewChannelBuffer[int]))
+	defer bus.Close()
+	bus.RemoveConveyor(1)
+	// one event held for a consumer, one in the buffer and one on the channel before it
+	accepted := 0
+	for i := 1; i <= 6; i++ {
+		if bus.Produce(Event[int]{ID: i}) == nil {
+			accepted++
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if accepted != 3 || bus.Depth(0) != 3 || bus.Capacity(0) != 3 {
+		t.Fatalf("accepted %d with depth %d of %d, want 3 of 3", accepted, bus.Depth(0), bus.Capacity(0))
+	}
+	if evs := bus.Inspect(0); evs != nil {
+		t.Errorf("Inspect = %v on a buffered conveyor, want nil", evs)
+	}
+}
+
+func TestBufferFactoryCloseDelivers(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(4), WithBufferFactory[int](newLIFO))
+	ProduceAll(bus, prefillEvents(6))
+	bus.Close()
+	n := 0
+	for line := range bus.Conveyors {
+		for range bus.Receiver(line) {
+			n++
+		}
+	}
+	if n != 6 {
+		t.Fatalf("received %d events after Close, want 6", n)
+	}
+}
+
+func TestBufferFactoryMismatch(t *testing.T) {
+	if _, err := NewMainBusChecked[int]("iron", WithBufferFactory[string](NewChannelBuffer[string])); err == nil {
+		t.Fatal("buffer factory for another event type was accepted")
+	}
+}
+
+func TestBufferFactorySnapshot(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(4), WithBufferFactory[int](NewCoalescingBuffer[int]))
+	defer bus.Close()
+	ProduceAll(bus, prefillEvents(6))
+	evs, err := bus.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 6 || bus.TotalDepth() != 0 {
+		t.Fatalf("snapshot took %d events leaving %d, want 6 and 0", len(evs), bus.TotalDepth())
+	}
+}
+
+func TestCoalesceKeyKeepsLatestValue(t *testing.T) {
+	var dropped atomic.Int64
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(4),
+		WithCoalesceKey(func(ev Event[int]) string { return ev.Resource }))
+	bus.RemoveConveyor(1)
+	defer bus.Close()
+	// the first event is handed out for the next consumer at once, so it cannot be overwritten
+	bus.Produce(Event[int]{ID: 1, Resource: "boiler", Value: 80})
+	settle(t, bus, 0)
+	for v := 1; v <= 100; v++ {
+		bus.Produce(Event[int]{ID: 1 + v, Resource: "gauge", Value: v, OnDropped: func(string) { dropped.Add(1) }})
+	}
+	settle(t, bus, 0)
+
+	got, err := CollectN(bus, 0, 2, time.Second)
+	if err != nil {
+		t.Fatalf("CollectN: %v", err)
+	}
+	if got[0].Resource != "boiler" || got[1].Resource != "gauge" || got[1].Value != 100 {
+		t.Fatalf("consumed %+v, want the boiler reading then only the latest gauge value 100", got)
+	}
+	if d := bus.Depth(0); d != 0 {
+		t.Fatalf("%d events left buffered, want none", d)
+	}
+	if m := bus.Metrics(); m.Coalesced[0] != 99 || dropped.Load() != 99 {
+		t.Fatalf("coalesced %d, %d OnDropped calls; want the 99 intermediate updates", m.Coalesced[0], dropped.Load())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// checkpointSuffix names the file, next to the persistence log, that checkpoints are saved to
+const checkpointSuffix = ".checkpoint"
+
+// Checkpoint returns the consume offset of a conveyor: the highest event ID its consumers have
+// finished with, or 0 before any. Offsets are event IDs, so resuming expects positive IDs that
+// increase in the order events are produced, such as those of an auto-increment sequence. IDs
+// need not be contiguous: events dropped by the overflow policy or filtered out before reaching
+// the conveyor leave gaps. Handler-based consumers advance the offset once the handler returns,
+// even if it panicked; ConsumeBatch and ConsumeWindow advance it when an event joins a batch or
+// window. Like Depth, Checkpoint panics if line is out of range.
+func (bus *MainBus[T]) Checkpoint(line int) int64 {
+	return bus.belt(line).offset.Load()
+}
+
+// SeekTo makes the consumers of a conveyor skip every event whose ID is at most offset, counting
+// them in BusMetrics.Seeked, so a consumer restarting after offset does not handle the events
+// it processed before. It raises the conveyor's Checkpoint to offset if it is lower. Zero (or
+// less) stops skipping. SeekTo panics if line is out of range.
+func (bus *MainBus[T]) SeekTo(line int, offset int64) {
+	b := bus.belt(line)
+	b.seek.Store(max(offset, 0))
+	b.advance(offset)
+}
+
+// advance raises the consume offset to id, never lowering it
+func (b *belt[T]) advance(id int64) {
+	for {
+		cur := b.offset.Load()
+		if id <= cur || b.offset.CompareAndSwap(cur, id) {
+			return
+		}
+	}
+}
+
+// passed reports whether ev is behind the offset its conveyor was sought to and must be skipped
+func (b *belt[T]) passed(ev Event[T]) bool {
+	seek := b.seek.Load()
+	return seek > 0 && int64(ev.ID) <= seek
+}
+
+// SaveCheckpoints writes the Checkpoint of every conveyor next to the persistence log, in the
+// file named after the log with a ".checkpoint" suffix, replacing it atomically. Close saves
+// them too, but consumers may still be handling the last events at that point, so call
+// SaveCheckpoints once they are done to record exactly how far they got. It is a no-op on a bus
+// built without WithPersistence. A bus built WithPersistence on the same log seeks each conveyor
+// to its saved checkpoint. Checkpoints are per conveyor, so resuming from them expects replayed
+// events to land on the conveyor they were consumed from, as with a single conveyor or
+// StrategyHashKey over the same lines; other strategies may skip or redeliver events.
+func (bus *MainBus[T]) SaveCheckpoints() error {
+	if bus.checkpoints == "" {
+		return nil
+	}
+	offsets := make(map[string]int64)
+	for line, b := range bus.table().belts {
+		if off := b.offset.Load(); off > 0 {
+			offsets[strconv.Itoa(line)] = off
+		}
+	}
+	data, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+	tmp := bus.checkpoints + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, bus.checkpoints)
+}
+
+// loadCheckpoints seeks every conveyor to the checkpoint saved for it, if any. Lines the bus no
+// longer has are ignored.
+func (bus *MainBus[T]) loadCheckpoints() error {
+	data, err := os.ReadFile(bus.checkpoints)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var offsets map[string]int64
+	if err := json.Unmarshal(data, &offsets); err != nil {
+		return fmt.Errorf("main bus: %s: %w", bus.checkpoints, err)
+	}
+	belts := bus.table().belts
+	for key, off := range offsets {
+		if line, err := strconv.Atoi(key); err == nil && line >= 0 && line < len(belts) {
+			bus.SeekTo(line, off)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCheckpointAdvancesAndSeeks(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	bus.RemoveConveyor(1)
+	for _, id := range []int{1, 2, 4, 7} { // IDs may have gaps
+		bus.Produce(Event[int]{ID: id})
+	}
+	bus.SeekTo(0, 2)
+	if got := bus.Checkpoint(0); got != 2 {
+		t.Fatalf("Checkpoint after SeekTo = %d, want 2", got)
+	}
+	var handled []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.Close()
+	bus.ConsumeWith(0, &wg, func(ev Event[int]) { handled = append(handled, ev.ID) })
+	if len(handled) != 2 || handled[0] != 4 || handled[1] != 7 {
+		t.Fatalf("handled %v, want [4 7]", handled)
+	}
+	if got := bus.Checkpoint(0); got != 7 {
+		t.Fatalf("Checkpoint = %d, want 7", got)
+	}
+	if m := bus.Metrics(); m.Seeked[0] != 2 || m.Consumed[0] != 4 {
+		t.Fatalf("seeked %d, consumed %d; want 2, 4", m.Seeked[0], m.Consumed[0])
+	}
+	bus.SeekTo(0, 3) // seeking back does not lower the checkpoint
+	if got := bus.Checkpoint(0); got != 7 {
+		t.Fatalf("Checkpoint after seeking back = %d, want 7", got)
+	}
+}
+
+func TestCheckpointsResumeFromLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	build := func() *MainBus[int] {
+		bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(16), WithPersistence(path))
+		bus.RemoveConveyor(1)
+		return bus
+	}
+
+	bus := build()
+	for id := 1; id <= 5; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	for range 3 {
+		bus.deliver(0, <-bus.Conveyors[0], func(Event[int]) {})
+	}
+	if err := bus.SaveCheckpoints(); err != nil {
+		t.Fatalf("SaveCheckpoints: %v", err)
+	}
+	if _, err := os.Stat(path + ".checkpoint"); err != nil {
+		t.Fatalf("no checkpoint file: %v", err)
+	}
+	bus.Close()
+
+	restarted := build()
+	if got := restarted.Checkpoint(0); got != 3 {
+		t.Fatalf("restored Checkpoint = %d, want 3", got)
+	}
+	if err := ReplayFile(path, restarted); err != nil {
+		t.Fatalf("ReplayFile: %v", err)
+	}
+	restarted.Close()
+	// the replayed events are logged again, but the consumer skips those it handled before
+	var handled []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	restarted.ConsumeWith(0, &wg, func(ev Event[int]) { handled = append(handled, ev.ID) })
+	if len(handled) != 2 || handled[0] != 4 || handled[1] != 5 {
+		t.Fatalf("resumed consumer handled %v, want [4 5]", handled)
+	}
+}
+
+func TestSaveCheckpointsWithoutPersistence(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1))
+	defer bus.Close()
+	if err := bus.SaveCheckpoints(); err != nil {
+		t.Fatalf("SaveCheckpoints without persistence: %v", err)
+	}
+}
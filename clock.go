@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the bus the current time. Everything the bus stamps, compares or measures reads
+// it: event times from ProduceNew, TTL expiry, latency, the rate limit, circuit breaker
+// cooldowns, retry budgets, windows, rate samples, stall detection and the watchdog. Timers and
+// tickers still run on the wall clock, so a Clock changes what the bus sees when it wakes up,
+// not when it does.
+type Clock interface {
+	Now() time.Time
+}
+
+// clockPoll bounds how long a goroutine waiting for a time on the bus clock, such as the
+// scheduler of ProduceAt, sleeps on a bus built WithClock, whose clock may jump ahead without
+// waking it
+const clockPoll = 10 * time.Millisecond
+
+// realClock is the wall clock every bus uses unless built WithClock
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// WithClock makes the bus read the current time from c instead of the wall clock, so tests can
+// control TTL expiry, latency, windowing and the like with a FakeClock. A nil c keeps the wall
+// clock.
+func WithClock(c Clock) Option {
+	return func(cfg *busConfig) {
+		cfg.clock = c
+	}
+}
+
+// clockFor returns the clock configured by cfg
+func clockFor(cfg busConfig) Clock {
+	if cfg.clock == nil {
+		return realClock{}
+	}
+	return cfg.clock
+}
+
+// now returns the current time on the bus clock
+func (bus *MainBus[T]) now() time.Time {
+	return bus.clock.Now()
+}
+
+// since returns the time elapsed since t on the bus clock
+func (bus *MainBus[T]) since(t time.Time) time.Duration {
+	return bus.clock.Now().Sub(t)
+}
+
+// waitUntil blocks until the bus clock reaches t, re-reading a clock set with WithClock every
+// clockPoll, and reports false if ctx is done first
+func (bus *MainBus[T]) waitUntil(ctx context.Context, t time.Time) bool {
+	_, wall := bus.clock.(realClock)
+	var timer *time.Timer
+	for {
+		wait := t.Sub(bus.now())
+		if wait <= 0 {
+			return true
+		}
+		if !wall {
+			wait = min(wait, clockPoll)
+		}
+		if timer == nil {
+			timer = time.NewTimer(wait)
+			defer timer.Stop()
+		} else {
+			timer.Reset(wait)
+		}
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
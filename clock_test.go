@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClockDrivesTTL(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin), WithTTL(time.Minute), WithDeadLetter(4), WithClock(clock))
+	defer bus.Close()
+	bus.Produce(Event[int]{ID: 1, Time: clock.Now()})                       // conveyor 0
+	bus.Produce(Event[int]{ID: 2, Time: clock.Now().Add(30 * time.Second)}) // conveyor 1
+	clock.Advance(time.Minute + time.Second)                                // 1 is now stale, 2 is not
+
+	if evs, err := CollectN(bus, 1, 1, time.Second); err != nil || evs[0].ID != 2 {
+		t.Fatalf("CollectN(1) = %v, %v; want event 2", evs, err)
+	}
+	if evs, _ := CollectN(bus, 0, 1, 20*time.Millisecond); len(evs) != 0 {
+		t.Fatalf("CollectN(0) = %v, want the stale event expired", evs)
+	}
+	if dl := <-bus.deadLetters; dl.Event.ID != 1 || dl.Reason != "expired" {
+		t.Fatalf("dead letter %+v, want event 1 expired", dl)
+	}
+}
+
+func TestFakeClockLatency(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("iron", WithStrategy(StrategyRoundRobin), WithClock(clock))
+	defer bus.Close()
+	bus.Use(ClockTimestampMiddleware[int](clock))
+	bus.Produce(Event[int]{ID: 1})
+	clock.Advance(250 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	got := make(chan Event[int], 1)
+	go bus.ConsumeWith(0, &wg, func(ev Event[int]) { got <- ev })
+	if ev := <-got; !ev.Time.Equal(epoch) {
+		t.Fatalf("event Time = %v, want the fake clock's %v", ev.Time, epoch)
+	}
+	bus.Close()
+	wg.Wait()
+	if s := bus.LatencyStats(0); s.Count != 1 || s.Max < 250*time.Millisecond || s.Min > 500*time.Millisecond {
+		t.Fatalf("LatencyStats = %+v, want one observation of about 250ms", s)
+	}
+}
+
+func TestFakeClockRateLimit(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("iron", WithBuffer(16), WithRateLimit(2), WithClock(clock))
+	defer bus.Close()
+	var placed int
+	for i := 0; i < 4; i++ {
+		if bus.TryProduce(Event[int]{ID: i}) {
+			placed++
+		}
+	}
+	// the bucket starts empty and earns nothing until the clock moves
+	if placed != 0 {
+		t.Fatalf("placed %d events before the clock moved, want 0", placed)
+	}
+	clock.Advance(time.Second)
+	for i := 0; i < 4; i++ {
+		if bus.TryProduce(Event[int]{ID: i}) {
+			placed++
+		}
+	}
+	if placed != 2 {
+		t.Fatalf("placed %d events after one second at 2/s, want 2", placed)
+	}
+}
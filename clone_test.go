@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCloneConfig(t *testing.T) {
+	src := NewMainBus[int]("iron", WithLines(4), WithBuffer(3), WithStrategy(StrategyRoundRobin),
+		WithOverflowPolicy(OverflowDropNewest), WithRateLimit(500))
+	defer src.Close()
+	src.Produce(Event[int]{ID: 1})
+	src.SetRate(250)
+
+	clone := src.CloneConfig("copper")
+	defer clone.Close()
+	if clone.Resource != "copper" || len(clone.Conveyors) != 4 || clone.Capacity(0) != 3 {
+		t.Fatalf("clone %q has %d conveyors of %d", clone.Resource, len(clone.Conveyors), clone.Capacity(0))
+	}
+	if clone.Strategy != StrategyRoundRobin || clone.overflow != OverflowDropNewest || clone.limiter.eventsPerSec() != 250 {
+		t.Fatalf("clone strategy %v, overflow %v, rate %d", clone.Strategy, clone.overflow, clone.limiter.eventsPerSec())
+	}
+	if d := clone.TotalDepth(); d != 0 {
+		t.Fatalf("clone holds %d events, want none", d)
+	}
+	if m := clone.Metrics(); m.Produced[0]+m.Produced[1]+m.Produced[2]+m.Produced[3] != 0 {
+		t.Fatalf("clone counters not fresh: %v", m.Produced)
+	}
+}
+
+func TestCloneConfigStrategyDetails(t *testing.T) {
+	weighted := NewMainBus[string]("iron", WithLines(4), WithWeights([]int{1, 0, 3, 2}))
+	defer weighted.Close()
+	weighted.RemoveConveyor(1)
+	clone := weighted.CloneConfig("copper")
+	defer clone.Close()
+	if clone.Strategy != StrategyWeighted {
+		t.Fatalf("clone strategy %v, want weighted", clone.Strategy)
+	}
+	if w := *clone.weights.Load(); len(w) != 4 || w[0] != 1 || w[1] != 3 || w[2] != 2 || w[3] != 1 {
+		t.Fatalf("clone weights %v, want those of the live lines", w)
+	}
+
+	keyed := NewMainBus[string]("iron", WithKeyFunc(func(ev Event[string]) string { return ev.Value }))
+	defer keyed.Close()
+	kc := keyed.CloneConfig("copper")
+	defer kc.Close()
+	if kc.Strategy != StrategyHashKey || kc.keyFunc == nil {
+		t.Fatal("clone lost the key func")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec turns events into bytes and back. The bus codec, set WithCodec, is used by the
+// persistence log, the HTTP handler, the WebSocket stream and the gRPC transport.
+type Codec[T any] interface {
+	Encode(ev Event[T]) ([]byte, error)
+	Decode(data []byte) (Event[T], error)
+}
+
+// JSONCodec encodes events as JSON via Event.MarshalJSON. It is the default codec.
+type JSONCodec[T any] struct{}
+
+// Encode implements Codec
+func (JSONCodec[T]) Encode(ev Event[T]) ([]byte, error) { return ev.MarshalJSON() }
+
+// Decode implements Codec
+func (JSONCodec[T]) Decode(data []byte) (Event[T], error) {
+	var ev Event[T]
+	err := json.Unmarshal(data, &ev)
+	return ev, err
+}
+
+// GobCodec encodes events with encoding/gob. Concrete types carried in interface values, such as
+// the Value of an Event[any], must be registered with gob.Register.
+type GobCodec[T any] struct{}
+
+// gobEvent mirrors Event with exported fields only, so gob never sees Event's JSON methods
+type gobEvent[T any] struct {
+	ID       int
+	Resource string
+	Value    T
+	Time     []byte
+	Priority int
+	Carrier  map[string]string
+
+	CorrelationID string
+}
+
+// Encode implements Codec
+func (GobCodec[T]) Encode(ev Event[T]) ([]byte, error) {
+	ts, err := ev.Time.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = gob.NewEncoder(&buf).Encode(gobEvent[T]{ev.ID, ev.Resource, ev.Value, ts, ev.Priority, ev.Carrier, ev.CorrelationID})
+	return buf.Bytes(), err
+}
+
+// Decode implements Codec
+func (GobCodec[T]) Decode(data []byte) (Event[T], error) {
+	var g gobEvent[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return Event[T]{}, err
+	}
+	ev := Event[T]{ID: g.ID, Resource: g.Resource, Value: g.Value, Priority: g.Priority, Carrier: g.Carrier, CorrelationID: g.CorrelationID}
+	err := ev.Time.UnmarshalBinary(g.Time)
+	return ev, err
+}
+
+// WithCodec sets the wire format used for events by persistence and the network transports.
+// The codec must be for the bus event type.
+func WithCodec[T any](c Codec[T]) Option {
+	return func(cfg *busConfig) {
+		cfg.codec = c
+	}
+}
+
+// codecFor returns the codec set by WithCodec, JSONCodec by default, or an error wrapping
+// ErrInvalidConfig if it was written for events of another type
+func codecFor[T any](cfg busConfig) (Codec[T], error) {
+	if cfg.codec == nil {
+		return JSONCodec[T]{}, nil
+	}
+	c, ok := cfg.codec.(Codec[T])
+	if !ok {
+		return JSONCodec[T]{}, fmt.Errorf("%w: codec %T does not encode Event[%T]", ErrInvalidConfig, cfg.codec, *new(T))
+	}
+	return c, nil
+}
+
+// textCodec reports whether c produces text that needs no further framing on a line or in a
+// text message
+func textCodec[T any](c Codec[T]) bool {
+	_, ok := c.(JSONCodec[T])
+	return ok
+}
+
+// codecName identifies a codec in log headers
+func codecName[T any](c Codec[T]) string {
+	switch c.(type) {
+	case JSONCodec[T]:
+		return "json"
+	case GobCodec[T]:
+		return "gob"
+	default:
+		return fmt.Sprintf("%T", c)
+	}
+}
+
+// encodeEvent encodes ev with codec and then compresses it with comp
+func encodeEvent[T any](codec Codec[T], comp Compression, ev Event[T]) ([]byte, error) {
+	data, err := codec.Encode(ev)
+	if err != nil {
+		return nil, err
+	}
+	return compress(comp, data)
+}
+
+// decodeEvent reverses encodeEvent
+func decodeEvent[T any](codec Codec[T], comp Compression, data []byte) (Event[T], error) {
+	data, err := decompress(comp, data)
+	if err != nil {
+		return Event[T]{}, err
+	}
+	return codec.Decode(data)
+}
+
+// encodeLine encodes ev as one line of the persistence log: uncompressed, unencrypted text codecs
+// are written as is and anything else base64-encoded
+func (bus *MainBus[T]) encodeLine(ev Event[T]) ([]byte, error) {
+	data, err := encodeEvent(bus.codec, bus.compression, ev)
+	if err != nil {
+		return nil, err
+	}
+	if bus.aead == nil {
+		if textCodec(bus.codec) && bus.compression == CompressionNone {
+			return data, nil
+		}
+	} else if data, err = sealRecord(bus.aead, data); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.AppendEncode(nil, data), nil
+}
+
+// decodeLine reverses encodeLine for a log compressed with c, and encrypted under the bus key if
+// encrypted is set
+func (bus *MainBus[T]) decodeLine(c Compression, encrypted bool, line []byte) (Event[T], error) {
+	if textCodec(bus.codec) && c == CompressionNone && !encrypted {
+		return bus.codec.Decode(line)
+	}
+	data, err := base64.StdEncoding.AppendDecode(nil, line)
+	if err != nil {
+		return Event[T]{}, err
+	}
+	if encrypted {
+		if data, err = openRecord(bus.aead, data); err != nil {
+			return Event[T]{}, err
+		}
+	}
+	return decodeEvent(bus.codec, c, data)
+}
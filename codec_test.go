@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// shipment is a non-trivial payload for codec round trips
+type shipment struct {
+	Item   string
+	Counts map[string]int
+	Route  []string
+	Sealed bool
+}
+
+func init() {
+	gob.Register(shipment{})
+	RegisterValueType("shipment", shipment{})
+}
+
+// roundTrip encodes and decodes ev with c, failing unless it comes back unchanged
+func roundTrip[T any](t *testing.T, c Codec[T], ev Event[T]) {
+	t.Helper()
+	data, err := c.Encode(ev)
+	if err != nil {
+		t.Fatalf("%T Encode: %v", c, err)
+	}
+	got, err := c.Decode(data)
+	if err != nil {
+		t.Fatalf("%T Decode: %v", c, err)
+	}
+	if !got.Time.Equal(ev.Time) {
+		t.Fatalf("%T time %v, want %v", c, got.Time, ev.Time)
+	}
+	got.Time = ev.Time
+	if !reflect.DeepEqual(got, ev) {
+		t.Fatalf("%T round trip = %+v, want %+v", c, got, ev)
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	stamp := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	typed := Event[shipment]{ID: 1, Resource: "iron", Time: stamp, Priority: 2,
+		Value:   shipment{Item: "plate", Counts: map[string]int{"a": 1, "b": 2}, Route: []string{"smelter", "assembler"}, Sealed: true},
+		Carrier: map[string]string{"traceparent": "00-abc-def-01"}, CorrelationID: "order-1"}
+	boxed := Event[any]{ID: 2, Resource: "copper", Time: stamp, Value: shipment{Item: "wire", Route: []string{"x"}}}
+	for _, c := range []Codec[shipment]{JSONCodec[shipment]{}, GobCodec[shipment]{}} {
+		roundTrip(t, c, typed)
+	}
+	for _, c := range []Codec[any]{JSONCodec[any]{}, GobCodec[any]{}} {
+		roundTrip(t, c, boxed)
+	}
+}
+
+func TestWithCodecMismatch(t *testing.T) {
+	if _, err := NewMainBusChecked[int]("iron", WithCodec[string](GobCodec[string]{})); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched codec: %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestGobCodecPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	ev := Event[shipment]{ID: 7, Resource: "iron", Value: shipment{Item: "gear\nwheel", Counts: map[string]int{"teeth": 12}}}
+	bus := NewMainBus[shipment]("iron", WithPersistence(path), WithCodec[shipment](GobCodec[shipment]{}))
+	bus.Produce(ev)
+	bus.Close()
+
+	restored := NewMainBus[shipment]("iron", WithCodec[shipment](GobCodec[shipment]{}))
+	defer restored.Close()
+	if err := ReplayFile(path, restored); err != nil {
+		t.Fatalf("ReplayFile: %v", err)
+	}
+	var got Event[shipment]
+	for _, c := range restored.Conveyors {
+		if len(c) > 0 {
+			got = <-c
+		}
+	}
+	got.Time = ev.Time
+	if !reflect.DeepEqual(got, ev) {
+		t.Fatalf("replayed %+v, want %+v", got, ev)
+	}
+}
+
+func TestGobCodecTransports(t *testing.T) {
+	gobc := GobCodec[string]{}
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(4), WithCodec[string](gobc))
+	bus.RemoveConveyor(1)
+
+	srv := httptest.NewServer(BusHTTPHandler(bus))
+	defer srv.Close()
+	body, _ := gobc.Encode(Event[string]{ID: 1, Value: "over http"})
+	resp, err := http.Post(srv.URL+"/produce", "application/octet-stream", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /produce: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("gob event over HTTP: %d, want 202", resp.StatusCode)
+	}
+
+	addr, _ := serveBus(t, bus, "")
+	client := dial(t, addr, WithClientCodec[string](gobc))
+	defer client.Close()
+	if err := client.Produce(Event[string]{ID: 2, Value: "over grpc"}); err != nil {
+		t.Fatalf("Produce over gRPC: %v", err)
+	}
+	if err := dial(t, addr).Produce(Event[string]{ID: 3}); err == nil {
+		t.Fatal("a JSON client was accepted by a gob server")
+	}
+	got := make(chan Event[string], 2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go client.ConsumeWith(0, &wg, func(ev Event[string]) { got <- ev })
+	for _, want := range []string{"over http", "over grpc"} {
+		select {
+		case ev := <-got:
+			if ev.Value != want || ev.Resource != "iron" {
+				t.Fatalf("consumed %+v, want %q", ev, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("never consumed %q", want)
+		}
+	}
+	bus.Close()
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ConsumeCommit consumes a specific conveyor for a transactional sink that tracks its own
+// offset: process handles each event and, after every batchSize (at least 1) successes, commit
+// is called with the highest event ID processed so far, so the sink can checkpoint it. Once the
+// conveyor is closed and drained the successes since the last commit are committed too. When
+// process or commit returns an error the consumer stops taking events, commits nothing more and
+// returns that error, wrapped with the conveyor and event ID, leaving the rest of the conveyor
+// buffered; it returns nil after a clean close. A process that panics is logged as with
+// ConsumeWith and counts as neither a success nor an error.
+//
+// Delivery is at-least-once: a crash after process succeeded but before the next commit loses
+// the record of up to batchSize events, which a sink resuming from its last committed ID, with
+// ReplayFile and SeekTo for instance, sees again, so process should be idempotent. wg is marked
+// done once the final commit has returned.
+func (bus *MainBus[T]) ConsumeCommit(line int, wg *sync.WaitGroup, process func(Event[T]) error, commit func(lastID int) error, batchSize int) error {
+	defer wg.Done()
+	batchSize = max(batchSize, 1)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	var err error
+	last, uncommitted := 0, 0
+	var consumer sync.WaitGroup
+	consumer.Add(1)
+	bus.ConsumeContext(ctx, line, &consumer, func(ev Event[T]) {
+		if perr := process(ev); perr != nil {
+			err = fmt.Errorf("main bus %q: conveyor %d: processing event %d: %w", bus.Resource, line, ev.ID, perr)
+			stop()
+			return
+		}
+		last = max(last, ev.ID)
+		if uncommitted++; uncommitted < batchSize {
+			return
+		}
+		uncommitted = 0
+		if cerr := commit(last); cerr != nil {
+			err = fmt.Errorf("main bus %q: conveyor %d: committing through event %d: %w", bus.Resource, line, last, cerr)
+			stop()
+		}
+	})
+	if err != nil || uncommitted == 0 {
+		return err
+	}
+	if cerr := commit(last); cerr != nil {
+		return fmt.Errorf("main bus %q: conveyor %d: committing through event %d: %w", bus.Resource, line, last, cerr)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// commitBus returns a single-conveyor bus holding events with IDs 1 to n, closed
+func commitBus(n int) *MainBus[int] {
+	bus := NewMainBus[int]("iron", WithBuffer(n))
+	bus.RemoveConveyor(1)
+	for id := 1; id <= n; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	return bus
+}
+
+func TestConsumeCommitBoundaries(t *testing.T) {
+	bus := commitBus(7)
+	bus.Close()
+	var processed, commits []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err := bus.ConsumeCommit(0, &wg, func(ev Event[int]) error {
+		processed = append(processed, ev.ID)
+		return nil
+	}, func(last int) error {
+		commits = append(commits, last)
+		return nil
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if len(processed) != 7 {
+		t.Fatalf("processed %v, want 1 to 7", processed)
+	}
+	if got := fmt.Sprint(commits); got != "[3 6 7]" {
+		t.Fatalf("committed %s, want [3 6 7]", got)
+	}
+}
+
+func TestConsumeCommitStopsOnProcessError(t *testing.T) {
+	bus := commitBus(7)
+	defer bus.Close()
+	failed := errors.New("sink rejected event")
+	var commits []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err := bus.ConsumeCommit(0, &wg, func(ev Event[int]) error {
+		if ev.ID == 5 {
+			return failed
+		}
+		return nil
+	}, func(last int) error {
+		commits = append(commits, last)
+		return nil
+	}, 2)
+	if !errors.Is(err, failed) {
+		t.Fatalf("returned %v, want the process error", err)
+	}
+	if got := fmt.Sprint(commits); got != "[2 4]" {
+		t.Fatalf("committed %s, want [2 4]", got)
+	}
+	if got := bus.Depth(0); got != 2 {
+		t.Fatalf("%d events left buffered after the failure, want 2", got)
+	}
+}
+
+func TestConsumeCommitStopsOnCommitError(t *testing.T) {
+	bus := commitBus(6)
+	defer bus.Close()
+	failed := errors.New("offset store down")
+	processed := 0
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err := bus.ConsumeCommit(0, &wg, func(Event[int]) error {
+		processed++
+		return nil
+	}, func(int) error { return failed }, 2)
+	if !errors.Is(err, failed) {
+		t.Fatalf("returned %v, want the commit error", err)
+	}
+	if processed != 2 {
+		t.Fatalf("processed %d events, want 2 before the failed commit", processed)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"id":1,"resource":"iron","value":"plate"}`), 50)
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		packed, err := compress(c, data)
+		if err != nil {
+			t.Fatalf("%s compress: %v", c, err)
+		}
+		if c != CompressionNone && len(packed) >= len(data) {
+			t.Fatalf("%s did not shrink repetitive data: %d >= %d", c, len(packed), len(data))
+		}
+		got, err := decompress(c, packed)
+		if err != nil {
+			t.Fatalf("%s decompress: %v", c, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%s round trip changed the data", c)
+		}
+		if parsed, err := parseCompression(c.String()); err != nil || parsed != c {
+			t.Fatalf("parseCompression(%q) = %v, %v", c.String(), parsed, err)
+		}
+	}
+	if _, err := parseCompression("lz4"); err == nil {
+		t.Fatal("parseCompression accepted lz4")
+	}
+}
+
+func TestCompressedPersistence(t *testing.T) {
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(c.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.log")
+			bus := NewMainBus[string]("iron", WithPersistence(path), WithCompression(c))
+			for i := 1; i <= 3; i++ {
+				bus.Produce(Event[string]{ID: i, Value: "plate"})
+			}
+			bus.Close()
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc := bufio.NewScanner(f)
+			sc.Scan()
+			header := sc.Text()
+			f.Close()
+			if want := "#mainbus codec=json compression=" + c.String(); header != want {
+				t.Fatalf("header %q, want %q", header, want)
+			}
+
+			// the replay target does not compress: the header drives decompression
+			restored := NewMainBus[string]("iron", WithLines(1), WithBuffer(8))
+			defer restored.Close()
+			restored.RemoveConveyor(1)
+			if err := ReplayFile(path, restored); err != nil {
+				t.Fatalf("ReplayFile: %v", err)
+			}
+			for i := 1; i <= 3; i++ {
+				ev := <-restored.Conveyors[0]
+				if ev.ID != i || ev.Value != "plate" {
+					t.Fatalf("replayed %+v, want ID %d", ev, i)
+				}
+			}
+
+			// a bus writing another format must not append to the log
+			_, err = NewMainBusChecked[string]("iron", WithPersistence(path))
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("reopening a %s log uncompressed: %v, want ErrInvalidConfig", c, err)
+			}
+		})
+	}
+}
+
+func TestCompressedTransports(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(4), WithCompression(CompressionZstd))
+	bus.RemoveConveyor(1)
+
+	srv := httptest.NewServer(BusHTTPHandler(bus))
+	defer srv.Close()
+	body, _ := encodeEvent[string](JSONCodec[string]{}, CompressionGzip, Event[string]{ID: 1, Value: "over http"})
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/produce", bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /produce: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("gzip body over HTTP: %d, want 202", resp.StatusCode)
+	}
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/produce", strings.NewReader(`{}`))
+	req.Header.Set("Content-Encoding", "br")
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatalf("POST /produce: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("unknown Content-Encoding: %d, want 415", resp.StatusCode)
+	}
+
+	addr, _ := serveBus(t, bus, "")
+	client := dial(t, addr, WithClientCompression[string](CompressionGzip))
+	defer client.Close()
+	if err := client.Produce(Event[string]{ID: 2, Value: "over grpc"}); err != nil {
+		t.Fatalf("Produce over gRPC: %v", err)
+	}
+	// the server streams zstd; a client sending uncompressed events still reads them
+	reader := dial(t, addr)
+	defer reader.Close()
+	got := make(chan Event[string], 2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go reader.ConsumeWith(0, &wg, func(ev Event[string]) { got <- ev })
+	for _, want := range []string{"over http", "over grpc"} {
+		select {
+		case ev := <-got:
+			if ev.Value != want {
+				t.Fatalf("consumed %+v, want %q", ev, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("never consumed %q", want)
+		}
+	}
+	bus.Close()
+	wg.Wait()
+}
+
+// benchmarkPersist produces b.N events onto a persisted bus compressed with c and reports the
+// log size per event
+func benchmarkPersist(b *testing.B, c Compression) {
+	path := filepath.Join(b.TempDir(), "events.log")
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(1024), WithPersistence(path), WithCompression(c))
+	bus.RemoveConveyor(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(Event[string]) {})
+	value := strings.Repeat("iron plate ", 8)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bus.Produce(Event[string]{ID: i, Value: value})
+	}
+	b.StopTimer()
+	bus.Close()
+	wg.Wait()
+	if fi, err := os.Stat(path); err == nil {
+		b.ReportMetric(float64(fi.Size())/float64(b.N), "bytes/event")
+	}
+}
+
+func BenchmarkPersistUncompressed(b *testing.B) { benchmarkPersist(b, CompressionNone) }
+func BenchmarkPersistGzip(b *testing.B)         { benchmarkPersist(b, CompressionGzip) }
+func BenchmarkPersistZstd(b *testing.B)         { benchmarkPersist(b, CompressionZstd) }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConsumerInfo describes one attached consumer, as listed by ListConsumers
+type ConsumerInfo struct {
+	ID        uint64    `json:"id"`             // unique for the bus, in attach order
+	Name      string    `json:"name,omitempty"` // given with WithConsumerName
+	Line      int       `json:"line"`
+	Started   time.Time `json:"started"`
+	Processed uint64    `json:"processed"` // events taken off the conveyor and delivered or diverted
+}
+
+// consumer is a registered consumer of one conveyor. A consumer reading several conveyors, such
+// as ConsumeAll, registers once per conveyor.
+type consumer struct {
+	id        uint64
+	name      string
+	line      int
+	started   time.Time
+	processed atomic.Uint64
+	detach    func()
+}
+
+// done counts one event the consumer took off its conveyor
+func (c *consumer) done() { c.processed.Add(1) }
+
+// consumerSet tracks the consumers attached to a bus
+type consumerSet struct {
+	mu   sync.Mutex
+	next uint64
+	live map[uint64]*consumer
+}
+
+// add registers c, giving it an ID
+func (s *consumerSet) add(c *consumer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.live == nil {
+		s.live = make(map[uint64]*consumer)
+	}
+	s.next++
+	c.id = s.next
+	s.live[c.id] = c
+}
+
+// remove deregisters c
+func (s *consumerSet) remove(c *consumer) {
+	s.mu.Lock()
+	delete(s.live, c.id)
+	s.mu.Unlock()
+}
+
+// ListConsumers returns the consumers attached to the bus, sorted by conveyor and then by attach
+// order, with how many events each has processed so far. Consumers register when their Consume
+// method starts and deregister when it returns; one reading several conveyors is listed once per
+// conveyor, as counted by Consumers. The list is a point-in-time view.
+func (bus *MainBus[T]) ListConsumers() []ConsumerInfo {
+	bus.consumerSet.mu.Lock()
+	infos := make([]ConsumerInfo, 0, len(bus.consumerSet.live))
+	for _, c := range bus.consumerSet.live {
+		infos = append(infos, ConsumerInfo{
+			ID:        c.id,
+			Name:      c.name,
+			Line:      c.line,
+			Started:   c.started,
+			Processed: c.processed.Load(),
+		})
+	}
+	bus.consumerSet.mu.Unlock()
+	slices.SortFunc(infos, func(a, b ConsumerInfo) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.ID, b.ID))
+	})
+	return infos
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListConsumers(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin), WithClock(clock))
+	defer bus.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go bus.ConsumeWith(1, &wg, func(Event[int]) {}, WithConsumerName("smelter"))
+	if err := bus.WaitForConsumers(1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	go bus.ConsumeWith(0, &wg, func(Event[int]) {})
+	if err := bus.WaitForConsumers(2, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	if err := bus.Drain(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	// Drain waits for the events to be taken off, not for the count after each handler
+	deadline := time.Now().Add(time.Second)
+	var infos []ConsumerInfo
+	for {
+		infos = bus.ListConsumers()
+		if len(infos) == 2 && infos[0].Processed == 3 && infos[1].Processed == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("listed %d consumers, want 2", len(infos))
+	}
+	if c := infos[0]; c.Line != 0 || c.Name != "" || c.Processed != 3 || !c.Started.Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("line 0 consumer %+v, want unnamed, 3 processed, started a minute in", c)
+	}
+	if c := infos[1]; c.Line != 1 || c.Name != "smelter" || c.Processed != 2 || !c.Started.Equal(epoch) {
+		t.Fatalf("line 1 consumer %+v, want smelter, 2 processed, started at the epoch", c)
+	}
+	if infos[1].ID >= infos[0].ID {
+		t.Fatalf("IDs %d and %d do not follow attach order", infos[1].ID, infos[0].ID)
+	}
+
+	bus.Close()
+	wg.Wait()
+	if infos := bus.ListConsumers(); len(infos) != 0 {
+		t.Fatalf("%d consumers still listed after they returned", len(infos))
+	}
+}
+
+func TestListConsumersPerConveyor(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeAll(&wg, func(int, Event[int]) {})
+	if err := bus.WaitForConsumers(2, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	bus.Produce(Event[int]{ID: 1})
+	bus.Close()
+	wg.Wait()
+	if infos := bus.ListConsumers(); len(infos) != 0 {
+		t.Fatalf("%d consumers still listed after ConsumeAll returned", len(infos))
+	}
+}
+
+func TestListConsumersConcurrent(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2))
+	defer bus.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := bus.attachAs(i%2, "worker")
+			bus.ListConsumers()
+			c.done()
+			c.detach()
+		}()
+	}
+	wg.Wait()
+	if infos := bus.ListConsumers(); len(infos) != 0 {
+		t.Fatalf("%d consumers still listed", len(infos))
+	}
+	if n := bus.Consumers(); n != 0 {
+		t.Fatalf("Consumers() = %d after every consumer detached", n)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// correlationGroup is the events of one CorrelationID seen so far
+type correlationGroup[T any] struct {
+	events   []Event[T]
+	deadline time.Time // when the group times out, timeout after its first event
+}
+
+// ConsumeCorrelated consumes a specific conveyor, gathering events by their CorrelationID to
+// assemble multi-part workflows. After each event joins its group, isComplete is called with
+// the group's events in arrival order; once it returns true the group is passed to
+// onGroupComplete and forgotten, so a later event with the same ID starts a new group. A group
+// still incomplete timeout after its first event arrived is given up on: each of its events is
+// rejected onto the dead-letter conveyor, or counted as dropped without one or once the bus is
+// closed. So are events with no CorrelationID and the groups still open when the conveyor
+// closes or Poison stops the consumer. Each event goes through the same pipeline as ConsumeWith
+// before joining its group. Both callbacks run on the consumer goroutine; a panicking
+// onGroupComplete is recovered and logged, and the group is lost. A non-positive timeout panics.
+func (bus *MainBus[T]) ConsumeCorrelated(line int, wg *sync.WaitGroup, onGroupComplete func(id string, evs []Event[T]), isComplete func([]Event[T]) bool, timeout time.Duration) {
+	if timeout <= 0 {
+		panic("main bus: ConsumeCorrelated needs a positive timeout")
+	}
+	defer wg.Done()
+	self := bus.attach(line)
+	defer self.detach()
+	c := bus.conveyor(line)
+
+	groups := make(map[string]*correlationGroup[T])
+	giveUp := func(id string, g *correlationGroup[T], why string) {
+		delete(groups, id)
+		reason := fmt.Sprintf("correlation group %q %s with %d events", id, why, len(g.events))
+		for _, ev := range g.events {
+			bus.rejectOrDrop(line, ev, reason)
+		}
+	}
+	add := func(ev Event[T]) {
+		id := ev.CorrelationID
+		if id == "" {
+			bus.rejectOrDrop(line, ev, "no correlation ID")
+			return
+		}
+		g, ok := groups[id]
+		if !ok {
+			g = &correlationGroup[T]{deadline: bus.now().Add(timeout)}
+			groups[id] = g
+		}
+		g.events = append(g.events, ev)
+		if isComplete(g.events) {
+			delete(groups, id)
+			bus.handleBatch(line, g.events, func(evs []Event[T]) { onGroupComplete(id, evs) })
+		}
+	}
+	// expire gives up on the timed-out groups and returns how long until the next one times out
+	expire := func() time.Duration {
+		now := bus.now()
+		next := time.Duration(-1)
+		for id, g := range groups {
+			left := g.deadline.Sub(now)
+			if left <= 0 {
+				giveUp(id, g, "timed out")
+				continue
+			}
+			if next < 0 || left < next {
+				next = left
+			}
+		}
+		return next
+	}
+
+	timer := time.NewTimer(timeout)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		if bus.Paused(line) {
+			bus.waitResumed(context.Background(), line)
+		}
+		select {
+		case ev, ok := <-c:
+			if !ok && bus.reopened(line, &c) {
+				continue
+			}
+			if !ok || bus.poisoned(line, ev) {
+				for id, g := range groups {
+					giveUp(id, g, "left open by the stopped consumer")
+				}
+				return
+			}
+			bus.deliver(line, ev, add)
+			self.done()
+		case <-timer.C:
+		}
+		timer.Stop()
+		if next := expire(); next >= 0 {
+			timer.Reset(next)
+		}
+	}
+}
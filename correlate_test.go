@@ -0,0 +1,100 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// firstLine routes every event to line 0
+type firstLine[T any] struct{}
+
+func (firstLine[T]) Select(*MainBus[T], Event[T]) int { return 0 }
+
+func TestConsumeCorrelatedAssemblesGroup(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(2), WithRoutingStrategy[string](firstLine[string]{}), WithDeadLetter(8))
+	type group struct {
+		id    string
+		parts []string
+	}
+	groups := make(chan group, 4)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeCorrelated(0, &wg, func(id string, evs []Event[string]) {
+		parts := make([]string, len(evs))
+		for i, ev := range evs {
+			parts[i] = ev.Value
+		}
+		groups <- group{id, parts}
+	}, func(evs []Event[string]) bool { return len(evs) == 3 }, time.Second)
+
+	// two orders interleaved on the belt
+	for i, p := range []struct{ id, part string }{
+		{"order-1", "reserve"}, {"order-2", "reserve"}, {"order-1", "charge"},
+		{"order-2", "charge"}, {"order-1", "ship"},
+	} {
+		bus.Produce(Event[string]{ID: i + 1, Value: p.part, CorrelationID: p.id})
+	}
+	select {
+	case g := <-groups:
+		if g.id != "order-1" || !slices.Equal(g.parts, []string{"reserve", "charge", "ship"}) {
+			t.Fatalf("group %q %v, want order-1 reserve, charge, ship", g.id, g.parts)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("3-part group was not assembled")
+	}
+	select {
+	case g := <-groups:
+		t.Fatalf("incomplete group %q %v emitted", g.id, g.parts)
+	default:
+	}
+
+	// order-2 is left open when the conveyor closes and goes to the dead-letter conveyor
+	if err := bus.CloseConveyor(0); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	bus.Close()
+	var dead []int
+	for dl := range bus.deadLetters {
+		dead = append(dead, dl.Event.ID)
+	}
+	slices.Sort(dead)
+	if !slices.Equal(dead, []int{2, 4}) {
+		t.Errorf("dead-lettered %v, want the two parts of order-2", dead)
+	}
+}
+
+func TestConsumeCorrelatedTimesOut(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(2), WithStrategy(StrategyRoundRobin), WithDeadLetter(8))
+	bus.RemoveConveyor(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeCorrelated(0, &wg, func(id string, evs []Event[string]) {
+		t.Errorf("group %q completed with %d events", id, len(evs))
+	}, func(evs []Event[string]) bool { return len(evs) == 3 }, 20*time.Millisecond)
+	defer func() {
+		bus.Close()
+		wg.Wait()
+	}()
+
+	bus.Produce(Event[string]{ID: 1, Value: "reserve", CorrelationID: "order-1"})
+	bus.Produce(Event[string]{ID: 2, Value: "charge", CorrelationID: "order-1"})
+	bus.Produce(Event[string]{ID: 3, Value: "stray"})
+	reasons := make(map[int]string)
+	for len(reasons) < 3 {
+		select {
+		case dl := <-bus.deadLetters:
+			reasons[dl.Event.ID] = dl.Reason
+		case <-time.After(time.Second):
+			t.Fatalf("dead letters %v, want all three events", reasons)
+		}
+	}
+	if want := `correlation group "order-1" timed out with 2 events`; reasons[1] != want || reasons[2] != want {
+		t.Errorf("partial group reasons %q and %q, want %q", reasons[1], reasons[2], want)
+	}
+	if reasons[3] != "no correlation ID" {
+		t.Errorf("uncorrelated event reason %q", reasons[3])
+	}
+}
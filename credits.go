@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// creditPoll is how often a parked credit pipe checks the destination for room again
+const creditPoll = time.Millisecond
+
+// PipeWithCredits is Pipe with credit-based flow control: dst grants one credit for every slot
+// free on its fullest live conveyor, less the events the stage is already forwarding, and the
+// stage forwards an event only once it holds a credit. Without credits it parks, holding the
+// event it has taken, so src stops being consumed and fills up instead of the stage piling
+// events up in blocked sends; whichever conveyor dst's strategy then picks has room, so an
+// overflow policy on dst never drops a forwarded event. Granting from the fullest conveyor
+// keeps this true for every strategy, at the cost of forwarding less eagerly to a bus whose
+// conveyors are unevenly filled. An unbuffered conveyor grants one credit while it is empty.
+// Producers other than the stage can still fill dst between a grant and the send, in which case
+// the send waits or is dropped as with Pipe. Stage.Credits reports the credits available.
+func PipeWithCredits[A, B any](src *MainBus[A], dst *MainBus[B], transform func(Event[A]) (Event[B], bool)) *Stage {
+	gate := &creditGate[B]{dst: dst}
+	stage := pipeWith(src, func(ev Event[A]) (*MainBus[B], Event[B], bool) {
+		out, ok := transform(ev)
+		return dst, out, ok
+	}, gate)
+	stage.credits = gate.available
+	return stage
+}
+
+// creditGate hands out credits for sends to one destination bus
+type creditGate[B any] struct {
+	dst      *MainBus[B]
+	mu       sync.Mutex
+	inflight int // credits taken and not yet returned
+}
+
+// free returns the room left on dst's fullest live conveyor
+func (g *creditGate[B]) free() int {
+	t := g.dst.table()
+	room := -1
+	for _, line := range t.live {
+		b := t.belts[line]
+		n := b.capacity() - b.depth()
+		if b.capacity() == 0 && b.depth() == 0 {
+			n = 1
+		}
+		if room < 0 || n < room {
+			room = n
+		}
+	}
+	return max(room, 0)
+}
+
+// available returns the credits dst grants right now
+func (g *creditGate[B]) available() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return max(g.free()-g.inflight, 0)
+}
+
+// acquire parks until a credit is available and takes it. It returns ctx.Err() if ctx is done
+// first and ErrBusClosed once dst is closed.
+func (g *creditGate[B]) acquire(ctx context.Context) error {
+	var ticker *time.Ticker
+	for {
+		if g.dst.isClosed() {
+			return ErrBusClosed
+		}
+		g.mu.Lock()
+		if g.free()-g.inflight > 0 {
+			g.inflight++
+			g.mu.Unlock()
+			return nil
+		}
+		g.mu.Unlock()
+		if ticker == nil {
+			ticker = time.NewTicker(creditPoll)
+			defer ticker.Stop()
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release returns a credit once the send it was taken for is over
+func (g *creditGate[B]) release() {
+	g.mu.Lock()
+	g.inflight--
+	g.mu.Unlock()
+}
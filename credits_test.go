@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func consumedTotal[T any](bus *MainBus[T]) uint64 {
+	var n uint64
+	for _, c := range bus.Metrics().Consumed {
+		n += c
+	}
+	return n
+}
+
+func TestPipeWithCreditsParksOnSlowDownstream(t *testing.T) {
+	src := NewMainBus[int]("src", WithLines(2), WithBuffer(64))
+	dst := NewMainBus[int]("dst", WithLines(2), WithBuffer(2), WithOverflowPolicy(OverflowDropNewest))
+	stage := PipeWithCredits(src, dst, func(ev Event[int]) (Event[int], bool) { return ev, true })
+	defer stage.Stop()
+
+	const n = 40
+	for i := range n {
+		if err := src.Produce(Event[int]{ID: i + 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// with nobody consuming dst, the stage fills it and parks
+	deadline := time.Now().Add(2 * time.Second)
+	for dst.TotalDepth() == 0 || stage.Credits() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("dst depth = %d with %d credits left", dst.TotalDepth(), stage.Credits())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	taken := consumedTotal(src)
+	time.Sleep(50 * time.Millisecond)
+	if now := consumedTotal(src); now != taken {
+		t.Errorf("src kept advancing while parked: %d then %d consumed", taken, now)
+	}
+	// one event forwarded per dst slot, plus one parked per src conveyor
+	if taken > 4+2 {
+		t.Errorf("src consumed %d events, want at most 6", taken)
+	}
+
+	// a slow consumer lets the rest through without any drop
+	var got atomic.Int64
+	var wg sync.WaitGroup
+	for line := range 2 {
+		wg.Add(1)
+		go dst.ConsumeWith(line, &wg, func(Event[int]) {
+			time.Sleep(time.Millisecond)
+			got.Add(1)
+		})
+	}
+	for got.Load() < n {
+		if time.Now().After(deadline.Add(5 * time.Second)) {
+			t.Fatalf("delivered %d of %d events", got.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	dst.Close()
+	wg.Wait()
+	for line, d := range dst.Metrics().Dropped {
+		if d != 0 {
+			t.Errorf("dst line %d dropped %d events", line, d)
+		}
+	}
+}
+
+func TestPipeWithCreditsCounts(t *testing.T) {
+	src := NewMainBus[int]("src")
+	dst := NewMainBus[int]("dst", WithLines(2), WithBuffer(5))
+	defer dst.Close()
+	stage := PipeWithCredits(src, dst, func(ev Event[int]) (Event[int], bool) { return ev, true })
+	if got := stage.Credits(); got != 5 {
+		t.Errorf("Credits() on an empty dst = %d, want 5", got)
+	}
+	src.Close()
+	<-stage.Done()
+
+	plain := Pipe(NewMainBus[int]("other"), dst, func(ev Event[int]) (Event[int], bool) { return ev, true })
+	defer plain.Stop()
+	if got := plain.Credits(); got != 0 {
+		t.Errorf("Credits() on a plain pipe = %d, want 0", got)
+	}
+}
+
+func TestPipeWithCreditsStopsWhenDestinationCloses(t *testing.T) {
+	src := NewMainBus[int]("src", WithBuffer(8))
+	dst := NewMainBus[int]("dst", WithBuffer(1))
+	stage := PipeWithCredits(src, dst, func(ev Event[int]) (Event[int], bool) { return ev, true })
+	for i := range 6 {
+		src.Produce(Event[int]{ID: i + 1})
+	}
+	time.Sleep(20 * time.Millisecond)
+	dst.Close()
+	select {
+	case <-stage.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("stage still parked after dst closed")
+	}
+}
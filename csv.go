@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// csvHeader is the first row written by ConsumeToCSV
+var csvHeader = []string{"ID", "Resource", "Value", "Time"}
+
+// ConsumeToCSV consumes a specific conveyor, writing a header row and then one row per event to
+// w as CSV. Strings, booleans and numbers are written as text and any other Value as JSON; Time
+// is RFC 3339 with nanoseconds, or empty when unset. Rows are flushed whenever the conveyor has
+// nothing more buffered, and once more when it is closed; w itself is not closed. After the
+// first write error the remaining events are still drained, so the conveyor does not back up,
+// but are counted as dropped, and that error is returned.
+func (bus *MainBus[T]) ConsumeToCSV(line int, wg *sync.WaitGroup, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	werr := cw.Write(csvHeader)
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		if werr == nil {
+			if werr = writeCSVRow(cw, ev); werr == nil && len(bus.conveyor(line)) == 0 {
+				cw.Flush()
+				werr = cw.Error()
+			}
+		}
+		if werr != nil {
+			bus.drop(slog.LevelWarn, line, ev, "csv write failed", slog.Any("error", werr))
+		}
+	})
+	if werr == nil {
+		cw.Flush()
+		werr = cw.Error()
+	}
+	if werr != nil {
+		return fmt.Errorf("main bus %q: writing csv: %w", bus.Resource, werr)
+	}
+	return nil
+}
+
+// writeCSVRow writes one event as a CSV row
+func writeCSVRow[T any](cw *csv.Writer, ev Event[T]) error {
+	value, err := csvValue(ev.Value)
+	if err != nil {
+		return fmt.Errorf("event %d value: %w", ev.ID, err)
+	}
+	ts := ""
+	if !ev.Time.IsZero() {
+		ts = ev.Time.Format(time.RFC3339Nano)
+	}
+	return cw.Write([]string{strconv.Itoa(ev.ID), ev.Resource, value, ts})
+}
+
+// csvValue renders an event value for a CSV cell
+func csvValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsumeToCSV(t *testing.T) {
+	stamp := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+	bus := NewMainBus[any]("iron", WithLines(1), WithBuffer(4))
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[any]{ID: 1, Resource: "iron", Value: "plate, rolled", Time: stamp})
+	bus.Produce(Event[any]{ID: 2, Resource: "iron", Value: 42})
+	bus.Produce(Event[any]{ID: 3, Resource: "copper", Value: map[string]any{"purity": 0.99}, Time: stamp})
+	bus.Close()
+
+	var buf bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+	if err := bus.ConsumeToCSV(0, &wg, &buf); err != nil {
+		t.Fatalf("ConsumeToCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading csv back: %v", err)
+	}
+	want := [][]string{
+		{"ID", "Resource", "Value", "Time"},
+		{"1", "iron", "plate, rolled", "2024-03-01T12:30:00.0000005Z"},
+		{"2", "iron", "42", ""},
+		{"3", "copper", `{"purity":0.99}`, "2024-03-01T12:30:00.0000005Z"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, rows[1][3])
+	if err != nil || !parsed.Equal(stamp) {
+		t.Fatalf("time %q does not round-trip: %v", rows[1][3], err)
+	}
+}
+
+// failWriter fails every write
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestConsumeToCSVWriteError(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(4))
+	bus.RemoveConveyor(1)
+	for i := 0; i < 3; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	bus.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	if err := bus.ConsumeToCSV(0, &wg, failWriter{}); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("ConsumeToCSV = %v, want the write error", err)
+	}
+	if m := bus.Metrics(); m.Depth[0] != 0 || m.Dropped[0] == 0 {
+		t.Fatalf("depth %d, dropped %d; want the conveyor drained and drops counted", m.Depth[0], m.Dropped[0])
+	}
+}
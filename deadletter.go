@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// ErrNoDeadLetter is returned by Reject when the bus was built without WithDeadLetter
+var ErrNoDeadLetter = errors.New("main bus has no dead-letter conveyor")
+
+// ErrDeadLetterFull is returned by Reject when the dead-letter conveyor is full and its overflow
+// policy discarded the event
+var ErrDeadLetterFull = errors.New("main bus dead-letter conveyor is full")
+
+// WithDeadLetterOverflow sets what Reject does when the dead-letter conveyor is full: wait for
+// room (OverflowBlock, the default), discard the rejected event (OverflowDropNewest) or discard
+// the oldest dead letter to make room (OverflowDropOldest). Every discarded dead letter is
+// counted in BusMetrics.DeadLetterDropped, logged at error level and passed to the bus
+// OnDeadLetterDropped hook. That counter should be alerted on whenever it moves: events are
+// failing faster than anything handles the failures, so they are being lost for good.
+func WithDeadLetterOverflow(p OverflowPolicy) Option {
+	return func(c *busConfig) {
+		c.deadLetterOverflow = p
+	}
+}
+
+// DeadLetter is a rejected event together with the reason it was rejected
+type DeadLetter[T any] struct {
+	Event  Event[T]
+	Reason string
+}
+
+// Reject parks an event on the dead-letter conveyor, blocking while it is full. Rejections keep
+// working while the bus drains. It returns ErrNoDeadLetter if the bus has no dead-letter conveyor
+// and ErrBusClosed once its conveyors are closed, in which case the event is discarded.
+func (bus *MainBus[T]) Reject(ev Event[T], reason string) error {
+	if bus.deadLetters == nil {
+		return ErrNoDeadLetter
+	}
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	if bus.closed {
+		return ErrBusClosed
+	}
+	dl := DeadLetter[T]{Event: ev, Reason: reason}
+	switch bus.deadLetterOverflow {
+	case OverflowDropNewest:
+		select {
+		case bus.deadLetters <- dl:
+		default:
+			bus.dropDeadLetter(dl)
+			return ErrDeadLetterFull
+		}
+	case OverflowDropOldest:
+		for sent := false; !sent; {
+			select {
+			case bus.deadLetters <- dl:
+				sent = true
+			default:
+				select {
+				case old := <-bus.deadLetters:
+					bus.dropDeadLetter(old)
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case bus.deadLetters <- dl:
+		case <-bus.closing:
+			return ErrBusClosed
+		}
+	}
+	bus.rejected.Add(1)
+	bus.logEvent(slog.LevelWarn, "event dead-lettered", -1, ev, slog.String("reason", reason))
+	dropped(ev, "dead-lettered: "+reason)
+	return nil
+}
+
+// dropDeadLetter accounts for a dead letter the full dead-letter conveyor could not keep
+func (bus *MainBus[T]) dropDeadLetter(dl DeadLetter[T]) {
+	bus.deadLetterDropped.Add(1)
+	bus.logEvent(slog.LevelError, "dead letter dropped", -1, dl.Event, slog.String("reason", dl.Reason))
+	dropped(dl.Event, "dead-letter conveyor full")
+	if bus.OnDeadLetterDropped != nil {
+		bus.OnDeadLetterDropped(dl.Event)
+	}
+}
+
+// ConsumeWithReject consumes a specific conveyor like ConsumeWith, but a handler returning an
+// error rejects the event onto the dead-letter conveyor with the error text as the reason. If
+// the event cannot be rejected (no dead-letter conveyor, or the bus is closed) it is logged and
+// counted as dropped on the line. WithCircuitBreaker stops calling a handler that keeps failing.
+func (bus *MainBus[T]) ConsumeWithReject(line int, wg *sync.WaitGroup, handler func(Event[T]) error, opts ...ConsumeOption) {
+	cb := newBreaker(newConsumeConfig(opts), bus.clock)
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		if !cb.allow() {
+			bus.rejectOrDrop(line, ev, "circuit open")
+			return
+		}
+		err := handler(ev)
+		cb.record(err == nil)
+		if err != nil {
+			bus.rejectOrDrop(line, ev, err.Error())
+		}
+	}, opts...)
+}
+
+// rejectOrDrop rejects ev, counting it as dropped on line if it cannot be rejected. An event the
+// full dead-letter conveyor discarded is already counted as a dropped dead letter.
+func (bus *MainBus[T]) rejectOrDrop(line int, ev Event[T], reason string) {
+	if rerr := bus.Reject(ev, reason); rerr != nil && !errors.Is(rerr, ErrDeadLetterFull) {
+		bus.drop(slog.LevelError, line, ev, reason, slog.Any("reject_error", rerr))
+	}
+}
+
+// ConsumeDeadLetters calls handler with each rejected event and its reason until the bus is
+// closed. It returns immediately if the bus has no dead-letter conveyor.
+func (bus *MainBus[T]) ConsumeDeadLetters(handler func(Event[T], string)) {
+	if bus.deadLetters == nil {
+		return
+	}
+	for dl := range bus.deadLetters {
+		handler(dl.Event, dl.Reason)
+	}
+}
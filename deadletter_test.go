@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// rejectOdd fails every odd event
+func rejectOdd(ev Event[int]) error {
+	if ev.ID%2 != 0 {
+		return errors.New("odd")
+	}
+	return nil
+}
+
+func TestConsumeWithRejectDeadLetters(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithDeadLetter(8))
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWithReject(line, &wg, rejectOdd)
+	}
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+
+	got := map[int]bool{}
+	for len(got) < 2 {
+		dl := <-bus.deadLetters
+		if dl.Reason != "odd" || dl.Event.ID%2 == 0 {
+			t.Fatalf("unexpected dead letter %+v", dl)
+		}
+		got[dl.Event.ID] = true
+	}
+	bus.Close()
+	wg.Wait()
+	if n := bus.Metrics().DeadLettered; n != 2 {
+		t.Fatalf("DeadLettered = %d, want 2", n)
+	}
+}
+
+func TestConsumeWithRejectCountsFailedRejects(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin))
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	bus.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeWithReject(1, &wg, rejectOdd)
+
+	if d := bus.Metrics().Dropped[1]; d != 2 {
+		t.Fatalf("dropped on line 1 = %d, want 2 without a dead-letter conveyor", d)
+	}
+}
+
+func TestDeadLetterOverflowDropNewest(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithDeadLetter(1), WithDeadLetterOverflow(OverflowDropNewest))
+	defer bus.Close()
+	var lost []int
+	bus.OnDeadLetterDropped = func(ev Event[int]) { lost = append(lost, ev.ID) }
+	if err := bus.Reject(Event[int]{ID: 1}, "bad"); err != nil {
+		t.Fatalf("first Reject: %v", err)
+	}
+	if err := bus.Reject(Event[int]{ID: 2}, "bad"); !errors.Is(err, ErrDeadLetterFull) {
+		t.Fatalf("second Reject = %v, want ErrDeadLetterFull", err)
+	}
+	if dl := <-bus.deadLetters; dl.Event.ID != 1 {
+		t.Fatalf("dead letter %d, want 1", dl.Event.ID)
+	}
+	if len(lost) != 1 || lost[0] != 2 {
+		t.Fatalf("OnDeadLetterDropped saw %v, want [2]", lost)
+	}
+	if m := bus.Metrics(); m.DeadLetterDropped != 1 || m.DeadLettered != 1 {
+		t.Fatalf("DeadLetterDropped = %d, DeadLettered = %d, want 1 and 1", m.DeadLetterDropped, m.DeadLettered)
+	}
+}
+
+func TestDeadLetterOverflowDropOldest(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithDeadLetter(2), WithDeadLetterOverflow(OverflowDropOldest))
+	defer bus.Close()
+	var lost []int
+	bus.OnDeadLetterDropped = func(ev Event[int]) { lost = append(lost, ev.ID) }
+	for i := 1; i <= 3; i++ {
+		if err := bus.Reject(Event[int]{ID: i}, "bad"); err != nil {
+			t.Fatalf("Reject %d: %v", i, err)
+		}
+	}
+	for _, want := range []int{2, 3} {
+		if dl := <-bus.deadLetters; dl.Event.ID != want {
+			t.Fatalf("dead letter %d, want %d", dl.Event.ID, want)
+		}
+	}
+	if len(lost) != 1 || lost[0] != 1 {
+		t.Fatalf("OnDeadLetterDropped saw %v, want [1]", lost)
+	}
+	if n := bus.Metrics().DeadLetterDropped; n != 1 {
+		t.Fatalf("DeadLetterDropped = %d, want 1", n)
+	}
+}
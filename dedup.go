@@ -0,0 +1,84 @@
+package main
+
+import "sync"
+
+// DedupStore remembers the IDs of events already handled, for ConsumeDedup. SeenBefore reports
+// whether id was marked and still is; Mark records id once its event has been handled. The store
+// decides how long an ID is remembered: the default in-memory ring forgets the oldest beyond its
+// window, and a store backed by a file or Redis that outlives the process should expire IDs with a
+// TTL or evict them some other way, or it grows without bound. A store given to WithDedupStore is
+// shared by every ConsumeDedup consumer of the bus and must be safe for concurrent use.
+type DedupStore interface {
+	SeenBefore(id int) bool
+	Mark(id int)
+}
+
+// WithDedupStore makes ConsumeDedup consult store rather than an in-memory ring of its own, so
+// duplicates are skipped across restarts or across consumers and processes sharing the store, as
+// exactly-once-ish processing needs. Since every consumer of the bus shares store, an ID handled
+// on one conveyor is skipped on the others too, and the window passed to ConsumeDedup is ignored.
+func WithDedupStore(store DedupStore) Option {
+	return func(c *busConfig) {
+		c.dedupStore = store
+	}
+}
+
+// dedupRing remembers the last N event IDs seen, evicting the oldest as new ones arrive. It is
+// the DedupStore of a ConsumeDedup consumer when the bus has none, used from that consumer only.
+type dedupRing struct {
+	ids  []int
+	next int
+	full bool
+	seen map[int]struct{}
+}
+
+func newDedupRing(window int) *dedupRing {
+	if window < 1 {
+		window = 1
+	}
+	return &dedupRing{ids: make([]int, window), seen: make(map[int]struct{}, window)}
+}
+
+// SeenBefore implements DedupStore
+func (r *dedupRing) SeenBefore(id int) bool {
+	_, dup := r.seen[id]
+	return dup
+}
+
+// Mark implements DedupStore, evicting the oldest ID once the window is full
+func (r *dedupRing) Mark(id int) {
+	if _, dup := r.seen[id]; dup {
+		return
+	}
+	if r.full {
+		delete(r.seen, r.ids[r.next])
+	}
+	r.ids[r.next] = id
+	r.seen[id] = struct{}{}
+	r.next = (r.next + 1) % len(r.ids)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// ConsumeDedup consumes a specific conveyor like ConsumeWith, skipping events whose ID the
+// DedupStore has seen before. Without WithDedupStore each consumer keeps the last window IDs it
+// handled in memory, so deduplication is per-conveyor: the same ID arriving on two different
+// conveyors is handled twice. An ID is marked once handler returns, so an event whose handler
+// panicked is handled again if it comes back. Skipped events are counted in BusMetrics.Deduped.
+// Duplicates are skipped before the WithEnricher enricher runs.
+func (bus *MainBus[T]) ConsumeDedup(line int, wg *sync.WaitGroup, handler func(Event[T]), window int) {
+	store := bus.dedup
+	if store == nil {
+		store = newDedupRing(window)
+	}
+	handler = bus.enriched(handler)
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		if store.SeenBefore(ev.ID) {
+			bus.belt(line).stats.deduped.Add(1)
+			return
+		}
+		handler(ev)
+		store.Mark(ev.ID)
+	}, rawEvents())
+}
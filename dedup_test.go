@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeStore is a DedupStore recording its calls, standing in for one kept outside the process
+type fakeStore struct {
+	mu     sync.Mutex
+	marked map[int]bool
+	asked  []int
+}
+
+func (s *fakeStore) SeenBefore(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.asked = append(s.asked, id)
+	return s.marked[id]
+}
+
+func (s *fakeStore) Mark(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked[id] = true
+}
+
+func TestConsumeDedupUsesStore(t *testing.T) {
+	// ID 1 was handled before a restart
+	store := &fakeStore{marked: map[int]bool{1: true}}
+	bus := NewMainBus[int]("copper", WithDedupStore(store))
+	bus.RemoveConveyor(1)
+	for _, id := range []int{1, 2, 3, 2, 4, 3} {
+		bus.Produce(Event[int]{ID: id})
+	}
+	var got []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeDedup(0, &wg, func(ev Event[int]) { got = append(got, ev.ID) }, 1)
+	bus.Close()
+	wg.Wait()
+	if want := []int{2, 3, 4}; !slices.Equal(got, want) {
+		t.Fatalf("handled %v, want %v", got, want)
+	}
+	if len(store.asked) != 6 {
+		t.Fatalf("store asked about %v, want all 6 events", store.asked)
+	}
+	for _, id := range []int{2, 3, 4} {
+		if !store.marked[id] {
+			t.Fatalf("handled event %d was not marked", id)
+		}
+	}
+	if m := bus.Metrics(); m.Deduped[0] != 3 {
+		t.Fatalf("deduped = %d, want 3", m.Deduped[0])
+	}
+}
+
+func TestConsumeDedupDefaultRing(t *testing.T) {
+	bus := NewMainBus[int]("copper")
+	bus.RemoveConveyor(1)
+	for _, id := range []int{1, 2, 1, 3, 1} {
+		bus.Produce(Event[int]{ID: id})
+	}
+	var got []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	// a window of two forgets 1 once 2 and 3 are handled
+	go bus.ConsumeDedup(0, &wg, func(ev Event[int]) { got = append(got, ev.ID) }, 2)
+	bus.Close()
+	wg.Wait()
+	if want := []int{1, 2, 3, 1}; !slices.Equal(got, want) {
+		t.Fatalf("handled %v, want %v", got, want)
+	}
+}
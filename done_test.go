@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDoneFiresPerConveyor(t *testing.T) {
+	bus := NewMainBus[int]("copper", WithLines(4), WithStrategy(StrategyRoundRobin))
+	var wg sync.WaitGroup
+	for line := range 2 {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) { time.Sleep(time.Millisecond) })
+	}
+	for id := 1; id <= 8; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	if err := bus.CloseConveyor(0); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-bus.Done(0):
+	case <-time.After(time.Second):
+		t.Fatal("Done(0) did not fire after its conveyor was closed")
+	}
+	if got := bus.Depth(0); got != 0 {
+		t.Fatalf("Done(0) fired with %d events buffered", got)
+	}
+	for line := 1; line <= 3; line++ {
+		select {
+		case <-bus.Done(line):
+			t.Fatalf("Done(%d) fired while its conveyor is open", line)
+		default:
+		}
+	}
+
+	// lines 2 and 3 have no consumer, so they stay pending with events left once closed
+	settleDepth(t, bus, 1)
+	bus.Close()
+	select {
+	case <-bus.Done(1):
+	case <-time.After(time.Second):
+		t.Fatal("Done(1) did not fire after Close")
+	}
+	select {
+	case <-bus.Done(2):
+		t.Fatal("Done(2) fired with events still buffered")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if evs := bus.DrainAll(); len(evs) != 4 {
+		t.Fatalf("drained %d events from lines 2 and 3, want 4", len(evs))
+	}
+	for line := 2; line <= 3; line++ {
+		select {
+		case <-bus.Done(line):
+		default:
+			t.Fatalf("Done(%d) did not fire once drained", line)
+		}
+	}
+	wg.Wait()
+	if bus.Done(4) != nil {
+		t.Fatal("Done out of range is not nil")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func drainValues(bus *MainBus[string]) []string {
+	var got []string
+	for _, c := range bus.Conveyors {
+		for len(c) > 0 {
+			got = append(got, (<-c).Value)
+		}
+	}
+	return got
+}
+
+func TestEncryptedPersistence(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	for _, c := range []Compression{CompressionNone, CompressionZstd} {
+		t.Run(c.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.log")
+			bus := NewMainBus[string](Iron, WithPersistence(path), WithCompression(c), WithEncryption(key))
+			for i := 1; i <= 3; i++ {
+				if err := bus.Produce(Event[string]{ID: i, Value: "secret-plate"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			bus.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, _, _ := strings.Cut(string(data), "\n")
+			if !strings.HasSuffix(header, "encryption=aes-gcm") {
+				t.Errorf("header = %q, want the encryption scheme", header)
+			}
+			if bytes.Contains(data, []byte("secret-plate")) {
+				t.Error("log holds the event value in the clear")
+			}
+
+			replayed := NewMainBus[string](Copper, WithBuffer(8), WithEncryption(key))
+			defer replayed.Close()
+			if err := ReplayFile(path, replayed); err != nil {
+				t.Fatal(err)
+			}
+			if got := drainValues(replayed); len(got) != 3 || got[0] != "secret-plate" {
+				t.Errorf("replayed %v, want 3 secret-plate events", got)
+			}
+		})
+	}
+}
+
+func TestEncryptedPersistenceWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	bus := NewMainBus[string](Iron, WithPersistence(path), WithEncryption(bytes.Repeat([]byte{1}, 16)))
+	bus.Produce(Event[string]{ID: 1, Value: "plate"})
+	bus.Close()
+
+	wrong := NewMainBus[string](Copper, WithEncryption(bytes.Repeat([]byte{2}, 16)))
+	defer wrong.Close()
+	if err := ReplayFile(path, wrong); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("ReplayFile with the wrong key = %v, want ErrDecrypt", err)
+	}
+	none := NewMainBus[string](Copper)
+	defer none.Close()
+	if err := ReplayFile(path, none); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("ReplayFile without a key = %v, want ErrInvalidConfig", err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Fatalf("log has %d lines, want header and one record", len(lines))
+	}
+	if _, err := openEventLog(path, 0, logHeader[string](JSONCodec[string]{}, CompressionNone, false)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("reopening an encrypted log unencrypted = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestEncryptionNonceIsFresh(t *testing.T) {
+	aead, err := newAEAD(bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := sealRecord(aead, []byte("plate"))
+	b, _ := sealRecord(aead, []byte("plate"))
+	if bytes.Equal(a, b) {
+		t.Error("sealing the same record twice gave the same ciphertext")
+	}
+	if _, err := openRecord(aead, a[:4]); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("openRecord on a truncated record = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestEncryptionKeySize(t *testing.T) {
+	if _, err := NewMainBusChecked[string](Iron, WithEncryption([]byte("short"))); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "fmt"
+
+// Enricher decorates an event before a consumer's handler sees it
+type Enricher[T any] func(Event[T]) Event[T]
+
+// WithEnricher makes every handler-based consumer of the bus pass each event through f before
+// calling its handler, to attach derived fields to Value, normalize timestamps and the like in
+// one place rather than in every handler. Enrichment happens within consumption, after the bus
+// has skipped events behind a SeekTo offset or past their TTL and after the predicate of
+// ConsumeFiltered and the window of ConsumeDedup, so those see events as produced. Delivery
+// callbacks, Checkpoint offsets and tracing keep using the event as taken off the conveyor. A
+// panicking f is recovered like a panicking handler. The func must take the bus event type.
+func WithEnricher[T any](f Enricher[T]) Option {
+	return func(c *busConfig) {
+		c.enricher = f
+	}
+}
+
+// enricherFor returns the enricher set by WithEnricher, or an error wrapping ErrInvalidConfig if
+// it was written for events of another type
+func enricherFor[T any](cfg busConfig) (Enricher[T], error) {
+	if cfg.enricher == nil {
+		return nil, nil
+	}
+	f, ok := cfg.enricher.(Enricher[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: enricher %T does not take Event[%T]", ErrInvalidConfig, cfg.enricher, *new(T))
+	}
+	return f, nil
+}
+
+// enriched wraps handler so it sees events passed through the bus enricher
+func (bus *MainBus[T]) enriched(handler func(Event[T])) func(Event[T]) {
+	if bus.enricher == nil {
+		return handler
+	}
+	return func(ev Event[T]) { handler(bus.enricher(ev)) }
+}
+
+// rawEvents makes ConsumeContext leave enrichment to its handler, for consumers that must look
+// at events before they are enriched
+func rawEvents() ConsumeOption {
+	return func(c *consumeConfig) {
+		c.raw = true
+	}
+}
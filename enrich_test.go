@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnricherDecoratesHandledEvents(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithStrategy(StrategyRoundRobin),
+		WithEnricher(func(ev Event[string]) Event[string] {
+			ev.Value = strings.ToUpper(ev.Value)
+			return ev
+		}))
+	defer bus.Close()
+	ProduceAll(bus, []Event[string]{{ID: 1, Value: "plate"}, {ID: 2, Value: "gear"}})
+
+	evs, err := CollectN(bus, 0, 1, time.Second)
+	if err != nil || evs[0].Value != "PLATE" {
+		t.Fatalf("CollectN = %v, %v; want the enriched PLATE", evs, err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	got := make(chan Event[string], 1)
+	go bus.ConsumeWith(1, &wg, func(ev Event[string]) { got <- ev })
+	if ev := <-got; ev.Value != "GEAR" {
+		t.Fatalf("ConsumeWith handler saw %q, want GEAR", ev.Value)
+	}
+}
+
+func TestEnricherRunsAfterFilter(t *testing.T) {
+	var mu sync.Mutex
+	var enriched []int
+	bus := NewMainBus[string]("iron", WithLines(2), WithStrategy(StrategyRoundRobin),
+		WithEnricher(func(ev Event[string]) Event[string] {
+			mu.Lock()
+			enriched = append(enriched, ev.ID)
+			mu.Unlock()
+			ev.Value += "!"
+			return ev
+		}))
+	for i := 0; i < 6; i += 2 { // the even IDs land on conveyor 0
+		bus.Produce(Event[string]{ID: i, Value: "plate"})
+		bus.Produce(Event[string]{ID: i + 1, Value: "ore"})
+	}
+	var handled []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeFiltered(0, &wg, func(ev Event[string]) bool {
+		if strings.HasSuffix(ev.Value, "!") {
+			t.Errorf("predicate saw enriched event %+v", ev)
+		}
+		return ev.ID%4 == 0
+	}, func(ev Event[string]) { handled = append(handled, ev.Value) })
+	bus.Close()
+	wg.Wait()
+	if len(handled) != 2 || handled[0] != "plate!" || len(enriched) != 2 {
+		t.Fatalf("handled %v, enriched %v; want only events 0 and 4 enriched and handled", handled, enriched)
+	}
+}
+
+func TestEnricherWrongType(t *testing.T) {
+	_, err := NewMainBusChecked[int]("iron", WithEnricher(func(ev Event[string]) Event[string] { return ev }))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewMainBusChecked: %v, want ErrInvalidConfig", err)
+	}
+}
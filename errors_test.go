@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	errEmpty := errors.New("empty value")
+	for _, tc := range []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"produce on closed bus", func() error {
+			bus := NewMainBus[int]("iron")
+			bus.Close()
+			return bus.ProduceContext(context.Background(), Event[int]{ID: 1})
+		}, ErrBusClosed},
+		{"try produce on closed bus", func() error {
+			bus := NewMainBus[int]("iron")
+			bus.Close()
+			return bus.TryProduceErr(Event[int]{ID: 1})
+		}, ErrBusClosed},
+		{"try produce on full conveyors", func() error {
+			bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(1), WithStrategy(StrategyRoundRobin))
+			defer bus.Close()
+			bus.Produce(Event[int]{ID: 1})
+			bus.Produce(Event[int]{ID: 2})
+			return bus.TryProduceErr(Event[int]{ID: 3})
+		}, ErrConveyorFull},
+		{"try produce over the in-flight cap", func() error {
+			bus := NewMainBus[int]("iron", WithBuffer(8), WithMaxTotalInFlight(1))
+			defer bus.Close()
+			bus.Produce(Event[int]{ID: 1})
+			return bus.TryProduceErr(Event[int]{ID: 2})
+		}, ErrConveyorFull},
+		{"try produce past the rate limit", func() error {
+			bus := NewMainBus[int]("iron", WithBuffer(8), WithRateLimit(1))
+			defer bus.Close()
+			for {
+				if err := bus.TryProduceErr(Event[int]{ID: 1}); err != nil {
+					return err
+				}
+			}
+		}, ErrRateLimited},
+		{"invalid event", func() error {
+			bus := NewMainBus[int]("iron", WithValidators(func(ev Event[int]) error {
+				if ev.Value == 0 {
+					return errEmpty
+				}
+				return nil
+			}))
+			defer bus.Close()
+			err := bus.ProduceContext(context.Background(), Event[int]{ID: 1})
+			if !errors.Is(err, errEmpty) {
+				t.Errorf("validation error %v does not wrap its cause", err)
+			}
+			if terr := bus.TryProduceErr(Event[int]{ID: 1}); !errors.Is(terr, ErrInvalidEvent) {
+				t.Errorf("TryProduceErr = %v, want ErrInvalidEvent", terr)
+			}
+			return err
+		}, ErrInvalidEvent},
+		{"invalid event as ErrValidation", func() error {
+			bus := NewMainBus[int]("iron", WithValidators(ValidateResource[int]))
+			defer bus.Close()
+			return bus.ProduceContext(context.Background(), Event[int]{ID: 1})
+		}, ErrValidation},
+		{"close conveyor out of range", func() error {
+			bus := NewMainBus[int]("iron")
+			defer bus.Close()
+			return bus.CloseConveyor(7)
+		}, ErrInvalidLine},
+		{"remove conveyor already removed", func() error {
+			bus := NewMainBus[int]("iron", WithLines(4))
+			defer bus.Close()
+			bus.RemoveConveyor(1)
+			return bus.RemoveConveyor(1)
+		}, ErrInvalidLine},
+		{"remove the last conveyor", func() error {
+			bus := NewMainBus[int]("iron", WithLines(2))
+			defer bus.Close()
+			bus.RemoveConveyor(0)
+			return bus.RemoveConveyor(1)
+		}, ErrNoConveyors},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.run(); !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want an error matching %v", err, tc.want)
+			}
+		})
+	}
+}
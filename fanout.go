@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FanOutError reports a fan-out that did not reach every bus. A channel send cannot be taken
+// back, so nothing is rolled back: the buses in Produced keep the event, and it is up to the
+// caller to compensate, for instance by producing a cancellation to them. errors.Is and
+// errors.As see every error in Errs.
+type FanOutError struct {
+	Produced []int   // indexes into the buses passed of those that took the event
+	Failed   []int   // indexes of those that did not
+	Errs     []error // why each bus in Failed did not take it, in the same order
+}
+
+func (e *FanOutError) Error() string {
+	return fmt.Sprintf("fan-out produced on %d buses and failed on %d: %v", len(e.Produced), len(e.Failed), errors.Join(e.Errs...))
+}
+
+func (e *FanOutError) Unwrap() []error {
+	return e.Errs
+}
+
+// FanOutProduce produces ev on each of buses in turn, as FanOutProduceContext does without a
+// deadline
+func FanOutProduce[T any](ev Event[T], buses ...*MainBus[T]) error {
+	return FanOutProduceContext(context.Background(), ev, buses...)
+}
+
+// FanOutProduceContext produces ev on each of buses in turn with ProduceContext, for one logical
+// event that must reach several buses, such as a bus and its audit copy. It stops at the first
+// bus that does not take the event, because it is closed, its overflow policy dropped it or ctx
+// ended while it was full, and returns a *FanOutError naming the buses that already hold the
+// event and the one that failed; the buses after it are not tried. True atomicity across
+// conveyors is not possible, so the error reports the partial success rather than undoing it.
+// Each bus runs the event's delivery callbacks separately.
+func FanOutProduceContext[T any](ctx context.Context, ev Event[T], buses ...*MainBus[T]) error {
+	var fe FanOutError
+	for i, bus := range buses {
+		if err := bus.ProduceContext(ctx, ev); err != nil {
+			fe.Failed, fe.Errs = []int{i}, []error{err}
+			return &fe
+		}
+		fe.Produced = append(fe.Produced, i)
+	}
+	return nil
+}
+
+// FanOutBestEffort is FanOutProduceContext trying every bus even after one fails, for copies
+// that matter individually; the *FanOutError it returns lists every bus that failed
+func FanOutBestEffort[T any](ctx context.Context, ev Event[T], buses ...*MainBus[T]) error {
+	var fe FanOutError
+	for i, bus := range buses {
+		if err := bus.ProduceContext(ctx, ev); err != nil {
+			fe.Failed = append(fe.Failed, i)
+			fe.Errs = append(fe.Errs, err)
+			continue
+		}
+		fe.Produced = append(fe.Produced, i)
+	}
+	if len(fe.Failed) > 0 {
+		return &fe
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFanOutProduceStopsAtFirstFailure(t *testing.T) {
+	iron, audit, copper := NewMainBus[int]("iron"), NewMainBus[int]("audit"), NewMainBus[int]("copper")
+	defer iron.Close()
+	defer copper.Close()
+	audit.Close()
+
+	err := FanOutProduce(Event[int]{ID: 1}, iron, audit, copper)
+	var fe *FanOutError
+	if !errors.As(err, &fe) || !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("FanOutProduce = %v, want a *FanOutError wrapping ErrBusClosed", err)
+	}
+	if !slices.Equal(fe.Produced, []int{0}) || !slices.Equal(fe.Failed, []int{1}) {
+		t.Fatalf("Produced %v, Failed %v; want [0] and [1]", fe.Produced, fe.Failed)
+	}
+	if iron.TotalDepth() != 1 || copper.TotalDepth() != 0 {
+		t.Fatalf("depths iron %d, copper %d; want the event on iron only", iron.TotalDepth(), copper.TotalDepth())
+	}
+}
+
+func TestFanOutBestEffortTriesEveryBus(t *testing.T) {
+	iron, copper := NewMainBus[int]("iron"), NewMainBus[int]("copper")
+	full := NewMainBus[int]("full", WithBuffer(0)) // unbuffered and unconsumed: never takes an event
+	closed := NewMainBus[int]("closed")
+	defer iron.Close()
+	defer copper.Close()
+	defer full.Close()
+	closed.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := FanOutBestEffort(ctx, Event[int]{ID: 1}, iron, closed, copper, full)
+	var fe *FanOutError
+	if !errors.As(err, &fe) {
+		t.Fatalf("FanOutBestEffort = %v, want a *FanOutError", err)
+	}
+	if !slices.Equal(fe.Produced, []int{0, 2}) || !slices.Equal(fe.Failed, []int{1, 3}) {
+		t.Fatalf("Produced %v, Failed %v; want [0 2] and [1 3]", fe.Produced, fe.Failed)
+	}
+	if !errors.Is(fe.Errs[0], ErrBusClosed) || !errors.Is(fe.Errs[1], context.DeadlineExceeded) {
+		t.Fatalf("Errs = %v, want ErrBusClosed then a deadline", fe.Errs)
+	}
+}
+
+func TestFanOutProduceAllSucceed(t *testing.T) {
+	iron, audit := NewMainBus[int]("iron"), NewMainBus[int]("audit")
+	defer iron.Close()
+	defer audit.Close()
+	if err := FanOutProduce(Event[int]{ID: 1}, iron, audit); err != nil {
+		t.Fatal(err)
+	}
+	if iron.TotalDepth() != 1 || audit.TotalDepth() != 1 {
+		t.Fatal("event missing from a bus")
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/base64"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The JSON codec and WithTypeStats handle values of the common types string, bool, int, int64,
+// float64 and []byte without reflection: events holding one are encoded by appendEventJSON, their
+// values decoded by decodeFast and their type named by fastTypeName. Every other value takes the
+// reflective path through encoding/json and reflect, and the fast path produces exactly what
+// that path would, byte for byte.
+
+// fastTypeName returns the reflect name of v's type if it is one of the fast-path types
+func fastTypeName(v any) (string, bool) {
+	switch v.(type) {
+	case nil:
+		return "<nil>", true
+	case string:
+		return "string", true
+	case bool:
+		return "bool", true
+	case int:
+		return "int", true
+	case int64:
+		return "int64", true
+	case float64:
+		return "float64", true
+	case []byte:
+		return "[]uint8", true
+	}
+	return "", false
+}
+
+// fastTag returns the type tag of v if it is one of the fast-path types; floats encoding/json
+// cannot represent are left to the reflective path and its error
+func fastTag(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return "string", true
+	case bool:
+		return "bool", true
+	case int:
+		return "int", true
+	case int64:
+		return "int64", true
+	case float64:
+		return "float64", !math.IsInf(v, 0) && !math.IsNaN(v)
+	case []byte:
+		return "bytes", true
+	}
+	return "", false
+}
+
+// appendFastValue appends v, one of the types fastTag accepts, as encoding/json marshals it
+func appendFastValue(dst []byte, v any) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendJSONString(dst, v)
+	case bool:
+		return strconv.AppendBool(dst, v)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case float64:
+		return appendJSONFloat(dst, v)
+	case []byte:
+		if v == nil {
+			break
+		}
+		dst = append(dst, '"')
+		dst = base64.StdEncoding.AppendEncode(dst, v)
+		return append(dst, '"')
+	}
+	return append(dst, "null"...)
+}
+
+// appendEventJSON appends what Event.MarshalJSON writes for ev, reporting false without
+// appending anything if ev does not qualify for the fast path
+func appendEventJSON[T any](dst []byte, ev Event[T]) ([]byte, bool) {
+	start := len(dst)
+	dst = append(dst, `{"id":`...)
+	dst = strconv.AppendInt(dst, int64(ev.ID), 10)
+	dst = append(dst, `,"resource":`...)
+	dst = appendJSONString(dst, ev.Resource)
+	tag, ok := fastTag(ev.Value)
+	if !ok {
+		return dst[:start], false
+	}
+	if tag != "" {
+		dst = append(dst, `,"type":`...)
+		dst = appendJSONString(dst, tag)
+	}
+	dst = append(dst, `,"value":`...)
+	dst = appendFastValue(dst, ev.Value)
+	ts, err := ev.Time.MarshalJSON()
+	if err != nil {
+		return dst[:start], false
+	}
+	dst = append(dst, `,"time":`...)
+	dst = append(dst, ts...)
+	if ev.Priority != 0 {
+		dst = append(dst, `,"priority":`...)
+		dst = strconv.AppendInt(dst, int64(ev.Priority), 10)
+	}
+	if len(ev.Carrier) > 0 {
+		dst = append(dst, `,"carrier":{`...)
+		keys := make([]string, 0, len(ev.Carrier))
+		for k := range ev.Carrier {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for i, k := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, k)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, ev.Carrier[k])
+		}
+		dst = append(dst, '}')
+	}
+	if ev.CorrelationID != "" {
+		dst = append(dst, `,"correlation_id":`...)
+		dst = appendJSONString(dst, ev.CorrelationID)
+	}
+	return append(dst, '}'), true
+}
+
+// appendJSONString appends s quoted as encoding/json writes strings, HTML-escaped, with invalid
+// UTF-8 replaced by U+FFFD
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xF])
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendJSONFloat appends a finite f as encoding/json writes a float64: like %g, but with the
+// exponent cutoffs of ES6 number formatting and unpadded exponents
+func appendJSONFloat(dst []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if n := len(dst); format == 'e' && n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+		// e-09 becomes e-9
+		dst[n-2] = dst[n-1]
+		dst = dst[:n-1]
+	}
+	return dst
+}
+
+// decodeFast decodes raw, a valid JSON value, into a T without reflection when T is one of the
+// fast-path types, or an interface and tag names one of them. It reports false whenever the
+// reflective path has to decide: a null, an escaped string or a number out of range.
+func decodeFast[T any](tag string, raw []byte) (T, bool) {
+	var value T
+	switch any(&value).(type) {
+	case *string:
+		tag = "string"
+	case *bool:
+		tag = "bool"
+	case *int:
+		tag = "int"
+	case *int64:
+		tag = "int64"
+	case *float64:
+		tag = "float64"
+	case *[]byte:
+		tag = "bytes"
+	case *any:
+	default:
+		return value, false // an interface other than any goes through assignValue
+	}
+	var v any
+	switch tag {
+	case "string":
+		s, ok := fastString(raw)
+		if !ok {
+			return value, false
+		}
+		v = s
+	case "bool":
+		switch string(raw) {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		default:
+			return value, false
+		}
+	case "int", "int64":
+		if !jsonInteger(raw) {
+			return value, false
+		}
+		n, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || tag == "int" && int64(int(n)) != n {
+			return value, false
+		}
+		if v = n; tag == "int" {
+			v = int(n)
+		}
+	case "float64":
+		if !jsonNumber(raw) {
+			return value, false
+		}
+		f, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			return value, false
+		}
+		v = f
+	case "bytes":
+		s, ok := fastString(raw)
+		if !ok {
+			return value, false
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return value, false
+		}
+		v = b
+	default:
+		return value, false
+	}
+	value, ok := v.(T)
+	return value, ok
+}
+
+// fastString returns the contents of a JSON string that needs no unescaping
+func fastString(raw []byte) (string, bool) {
+	n := len(raw)
+	if n < 2 || raw[0] != '"' || slices.Contains(raw, '\\') || !utf8.Valid(raw) {
+		return "", false
+	}
+	return string(raw[1 : n-1]), true
+}
+
+// jsonInteger reports whether s is a JSON number without fraction or exponent
+func jsonInteger(s []byte) bool {
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+	if len(s) == 0 || s[0] == '0' && len(s) > 1 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonNumber reports whether s is a number as the JSON grammar defines it
+func jsonNumber(s []byte) bool {
+	digits := func() int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		return n
+	}
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+	n := digits()
+	if n == 0 || s[0] == '0' && n > 1 {
+		return false
+	}
+	s = s[n:]
+	if len(s) > 0 && s[0] == '.' {
+		s = s[1:]
+		if n = digits(); n == 0 {
+			return false
+		}
+		s = s[n:]
+	}
+	if len(s) > 0 && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+		if n = digits(); n == 0 {
+			return false
+		}
+		s = s[n:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fastValues is the shared matrix of values the fast and reflective paths must agree on
+var fastValues = []any{
+	nil,
+	"", "iron plate", `quote " and \ backslash`, "<b>&amp;</b>", "ctl \x00\x01\x1f \b\f\n\r\t \x7f",
+	"line\u2028para\u2029", "bad \xff\xfe utf-8", "héllo, 世界 🚂",
+	true, false,
+	0, -7, math.MaxInt64, math.MinInt64,
+	int64(0), int64(42), int64(math.MaxInt64), int64(math.MinInt64),
+	0.0, math.Copysign(0, -1), 1.5, -273.15, 1e-6, 1e-7, 9.99e-7, 1e20, 1e21, 123456789.125,
+	5e-324, math.MaxFloat64, 1.0 / 3,
+	[]byte(nil), []byte{}, []byte{0, 1, 254, 255}, []byte("a longer payload of raw bytes"),
+}
+
+// fastEvent wraps v in an event exercising every optional field
+func fastEvent(v any) Event[any] {
+	return Event[any]{ID: 9, Resource: "iron <west>", Value: v, Time: time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC),
+		Priority: -2, Carrier: map[string]string{"tracestate": "a=1", "traceparent": "00-abc-01"}, CorrelationID: "order \"7\""}
+}
+
+func TestFastPathMatchesReflection(t *testing.T) {
+	for _, v := range fastValues {
+		for _, ev := range []Event[any]{fastEvent(v), {ID: 1, Value: v}} {
+			want, werr := ev.marshalReflect()
+			got, ok := appendEventJSON(nil, ev)
+			if !ok || werr != nil {
+				t.Fatalf("%#v: fast path ok %v, reflective error %v", v, ok, werr)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("%#v:\nfast    %s\nreflect %s", v, got, want)
+			}
+			if viaJSON, _ := json.Marshal(ev); !bytes.Equal(viaJSON, want) {
+				t.Fatalf("%#v: json.Marshal %s, want %s", v, viaJSON, want)
+			}
+
+			var raw eventJSON
+			if err := json.Unmarshal(got, &raw); err != nil {
+				t.Fatal(err)
+			}
+			slow, err := decodeValueReflect[any](raw.Type, raw.Value)
+			if err != nil {
+				t.Fatalf("%#v: reflective decode: %v", v, err)
+			}
+			if fast, ok := decodeFast[any](raw.Type, raw.Value); ok && !reflect.DeepEqual(fast, slow) {
+				t.Fatalf("%#v: fast decode %#v, reflective %#v", v, fast, slow)
+			}
+			var back Event[any]
+			if err := back.UnmarshalJSON(got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(back.Value, slow) {
+				t.Fatalf("%#v: decoded %#v, want %#v", v, back.Value, slow)
+			}
+		}
+		name, ok := fastTypeName(v)
+		if !ok || name != reflectTypeName(v) {
+			t.Fatalf("%#v: fast type name %q, reflect %q", v, name, reflectTypeName(v))
+		}
+	}
+}
+
+func TestFastPathRoundTripsConcreteTypes(t *testing.T) {
+	roundTrip(t, JSONCodec[string]{}, Event[string]{ID: 1, Value: "smelted <plate>", Time: epoch})
+	roundTrip(t, JSONCodec[int64]{}, Event[int64]{ID: 2, Value: -1 << 40, Time: epoch})
+	roundTrip(t, JSONCodec[float64]{}, Event[float64]{ID: 3, Value: 2.5e-9, Time: epoch})
+	roundTrip(t, JSONCodec[[]byte]{}, Event[[]byte]{ID: 4, Value: []byte{1, 2, 3}, Time: epoch})
+	roundTrip(t, JSONCodec[any]{}, Event[any]{ID: 5, Value: []byte("boxed"), Time: epoch})
+	for _, v := range fastValues {
+		if s, ok := v.(string); ok && s == string([]rune(s)) {
+			roundTrip(t, JSONCodec[any]{}, Event[any]{ID: 6, Value: s, Time: epoch})
+		}
+	}
+}
+
+func TestFastPathFallsBack(t *testing.T) {
+	type plate struct{ Grade int }
+	for _, v := range []any{plate{3}, math.NaN(), math.Inf(1), int32(7), []string{"a"}} {
+		if _, ok := appendEventJSON(nil, Event[any]{Value: v}); ok {
+			t.Errorf("%#v took the fast path", v)
+		}
+	}
+	if _, err := (Event[any]{Value: math.NaN()}).MarshalJSON(); err == nil {
+		t.Error("NaN value marshalled")
+	}
+	for tag, raw := range map[string]string{"int": "1e3", "int64": "007", "float64": "+1", "string": `"a\nb"`, "bytes": "null"} {
+		if _, ok := decodeFast[any](tag, []byte(raw)); ok {
+			t.Errorf("%s %s decoded on the fast path", tag, raw)
+		}
+	}
+	if got, err := decodeValue[any]("string", json.RawMessage(`"a\nb"`)); err != nil || got != "a\nb" {
+		t.Errorf("escaped string decoded as %q, %v", got, err)
+	}
+}
+
+func BenchmarkJSONEncode(b *testing.B) {
+	for _, c := range []struct {
+		name string
+		v    any
+	}{{"string", "iron plate"}, {"int64", int64(1 << 40)}, {"float64", 1234.5678}, {"bytes", []byte("raw payload bytes")}} {
+		ev := fastEvent(c.v)
+		b.Run(c.name+"/fast", func(b *testing.B) {
+			for b.Loop() {
+				ev.MarshalJSON()
+			}
+		})
+		b.Run(c.name+"/reflect", func(b *testing.B) {
+			for b.Loop() {
+				ev.marshalReflect()
+			}
+		})
+	}
+}
+
+func BenchmarkJSONDecodeValue(b *testing.B) {
+	for _, c := range []struct{ tag, raw string }{{"string", `"iron plate"`}, {"int64", "1099511627776"}, {"float64", "1234.5678"}, {"bytes", `"cmF3IHBheWxvYWQ="`}} {
+		raw := json.RawMessage(c.raw)
+		b.Run(c.tag+"/fast", func(b *testing.B) {
+			for b.Loop() {
+				decodeValue[any](c.tag, raw)
+			}
+		})
+		b.Run(c.tag+"/reflect", func(b *testing.B) {
+			for b.Loop() {
+				decodeValueReflect[any](c.tag, raw)
+			}
+		})
+	}
+}
+
+func BenchmarkTypeName(b *testing.B) {
+	var v any = int64(7)
+	b.Run("fast", func(b *testing.B) {
+		for b.Loop() {
+			fastTypeName(v)
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		for b.Loop() {
+			reflectTypeName(v)
+		}
+	})
+}
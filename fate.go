@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// eventFate makes sure only one of an event's callbacks runs, once
+type eventFate struct {
+	once      sync.Once
+	delivered func()
+	dropped   func(reason string)
+}
+
+// withFate returns ev with its callbacks guarded so that, across every copy of ev, exactly one
+// of them runs and only once. Events without callbacks are returned unchanged.
+func withFate[T any](ev Event[T]) Event[T] {
+	if ev.OnDelivered == nil && ev.OnDropped == nil {
+		return ev
+	}
+	f := &eventFate{delivered: ev.OnDelivered, dropped: ev.OnDropped}
+	ev.OnDelivered = func() {
+		f.once.Do(func() {
+			if f.delivered != nil {
+				f.delivered()
+			}
+		})
+	}
+	ev.OnDropped = func(reason string) {
+		f.once.Do(func() {
+			if f.dropped != nil {
+				f.dropped(reason)
+			}
+		})
+	}
+	return ev
+}
+
+// withoutFate returns ev stripped of its callbacks, for copies whose fate is not the event's
+func withoutFate[T any](ev Event[T]) Event[T] {
+	ev.OnDelivered, ev.OnDropped = nil, nil
+	return ev
+}
+
+// delivered runs the OnDelivered callback of ev, if any
+func delivered[T any](ev Event[T]) {
+	if ev.OnDelivered != nil {
+		ev.OnDelivered()
+	}
+}
+
+// dropped runs the OnDropped callback of ev, if any, and lets a Flush barrier count as reached
+func dropped[T any](ev Event[T], reason string) {
+	if ev.barrier != nil {
+		ev.barrier.pass() // the events queued before it are settled as well
+	}
+	if ev.OnDropped != nil {
+		ev.OnDropped(reason)
+	}
+}
+
+// drop counts ev as dropped on line, logs it at level with reason and runs its OnDropped callback
+func (bus *MainBus[T]) drop(level slog.Level, line int, ev Event[T], reason string, attrs ...slog.Attr) {
+	bus.belt(line).stats.dropped.Add(1)
+	bus.logEvent(level, "event dropped", line, ev, append([]slog.Attr{slog.String("reason", reason)}, attrs...)...)
+	dropped(ev, reason)
+}
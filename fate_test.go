@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fateRecorder counts the callbacks run for the events it equips
+type fateRecorder struct {
+	mu        sync.Mutex
+	delivered int
+	dropped   []string
+}
+
+func (f *fateRecorder) equip(ev Event[int]) Event[int] {
+	ev.OnDelivered = func() {
+		f.mu.Lock()
+		f.delivered++
+		f.mu.Unlock()
+	}
+	ev.OnDropped = func(reason string) {
+		f.mu.Lock()
+		f.dropped = append(f.dropped, reason)
+		f.mu.Unlock()
+	}
+	return ev
+}
+
+func (f *fateRecorder) counts() (int, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.delivered, append([]string(nil), f.dropped...)
+}
+
+func TestOnDeliveredAwaitsConsumer(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithBuffer(4))
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	done := make(chan struct{})
+	ev := Event[int]{ID: 1, OnDelivered: func() { close(done) }}
+	if err := bus.Produce(ev); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnDelivered never ran")
+	}
+	bus.Close()
+	wg.Wait()
+}
+
+func TestOnDroppedReasons(t *testing.T) {
+	var f fateRecorder
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(1), WithStrategy(StrategyRoundRobin), WithOverflowPolicy(OverflowDropNewest))
+	for i := range 3 {
+		bus.Produce(f.equip(Event[int]{ID: i + 1}))
+	}
+	if _, dropped := f.counts(); len(dropped) != 1 || dropped[0] != "conveyor full" {
+		t.Fatalf("dropped = %v, want one conveyor full", dropped)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go bus.ConsumeWith(0, &wg, func(Event[int]) { panic("boom") })
+	go bus.ConsumeWith(1, &wg, func(Event[int]) {})
+	bus.Close()
+	wg.Wait()
+	delivered, dropped := f.counts()
+	if delivered != 1 || len(dropped) != 2 || dropped[1] != "handler panicked" {
+		t.Errorf("delivered %d, dropped %v; want 1 and a handler panic", delivered, dropped)
+	}
+}
+
+func TestFateRunsOnceAcrossCopies(t *testing.T) {
+	var f fateRecorder
+	replica := NewMainBus[int](Copper, WithBuffer(8))
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(8))
+	bus.Mirror(replica)
+	if err := bus.Broadcast(f.equip(Event[int]{ID: 1})); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Produce(f.equip(Event[int]{ID: 2})); err != nil {
+		t.Fatal(err)
+	}
+	bus.Close()
+	replica.Close()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go bus.ConsumeAll(&wg, func(int, Event[int]) {})
+	go replica.ConsumeAll(&wg, func(int, Event[int]) {})
+	wg.Wait()
+	if delivered, dropped := f.counts(); delivered != 2 || len(dropped) != 0 {
+		t.Errorf("delivered %d, dropped %v; want each event settled once", delivered, dropped)
+	}
+}
+
+func TestFateExpiredAndDeadLettered(t *testing.T) {
+	var f fateRecorder
+	bus := NewMainBus[int](Iron, WithBuffer(4), WithTTL(time.Millisecond), WithDeadLetter(4))
+	bus.Produce(f.equip(Event[int]{ID: 1, Time: time.Now().Add(-time.Hour)}))
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	<-bus.deadLetters
+	bus.Close()
+	wg.Wait()
+	if delivered, dropped := f.counts(); delivered != 0 || len(dropped) != 1 || dropped[0] != "dead-lettered: expired" {
+		t.Errorf("delivered %d, dropped %v; want dead-lettered: expired", delivered, dropped)
+	}
+}
+
+func TestFateConsumeAckWaitsForAck(t *testing.T) {
+	var f fateRecorder
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin))
+	bus.Produce(f.equip(Event[int]{ID: 1}))
+	var failures atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeAck(0, &wg, func(Event[int]) error {
+		if failures.Add(1) < 3 {
+			if delivered, _ := f.counts(); delivered != 0 {
+				t.Error("OnDelivered ran before the event was acknowledged")
+			}
+			return context.Canceled
+		}
+		return nil
+	})
+	bus.Close()
+	wg.Wait()
+	if delivered, dropped := f.counts(); delivered != 1 || len(dropped) != 0 {
+		t.Errorf("delivered %d, dropped %v; want one delivery after the ack", delivered, dropped)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "sync"
+
+// ConsumeFiltered consumes a specific conveyor like ConsumeWith, but only events for which pred
+// returns true reach handler. Events failing pred are dropped: they are taken off the conveyor
+// and counted as consumed, but neither handled nor forwarded to the dead-letter conveyor. pred
+// sees events before the WithEnricher enricher, which only runs on those it lets through.
+func (bus *MainBus[T]) ConsumeFiltered(line int, wg *sync.WaitGroup, pred func(Event[T]) bool, handler func(Event[T])) {
+	handler = bus.enriched(handler)
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		if pred(ev) {
+			handler(ev)
+		}
+	}, rawEvents())
+}
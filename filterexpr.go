@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CompileFilter compiles a filter expression into a predicate for ConsumeFiltered, so operators
+// can configure filters at runtime, from a flag or a config file, without recompiling:
+//
+//	pred, err := CompileFilter[string](`resource == "iron" && value contains "Plate"`)
+//
+// An expression compares event fields with literals, field on the left:
+//
+//	id, priority   numbers: == != < <= > >=
+//	resource       strings: == != < <= > >= and contains (substring)
+//	value          either: a number compares with a numeric Value, a string with the Value
+//	               formatted as fmt.Sprint does, and contains looks for a substring of that
+//	time           an RFC 3339 string: == != < <= > >=
+//
+// Strings are double-quoted with Go escapes and numbers are decimal, with an optional sign and
+// fraction. Comparisons combine with && and ||, negate with ! and group with parentheses; &&
+// binds tighter than ||. A comparison between a number and a Value that is not numeric is false,
+// whatever the operator. That is the whole language: it has no function calls, no regular
+// expressions and no access to anything but these fields, so an expression runs in time linear
+// in its length and cannot reach outside the event. A malformed expression, an unknown field or
+// a literal of the wrong kind for its field returns an error naming the offending position.
+func CompileFilter[T any](expr string) (func(Event[T]) bool, error) {
+	toks, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", expr, err)
+	}
+	p := &filterParser[T]{toks: toks}
+	pred, err := p.or()
+	if err == nil && p.peek().kind != filterEOF {
+		err = p.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", expr, err)
+	}
+	return pred, nil
+}
+
+// filterKind is the kind of a filter expression token
+type filterKind int
+
+const (
+	filterEOF filterKind = iota
+	filterIdent
+	filterString
+	filterNumber
+	filterOp
+)
+
+// filterToken is one token of a filter expression; text holds a string literal unquoted
+type filterToken struct {
+	kind filterKind
+	text string
+	pos  int // byte offset in the expression, from 0
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case filterEOF:
+		return "end of expression"
+	case filterString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lexFilter splits a filter expression into tokens, ending with filterEOF
+func lexFilter(expr string) ([]filterToken, error) {
+	var toks []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("at %d: unterminated string", i)
+			}
+			s, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("at %d: bad string %s", i, expr[i:end+1])
+			}
+			toks = append(toks, filterToken{filterString, s, i})
+			i = end + 1
+		case c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(expr) && (expr[end] == '.' || expr[end] >= '0' && expr[end] <= '9') {
+				end++
+			}
+			if _, err := strconv.ParseFloat(expr[i:end], 64); err != nil {
+				return nil, fmt.Errorf("at %d: bad number %q", i, expr[i:end])
+			}
+			toks = append(toks, filterToken{filterNumber, expr[i:end], i})
+			i = end
+		case identByte(c):
+			end := i + 1
+			for end < len(expr) && (identByte(expr[end]) || expr[end] >= '0' && expr[end] <= '9') {
+				end++
+			}
+			toks = append(toks, filterToken{filterIdent, expr[i:end], i})
+			i = end
+		default:
+			op := ""
+			for _, o := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("at %d: unexpected character %q", i, c)
+			}
+			toks = append(toks, filterToken{filterOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, filterToken{kind: filterEOF, pos: len(expr)}), nil
+}
+
+// filterComparisons are the comparison operators of filter expressions besides contains
+var filterComparisons = []string{"==", "!=", "<", "<=", ">", ">="}
+
+// identByte reports whether c can start a field name or keyword
+func identByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// filterParser builds a predicate from filter tokens by recursive descent
+type filterParser[T any] struct {
+	toks []filterToken
+	next int
+}
+
+func (p *filterParser[T]) peek() filterToken { return p.toks[p.next] }
+
+func (p *filterParser[T]) take() filterToken {
+	t := p.toks[p.next]
+	if t.kind != filterEOF {
+		p.next++
+	}
+	return t
+}
+
+// accept takes the next token if it is the operator op
+func (p *filterParser[T]) accept(op string) bool {
+	if t := p.peek(); t.kind == filterOp && t.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser[T]) unexpected() error {
+	t := p.peek()
+	return fmt.Errorf("at %d: unexpected %s", t.pos, t)
+}
+
+// or parses comparisons joined by ||
+func (p *filterParser[T]) or() (func(Event[T]) bool, error) {
+	left, err := p.and()
+	for err == nil && p.accept("||") {
+		var right func(Event[T]) bool
+		if right, err = p.and(); err == nil {
+			l := left
+			left = func(ev Event[T]) bool { return l(ev) || right(ev) }
+		}
+	}
+	return left, err
+}
+
+// and parses comparisons joined by &&
+func (p *filterParser[T]) and() (func(Event[T]) bool, error) {
+	left, err := p.unary()
+	for err == nil && p.accept("&&") {
+		var right func(Event[T]) bool
+		if right, err = p.unary(); err == nil {
+			l := left
+			left = func(ev Event[T]) bool { return l(ev) && right(ev) }
+		}
+	}
+	return left, err
+}
+
+// unary parses a negation, a parenthesized expression or a comparison
+func (p *filterParser[T]) unary() (func(Event[T]) bool, error) {
+	switch {
+	case p.accept("!"):
+		pred, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(ev Event[T]) bool { return !pred(ev) }, nil
+	case p.accept("("):
+		pred, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected()
+		}
+		return pred, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses field op literal
+func (p *filterParser[T]) comparison() (func(Event[T]) bool, error) {
+	field := p.peek()
+	if field.kind != filterIdent {
+		return nil, p.unexpected()
+	}
+	p.take()
+	op := p.peek()
+	if !(op.kind == filterOp && slices.Contains(filterComparisons, op.text)) && !(op.kind == filterIdent && op.text == "contains") {
+		return nil, p.unexpected()
+	}
+	p.take()
+	lit := p.peek()
+	if lit.kind != filterString && lit.kind != filterNumber {
+		return nil, p.unexpected()
+	}
+	p.take()
+	wrong := fmt.Errorf("at %d: %s cannot be compared with %s %s", lit.pos, field.text, op.text, lit)
+	switch field.text {
+	case "id", "priority":
+		if lit.kind != filterNumber || op.text == "contains" {
+			return nil, wrong
+		}
+		n, _ := strconv.ParseFloat(lit.text, 64)
+		get := func(ev Event[T]) float64 { return float64(ev.ID) }
+		if field.text == "priority" {
+			get = func(ev Event[T]) float64 { return float64(ev.Priority) }
+		}
+		return func(ev Event[T]) bool { return compareOrdered(get(ev), n, op.text) }, nil
+	case "resource":
+		if lit.kind != filterString {
+			return nil, wrong
+		}
+		return func(ev Event[T]) bool { return compareString(ev.Resource, lit.text, op.text) }, nil
+	case "value":
+		if lit.kind == filterString {
+			return func(ev Event[T]) bool { return compareString(fmt.Sprint(ev.Value), lit.text, op.text) }, nil
+		}
+		if op.text == "contains" {
+			return nil, wrong
+		}
+		n, _ := strconv.ParseFloat(lit.text, 64)
+		return func(ev Event[T]) bool {
+			v, ok := numeric(ev.Value)
+			return ok && compareOrdered(v, n, op.text)
+		}, nil
+	case "time":
+		if lit.kind != filterString || op.text == "contains" {
+			return nil, wrong
+		}
+		at, err := time.Parse(time.RFC3339Nano, lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("at %d: time %s is not RFC 3339", lit.pos, lit)
+		}
+		return func(ev Event[T]) bool { return compareOrdered(ev.Time.Compare(at), 0, op.text) }, nil
+	}
+	return nil, fmt.Errorf("at %d: unknown field %q", field.pos, field.text)
+}
+
+// compareOrdered applies a comparison operator to a and b
+func compareOrdered[N int | float64](a, b N, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+// compareString applies a comparison operator or contains to a and b
+func compareString(a, b, op string) bool {
+	if op == "contains" {
+		return strings.Contains(a, b)
+	}
+	return compareOrdered(strings.Compare(a, b), 0, op)
+}
+
+// numeric returns v as a float64 if it holds a number
+func numeric(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompileFilter(t *testing.T) {
+	plate := Event[any]{ID: 7, Resource: "iron", Value: "Iron Plate", Priority: 2, Time: epoch}
+	gear := Event[any]{ID: 12, Resource: "iron", Value: 3.5, Time: epoch.Add(time.Hour)}
+	copper := Event[any]{ID: 3, Resource: "copper", Value: "Copper Plate", Time: epoch}
+	for _, tc := range []struct {
+		expr string
+		want [3]bool // plate, gear, copper
+	}{
+		{`resource == "iron" && value contains "Plate"`, [3]bool{true, false, false}},
+		{`resource != "iron" || id >= 12`, [3]bool{false, true, true}},
+		{`!(value contains "Plate")`, [3]bool{false, true, false}},
+		{`value > 3 && value <= 3.5`, [3]bool{false, true, false}},
+		{`value == "Copper Plate"`, [3]bool{false, false, true}},
+		{`priority > 0 || id < 5 && resource contains "opp"`, [3]bool{true, false, true}},
+		{`time > "2024-01-01T00:30:00Z"`, [3]bool{false, true, false}},
+		{`id != -1 && (id == 7 || id == 3)`, [3]bool{true, false, true}},
+		{"resource == \"ir\\u006fn\"", [3]bool{true, true, false}},
+	} {
+		pred, err := CompileFilter[any](tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		for i, ev := range []Event[any]{plate, gear, copper} {
+			if got := pred(ev); got != tc.want[i] {
+				t.Errorf("%s on event %d = %v, want %v", tc.expr, ev.ID, got, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`resource ==`,
+		`resource = "iron"`,
+		`colour == "red"`,
+		`id == "seven"`,
+		`resource > 3`,
+		`id contains 1`,
+		`time < "yesterday"`,
+		`(id == 1`,
+		`id == 1)`,
+		`id == 1 &&`,
+		`resource == "iron`,
+		`"iron" == resource`,
+		`id == 1.2.3`,
+		`id == 1 ; id == 2`,
+	} {
+		if _, err := CompileFilter[string](expr); err == nil {
+			t.Errorf("CompileFilter(%q) compiled", expr)
+		}
+	}
+}
+
+func TestCompileFilterWithConsumeFiltered(t *testing.T) {
+	pred, err := CompileFilter[string](`value contains "Plate" && id > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := NewMainBus[string]("iron")
+	bus.RemoveConveyor(1)
+	for i, v := range []string{"Iron Plate", "Ore", "Steel Plate", "Gear"} {
+		bus.Produce(Event[string]{ID: i + 1, Value: v})
+	}
+	var got []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeFiltered(0, &wg, pred, func(ev Event[string]) { got = append(got, ev.Value) })
+	bus.Close()
+	wg.Wait()
+	if len(got) != 1 || got[0] != "Steel Plate" {
+		t.Fatalf("handled %v, want [Steel Plate]", got)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// barrier is the internal event Flush queues on a conveyor
+type barrier struct {
+	reached chan struct{}
+	once    sync.Once
+}
+
+// pass marks the barrier reached
+func (b *barrier) pass() {
+	b.once.Do(func() { close(b.reached) })
+}
+
+// internal reports whether ev is a pill or a barrier, which are never handed out of the bus,
+// marking a barrier reached since what was queued before it is taken too
+func internal[T any](ev Event[T]) bool {
+	if ev.barrier != nil {
+		ev.barrier.pass()
+		return true
+	}
+	return ev.poison
+}
+
+// Flush returns once every event produced before the call has been handled, for checkpointing a
+// pipeline: unlike Drain, which only waits for the conveyors to be empty, it confirms that the
+// handlers are done with the events too, and the bus stays open. It queues a barrier, an internal
+// event, behind the events buffered on every live conveyor, waiting for room as Broadcast does,
+// and returns once a consumer has taken each barrier after handling what came before it: a
+// ConsumeBatch or ConsumeWindow consumer first flushes its batch or window, ConsumeBounded waits
+// for its in-flight handlers, and a Merger counts as done once it has handed the events on.
+// A barrier dropped, expired or taken off by Snapshot or DrainAll counts as reached, since the
+// events before it are gone as well. Barriers never reach handlers, so Flush needs handler-based
+// consumers that recognize them: code reading Conveyors or a Receiver directly gets them as events
+// with no ID and cannot complete a Flush, nor can a paused conveyor or one with no consumer
+// until it resumes or gets one. With several consumers on a conveyor, Flush only waits for the
+// one taking the barrier, so another may still be handling an earlier event. If ctx is done
+// first, Flush returns an error wrapping ctx.Err() and the barriers still queued are swallowed
+// later. A conveyor closed meanwhile is left out. A closed bus
+// returns ErrBusClosed.
+func (bus *MainBus[T]) Flush(ctx context.Context) error {
+	if bus.isClosed() {
+		return ErrBusClosed
+	}
+	t := bus.table()
+	marks := make(map[int]*barrier, len(t.live))
+	for _, line := range t.live {
+		m := &barrier{reached: make(chan struct{})}
+		err := bus.sendBlocking(ctx, line, t.belts[line], Event[T]{Resource: bus.Resource, Time: bus.now(), barrier: m})
+		switch {
+		case err == nil:
+			marks[line] = m
+		case err != errBeltClosed:
+			return fmt.Errorf("main bus %q: flushing conveyor %d: %w", bus.Resource, line, err)
+		}
+	}
+	for line, m := range marks {
+		select {
+		case <-m.reached:
+		case <-ctx.Done():
+			return fmt.Errorf("main bus %q: flushing conveyor %d: %w", bus.Resource, line, ctx.Err())
+		}
+	}
+	return nil
+}
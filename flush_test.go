@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlushWaitsForHandlers(t *testing.T) {
+	bus := NewMainBus[int]("copper", WithLines(4), WithBuffer(16), WithStrategy(StrategyRoundRobin))
+	var handled atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go bus.ConsumeWith(0, &wg, func(Event[int]) { time.Sleep(2 * time.Millisecond); handled.Add(1) })
+	go bus.ConsumeBatch(1, &wg, func(evs []Event[int]) { handled.Add(int32(len(evs))) }, 100, time.Hour)
+	go bus.ConsumeBounded(2, &wg, func(Event[int]) { time.Sleep(5 * time.Millisecond); handled.Add(1) }, 4)
+	go bus.ConsumeWindow(3, &wg, time.Hour, func(agg any, _ Event[int]) any {
+		n, _ := agg.(int)
+		return n + 1
+	}, func(_ time.Time, agg any) { handled.Add(int32(agg.(int))) })
+	for id := 1; id <= 40; id++ {
+		bus.Produce(Event[int]{ID: id, Value: id})
+	}
+	if err := bus.Flush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if n := handled.Load(); n != 40 {
+		t.Fatalf("Flush returned with %d of 40 events handled", n)
+	}
+	if m := bus.Metrics(); m.Consumed[0]+m.Consumed[1]+m.Consumed[2]+m.Consumed[3] != 44 {
+		t.Fatalf("consumed %v, want the 40 events and 4 barriers", m.Consumed)
+	}
+	bus.Close()
+	wg.Wait()
+	if n := handled.Load(); n != 40 {
+		t.Fatalf("handled %d events, want 40", n)
+	}
+}
+
+func TestFlushContext(t *testing.T) {
+	bus := NewMainBus[int]("copper")
+	defer bus.Close()
+	bus.Produce(Event[int]{ID: 1})
+	// nothing consumes, so the barriers are never reached
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush = %v, want context.DeadlineExceeded", err)
+	}
+	// the barriers left behind never reach a later consumer's handler
+	var got []Event[int]
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(ev Event[int]) { got = append(got, ev) })
+	}
+	if err := bus.Flush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("handled %v, want event 1 only", got)
+	}
+	bus.Close()
+	wg.Wait()
+	if err := bus.Flush(t.Context()); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Flush on a closed bus = %v, want ErrBusClosed", err)
+	}
+}
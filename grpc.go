@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// The remote bus is the gRPC service
+//
+//	service mainbus.Bus {
+//	  // Produce takes a stream of events and acknowledges each one in order
+//	  rpc Produce(stream WireEvent) returns (stream ProduceAck);
+//	  // Consume streams the events consumed from one conveyor until it is closed
+//	  rpc Consume(ConsumeRequest) returns (stream WireEvent);
+//	}
+//
+// with messages encoded by grpcCodec as JSON rather than protobuf, so no generated code is
+// needed. A WireEvent carries one event encoded with the bus Codec, compressed as its
+// compression field says; client and server must use the same codec.
+
+// grpcCodecName is the content subtype selecting grpcCodec
+const grpcCodecName = "mainbus-json"
+
+// grpcCodec marshals gRPC messages as JSON
+type grpcCodec struct{}
+
+func (grpcCodec) Marshal(v any) ([]byte, error)   { return json.Marshal(v) }
+func (grpcCodec) Unmarshal(b []byte, v any) error { return json.Unmarshal(b, v) }
+func (grpcCodec) Name() string                    { return grpcCodecName }
+
+func init() {
+	encoding.RegisterCodec(grpcCodec{})
+}
+
+// produceAck answers one event sent on a Produce stream
+type produceAck struct {
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"` // "closed" or "dropped" for the matching sentinel errors
+}
+
+// wireEvent carries one event encoded with the bus codec and compressed as Compression says
+type wireEvent struct {
+	Event       []byte `json:"event"`
+	Compression string `json:"compression,omitempty"` // Compression.String; empty means none
+}
+
+// newWireEvent encodes ev for the wire
+func newWireEvent[T any](codec Codec[T], comp Compression, ev Event[T]) (*wireEvent, error) {
+	data, err := encodeEvent(codec, comp, ev)
+	if err != nil {
+		return nil, err
+	}
+	msg := &wireEvent{Event: data}
+	if comp != CompressionNone {
+		msg.Compression = comp.String()
+	}
+	return msg, nil
+}
+
+// decodeWireEvent reverses newWireEvent
+func decodeWireEvent[T any](codec Codec[T], msg *wireEvent) (Event[T], error) {
+	comp := CompressionNone
+	if msg.Compression != "" {
+		var err error
+		if comp, err = parseCompression(msg.Compression); err != nil {
+			return Event[T]{}, err
+		}
+	}
+	return decodeEvent(codec, comp, msg.Event)
+}
+
+// consumeRequest opens a Consume stream
+type consumeRequest struct {
+	Line int `json:"line"`
+}
+
+// busService is implemented by BusServer; gRPC checks registered servers against it
+type busService interface {
+	produce(grpc.ServerStream) error
+	consume(grpc.ServerStream) error
+}
+
+var busServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mainbus.Bus",
+	HandlerType: (*busService)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Produce",
+			Handler:       func(srv any, s grpc.ServerStream) error { return srv.(busService).produce(s) },
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Consume",
+			Handler:       func(srv any, s grpc.ServerStream) error { return srv.(busService).consume(s) },
+			ServerStreams: true,
+		},
+	},
+}
+
+// BusServer serves a MainBus over gRPC
+type BusServer[T any] struct {
+	bus *MainBus[T]
+}
+
+// NewBusServer wraps bus for serving over gRPC
+func NewBusServer[T any](bus *MainBus[T]) *BusServer[T] {
+	return &BusServer[T]{bus: bus}
+}
+
+// Register adds the bus service to s
+func (srv *BusServer[T]) Register(s *grpc.Server) {
+	s.RegisterService(&busServiceDesc, srv)
+}
+
+// produce produces every event received on the stream, acknowledging each in turn. An event
+// without a resource gets the bus resource.
+func (srv *BusServer[T]) produce(s grpc.ServerStream) error {
+	for {
+		var msg wireEvent
+		if err := s.RecvMsg(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		ack := srv.produceOne(s.Context(), msg)
+		if err := s.SendMsg(&ack); err != nil {
+			return err
+		}
+	}
+}
+
+// produceOne decodes and produces one received event, returning its ack
+func (srv *BusServer[T]) produceOne(ctx context.Context, msg wireEvent) produceAck {
+	ev, err := decodeWireEvent(srv.bus.codec, &msg)
+	if err != nil {
+		return produceAck{Error: fmt.Sprintf("main bus %q: malformed event: %v", srv.bus.Resource, err)}
+	}
+	if ev.Resource == "" {
+		ev.Resource = srv.bus.Resource
+	}
+	var ack produceAck
+	if err := srv.bus.ProduceContext(ctx, ev); err != nil {
+		ack.Error = err.Error()
+		switch {
+		case errors.Is(err, ErrBusClosed):
+			ack.Code = "closed"
+		case errors.Is(err, ErrEventDropped):
+			ack.Code = "dropped"
+		}
+	}
+	return ack
+}
+
+// consume streams the events of one conveyor until it is closed or the client goes away. An
+// event taken off the conveyor that cannot be sent is rejected, or counted as dropped when
+// there is no dead-letter conveyor.
+func (srv *BusServer[T]) consume(s grpc.ServerStream) error {
+	var req consumeRequest
+	if err := s.RecvMsg(&req); err != nil {
+		return err
+	}
+	if req.Line < 0 || req.Line >= len(srv.bus.table().belts) {
+		return status.Errorf(codes.InvalidArgument, "main bus %q: no conveyor %d", srv.bus.Resource, req.Line)
+	}
+	ctx, cancel := context.WithCancel(s.Context())
+	defer cancel()
+	var sendErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	srv.bus.ConsumeContext(ctx, req.Line, &wg, func(ev Event[T]) {
+		if sendErr != nil {
+			return
+		}
+		var msg *wireEvent
+		if msg, sendErr = newWireEvent(srv.bus.codec, srv.bus.compression, ev); sendErr == nil {
+			sendErr = s.SendMsg(msg)
+		}
+		if sendErr != nil {
+			cancel()
+			if rerr := srv.bus.Reject(ev, "remote consumer disconnected"); rerr != nil && !errors.Is(rerr, ErrDeadLetterFull) {
+				srv.bus.drop(slog.LevelWarn, req.Line, ev, "remote consumer disconnected")
+			}
+		}
+	})
+	return sendErr
+}
+
+// BusClient talks to a BusServer, offering the produce and consume calls of a local MainBus
+type BusClient[T any] struct {
+	conn        grpc.ClientConnInterface
+	codec       Codec[T]
+	compression Compression
+
+	mu     sync.Mutex // serializes produces on the shared stream
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+// BusClientOption configures a BusClient
+type BusClientOption[T any] func(*BusClient[T])
+
+// WithClientCodec sets the codec events are sent and received with. It must match the codec of
+// the served bus; the default is JSONCodec.
+func WithClientCodec[T any](c Codec[T]) BusClientOption[T] {
+	return func(cl *BusClient[T]) {
+		cl.codec = c
+	}
+}
+
+// WithClientCompression compresses the events the client sends. Received events are
+// decompressed as the server marked them, whatever this setting.
+func WithClientCompression[T any](c Compression) BusClientOption[T] {
+	return func(cl *BusClient[T]) {
+		cl.compression = c
+	}
+}
+
+// NewBusClient returns a client for the bus served on conn. The connection itself reconnects
+// as needed; the client reopens its streams when they break.
+func NewBusClient[T any](conn grpc.ClientConnInterface, opts ...BusClientOption[T]) *BusClient[T] {
+	c := &BusClient[T]{conn: conn, codec: JSONCodec[T]{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// grpcCallOptions makes calls wait for the server to (re)connect and use the JSON codec
+var grpcCallOptions = []grpc.CallOption{grpc.WaitForReady(true), grpc.CallContentSubtype(grpcCodecName)}
+
+// Produce sends an event to the remote bus and waits for it to be accepted
+func (c *BusClient[T]) Produce(ev Event[T]) error {
+	return c.ProduceContext(context.Background(), ev)
+}
+
+// ProduceContext sends an event to the remote bus, giving up when ctx is done. It returns the
+// remote bus's ErrBusClosed and ErrEventDropped as those errors. If the stream breaks before the
+// event is acknowledged the client reopens it and sends the event once more, so an event may be
+// produced twice across a reconnect.
+func (c *BusClient[T]) ProduceContext(ctx context.Context, ev Event[T]) error {
+	msg, err := newWireEvent(c.codec, c.compression, ev)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for attempt := 0; attempt < 2; attempt++ {
+		var ack produceAck
+		if ack, err = c.roundTrip(ctx, msg); err == nil {
+			switch ack.Code {
+			case "":
+				if ack.Error != "" {
+					return errors.New(ack.Error)
+				}
+				return nil
+			case "closed":
+				return ErrBusClosed
+			case "dropped":
+				return ErrEventDropped
+			default:
+				return errors.New(ack.Error)
+			}
+		}
+		c.reset()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// roundTrip sends msg on the produce stream, opening it if needed, and reads its ack. A done ctx
+// tears the stream down so the call does not outlive it.
+func (c *BusClient[T]) roundTrip(ctx context.Context, msg *wireEvent) (produceAck, error) {
+	if c.stream == nil {
+		var sctx context.Context
+		sctx, c.cancel = context.WithCancel(context.Background())
+		stop := context.AfterFunc(ctx, c.cancel)
+		s, err := c.conn.NewStream(sctx, &busServiceDesc.Streams[0], "/mainbus.Bus/Produce", grpcCallOptions...)
+		stop()
+		if err != nil {
+			return produceAck{}, err
+		}
+		c.stream = s
+	}
+	stop := context.AfterFunc(ctx, c.cancel)
+	defer stop()
+	var ack produceAck
+	if err := c.stream.SendMsg(msg); err != nil {
+		return ack, err
+	}
+	err := c.stream.RecvMsg(&ack)
+	return ack, err
+}
+
+// reset drops a broken produce stream
+func (c *BusClient[T]) reset() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.stream, c.cancel = nil, nil
+}
+
+// Close ends the produce stream. Consumers are stopped through their contexts.
+func (c *BusClient[T]) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream != nil {
+		c.stream.CloseSend()
+	}
+	c.reset()
+	return nil
+}
+
+// ConsumeWith consumes a remote conveyor until it is closed, calling handler for each event
+func (c *BusClient[T]) ConsumeWith(line int, wg *sync.WaitGroup, handler func(Event[T])) error {
+	return c.ConsumeContext(context.Background(), line, wg, handler)
+}
+
+// ConsumeContext consumes a remote conveyor like ConsumeWith, but also returns when ctx is
+// cancelled. A broken stream is reopened with backoff, so the consumer survives server restarts.
+// It returns nil once the remote conveyor is closed or ctx is done, and an error only for a
+// request the server refuses, such as an unknown line.
+func (c *BusClient[T]) ConsumeContext(ctx context.Context, line int, wg *sync.WaitGroup, handler func(Event[T])) error {
+	defer wg.Done()
+	backoff := 10 * time.Millisecond
+	for {
+		err := c.consumeStream(ctx, line, handler, &backoff)
+		switch {
+		case err == nil || ctx.Err() != nil:
+			return nil
+		case status.Code(err) == codes.InvalidArgument || status.Code(err) == codes.Unimplemented:
+			return err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
+
+// consumeStream runs one Consume stream, resetting the backoff once events flow. It returns nil
+// when the server ends the stream because the conveyor was closed.
+func (c *BusClient[T]) consumeStream(ctx context.Context, line int, handler func(Event[T]), backoff *time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s, err := c.conn.NewStream(ctx, &busServiceDesc.Streams[1], "/mainbus.Bus/Consume", grpcCallOptions...)
+	if err != nil {
+		return err
+	}
+	if err := s.SendMsg(&consumeRequest{Line: line}); err != nil {
+		return err
+	}
+	if err := s.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		var msg wireEvent
+		if err := s.RecvMsg(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		ev, err := decodeWireEvent(c.codec, &msg)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "malformed event from server: %v", err)
+		}
+		*backoff = 10 * time.Millisecond
+		handler(ev)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// serveBus serves bus on addr ("" picks a free port) and returns the address and the server
+func serveBus(t *testing.T, bus *MainBus[string], addr string) (string, *grpc.Server) {
+	t.Helper()
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := grpc.NewServer()
+	NewBusServer(bus).Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), s
+}
+
+// dial returns a client for the bus served at addr
+func dial(t *testing.T, addr string, opts ...BusClientOption[string]) *BusClient[string] {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewBusClient[string](conn, opts...)
+}
+
+func TestGRPCProduceAndConsume(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithStrategy(StrategyRoundRobin))
+	addr, _ := serveBus(t, bus, "")
+	client := dial(t, addr)
+	defer client.Close()
+
+	got := make(chan Event[string], 4)
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go client.ConsumeWith(line, &wg, func(ev Event[string]) { got <- ev })
+	}
+	for i := 0; i < 4; i++ {
+		if err := client.Produce(Event[string]{ID: i, Value: "plate"}); err != nil {
+			t.Fatalf("Produce: %v", err)
+		}
+	}
+	seen := map[int]bool{}
+	for len(seen) < 4 {
+		select {
+		case ev := <-got:
+			if ev.Resource != "iron" || ev.Value != "plate" {
+				t.Fatalf("consumed %+v", ev)
+			}
+			seen[ev.ID] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("consumed %d of 4 events", len(seen))
+		}
+	}
+
+	bus.Close()
+	wg.Wait() // remote consumers return once the conveyors close
+	if err := client.Produce(Event[string]{ID: 5}); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Produce after Close = %v, want ErrBusClosed", err)
+	}
+}
+
+func TestGRPCConsumeUnknownLine(t *testing.T) {
+	bus := NewMainBus[string]("iron")
+	defer bus.Close()
+	addr, _ := serveBus(t, bus, "")
+	client := dial(t, addr)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	if err := client.ConsumeWith(7, &wg, func(Event[string]) {}); err == nil {
+		t.Fatal("consuming an unknown line succeeded")
+	}
+}
+
+func TestGRPCReconnect(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(8))
+	defer bus.Close()
+	bus.RemoveConveyor(1)
+	addr, first := serveBus(t, bus, "")
+	client := dial(t, addr)
+	defer client.Close()
+
+	got := make(chan int, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go client.ConsumeContext(ctx, 0, &wg, func(ev Event[string]) { got <- ev.ID })
+
+	if err := client.Produce(Event[string]{ID: 1}); err != nil {
+		t.Fatalf("Produce: %v", err)
+	}
+	if id := <-got; id != 1 {
+		t.Fatalf("consumed %d, want 1", id)
+	}
+
+	first.Stop()
+	serveBus(t, bus, addr)
+	pctx, pcancel := context.WithTimeout(ctx, 10*time.Second)
+	defer pcancel()
+	if err := client.ProduceContext(pctx, Event[string]{ID: 2}); err != nil {
+		t.Fatalf("Produce after restart: %v", err)
+	}
+	select {
+	case id := <-got:
+		if id != 2 {
+			t.Fatalf("consumed %d after restart, want 2", id)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("consumer did not reconnect")
+	}
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// maxHandoffFrame bounds the size of one frame read by ImportFrom, so a corrupt length cannot
+// make it allocate without limit
+const maxHandoffFrame = 64 << 20
+
+// ExportTo hands the bus's buffered events to another process, typically the one replacing it
+// in a zero-downtime deploy, over conn, such as a Unix socket connection. It takes every
+// event off the conveyors as Snapshot does, so stop producing first: events produced later
+// stay on the bus. The stream is a sequence of frames, each a 4-byte big-endian length followed
+// by that many bytes:
+//
+//   - a header frame, "#mainbus codec=<name> compression=<name>" as in the persistence log
+//     header, with " encryption=aes-gcm" appended when the bus was built WithEncryption
+//   - one frame per event, encoded with the bus codec, compressed and, with encryption, sealed
+//     as a persistence log record is before its base64 step
+//   - an empty frame marking the end, so ImportFrom can tell a complete handoff from a
+//     connection cut short
+//
+// If writing fails, the events not yet written are put back on the bus, as by Restore, and the
+// error is returned; events already written may or may not have reached the other side. ExportTo
+// does not close conn.
+func (bus *MainBus[T]) ExportTo(conn net.Conn) error {
+	evs, err := bus.Snapshot()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(conn)
+	sent, err := bus.exportEvents(w, evs)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		if rerr := bus.Restore(evs[sent:]); rerr != nil {
+			err = errors.Join(err, rerr)
+		}
+		return fmt.Errorf("main bus %q: export: %w", bus.Resource, err)
+	}
+	return nil
+}
+
+// exportEvents writes the header, evs and the end frame to w, returning how many events it
+// wrote before an error
+func (bus *MainBus[T]) exportEvents(w io.Writer, evs []Event[T]) (int, error) {
+	header := fmt.Sprintf("%scodec=%s compression=%s", logHeaderPrefix, codecName(bus.codec), bus.compression)
+	if bus.aead != nil {
+		header += " encryption=" + encryptionScheme
+	}
+	if err := writeFrame(w, []byte(header)); err != nil {
+		return 0, err
+	}
+	for i, ev := range evs {
+		data, err := bus.encodeFrame(ev)
+		if err == nil {
+			err = writeFrame(w, data)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(evs), writeFrame(w, nil)
+}
+
+// encodeFrame encodes ev for a frame with the bus codec, compression and key
+func (bus *MainBus[T]) encodeFrame(ev Event[T]) ([]byte, error) {
+	data, err := encodeEvent(bus.codec, bus.compression, ev)
+	if err == nil && bus.aead != nil {
+		data, err = sealRecord(bus.aead, data)
+	}
+	return data, err
+}
+
+// decodeFrame decodes a frame written by encodeFrame
+func (bus *MainBus[T]) decodeFrame(data []byte) (Event[T], error) {
+	if bus.aead != nil {
+		var err error
+		if data, err = openRecord(bus.aead, data); err != nil {
+			return Event[T]{}, err
+		}
+	}
+	return decodeEvent(bus.codec, bus.compression, data)
+}
+
+// ImportFrom reads the events sent by ExportTo from conn and places them on this bus, usually a
+// fresh one, as Restore does: across the conveyors by the bus strategy, skipping middleware and
+// blocking while the chosen conveyor is full. The bus must use the exporter's codec, and its
+// key if the stream is encrypted; otherwise the error wraps ErrInvalidConfig. A stream that
+// ends before its end frame returns an error wrapping io.ErrUnexpectedEOF, with the events
+// read so far already placed. ImportFrom does not close conn.
+func (bus *MainBus[T]) ImportFrom(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	header, err := readFrame(r)
+	if err != nil {
+		return fmt.Errorf("main bus %q: import: %w", bus.Resource, err)
+	}
+	codec, comp, encrypted, err := parseLogHeader(string(header))
+	switch {
+	case err != nil:
+		return fmt.Errorf("main bus %q: import: %w", bus.Resource, err)
+	case codec != codecName(bus.codec):
+		return fmt.Errorf("main bus %q: import: %w: stream uses codec %s, bus uses %s", bus.Resource, ErrInvalidConfig, codec, codecName(bus.codec))
+	case encrypted && bus.aead == nil:
+		return fmt.Errorf("main bus %q: import: %w: stream is encrypted and bus has no key", bus.Resource, ErrInvalidConfig)
+	}
+	for n := 0; ; n++ {
+		data, err := readFrame(r)
+		if err != nil {
+			return fmt.Errorf("main bus %q: import: event %d: %w", bus.Resource, n, err)
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		if encrypted {
+			if data, err = openRecord(bus.aead, data); err != nil {
+				return fmt.Errorf("main bus %q: import: event %d: %w", bus.Resource, n, err)
+			}
+		}
+		ev, err := decodeEvent(bus.codec, comp, data)
+		if err != nil {
+			return fmt.Errorf("main bus %q: import: event %d: %w", bus.Resource, n, err)
+		}
+		if _, err := bus.route(context.Background(), ev, true); err != nil {
+			return fmt.Errorf("main bus %q: import: event %d: %w", bus.Resource, n, err)
+		}
+		bus.occupy(1)
+	}
+}
+
+// writeFrame writes data prefixed with its length
+func writeFrame(w io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readFrame reads one frame written by writeFrame, reporting a stream that ends inside or
+// before it as io.ErrUnexpectedEOF
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxHandoffFrame {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the %d byte limit", n, maxHandoffFrame)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
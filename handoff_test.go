@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"testing"
+)
+
+// handoff exports from src to dst over an in-memory connection
+func handoff[T any](src, dst *MainBus[T]) (exportErr, importErr error) {
+	out, in := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- src.ExportTo(out)
+		out.Close()
+	}()
+	importErr = dst.ImportFrom(in)
+	in.Close()
+	return <-done, importErr
+}
+
+func TestExportImportHandsOffBufferedEvents(t *testing.T) {
+	opts := []Option{WithLines(2), WithBuffer(8), WithCodec[string](GobCodec[string]{}), WithCompression(CompressionZstd)}
+	old := NewMainBus[string]("iron", opts...)
+	defer old.Close()
+	for i := 1; i <= 6; i++ {
+		old.Produce(Event[string]{ID: i, Value: "plate"})
+	}
+	fresh := NewMainBus[string]("iron", opts...)
+	defer fresh.Close()
+
+	if exportErr, importErr := handoff(old, fresh); exportErr != nil || importErr != nil {
+		t.Fatalf("handoff: export %v, import %v", exportErr, importErr)
+	}
+	if d := old.TotalDepth(); d != 0 {
+		t.Fatalf("old bus still holds %d events", d)
+	}
+	evs, err := fresh.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, ev := range evs {
+		if ev.Value != "plate" {
+			t.Fatalf("imported %+v, want Value plate", ev)
+		}
+		ids = append(ids, ev.ID)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []int{1, 2, 3, 4, 5, 6}) {
+		t.Fatalf("imported IDs %v, want 1 to 6", ids)
+	}
+}
+
+func TestImportFromTruncatedStream(t *testing.T) {
+	out, in := net.Pipe()
+	go func() {
+		writeFrame(out, []byte(logHeaderPrefix+"codec=json compression=none"))
+		writeFrame(out, []byte(`{"ID":1}`))
+		out.Close() // no end frame
+	}()
+	bus := NewMainBus[int]("iron")
+	defer bus.Close()
+	if err := bus.ImportFrom(in); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("ImportFrom = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if d := bus.TotalDepth(); d != 1 {
+		t.Fatalf("TotalDepth = %d, want the one event read before the cut", d)
+	}
+}
+
+func TestImportFromCodecMismatch(t *testing.T) {
+	src := NewMainBus[int]("iron", WithCodec[int](GobCodec[int]{}))
+	defer src.Close()
+	src.Produce(Event[int]{ID: 1})
+	dst := NewMainBus[int]("iron")
+	defer dst.Close()
+	out, in := net.Pipe()
+	go func() {
+		src.ExportTo(out) // fails once the importer hangs up, putting the event back
+		out.Close()
+	}()
+	err := dst.ImportFrom(in)
+	in.Close()
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("ImportFrom = %v, want ErrInvalidConfig", err)
+	}
+}
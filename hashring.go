@@ -0,0 +1,94 @@
+package main
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// DefaultVirtualNodes is how many points each conveyor gets on the StrategyConsistentHash ring
+// unless WithVirtualNodes says otherwise
+const DefaultVirtualNodes = 128
+
+// WithVirtualNodes sets how many points each conveyor gets on the StrategyConsistentHash ring,
+// and selects that strategy; counts below one use DefaultVirtualNodes. More points spread keys
+// more evenly over the conveyors, at the cost of a larger ring to build whenever the conveyors
+// change and a slightly slower lookup. Keys come from WithKeyFunc, which keeps this strategy
+// whichever order the two options are given in.
+func WithVirtualNodes(n int) Option {
+	return func(c *busConfig) {
+		c.strategy = StrategyConsistentHash
+		c.vnodes = n
+	}
+}
+
+// hashRing maps keys onto the live lines of a layout by consistent hashing: every line owns
+// vnodes points on a ring of 64-bit hashes, and a key goes to the owner of the first point at or
+// after its own hash
+type hashRing struct {
+	points []uint64 // sorted
+	owners []int    // owners[i] is the line owning points[i]
+}
+
+// ringHash hashes s onto the ring
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// FNV leaves similar short inputs close together; a finalizer spreads them over the ring
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// newHashRing builds the ring for live, placing each line's points by its line number so a line
+// keeps its points whatever other lines come and go
+func newHashRing(live []int, vnodes int) *hashRing {
+	type point struct {
+		hash uint64
+		line int
+	}
+	pts := make([]point, 0, len(live)*vnodes)
+	for _, line := range live {
+		prefix := strconv.Itoa(line) + "#"
+		for v := range vnodes {
+			pts = append(pts, point{ringHash(prefix + strconv.Itoa(v)), line})
+		}
+	}
+	slices.SortFunc(pts, func(a, b point) int {
+		if a.hash != b.hash {
+			return cmp.Compare(a.hash, b.hash)
+		}
+		return a.line - b.line
+	})
+	r := &hashRing{points: make([]uint64, len(pts)), owners: make([]int, len(pts))}
+	for i, p := range pts {
+		r.points[i], r.owners[i] = p.hash, p.line
+	}
+	return r
+}
+
+// lookup returns the line owning key
+func (r *hashRing) lookup(key string) int {
+	i, _ := slices.BinarySearch(r.points, ringHash(key))
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+// ringOf returns the consistent-hash ring of t, building it on first use
+func (bus *MainBus[T]) ringOf(t *lineTable[T]) *hashRing {
+	t.ringOnce.Do(func() {
+		n := bus.vnodes
+		if n < 1 {
+			n = DefaultVirtualNodes
+		}
+		t.ring = newHashRing(t.live, n)
+	})
+	return t.ring
+}
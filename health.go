@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultStallThreshold is how long a conveyor may stay completely full before Health reports it
+// stalled, unless WithStallThreshold says otherwise
+const DefaultStallThreshold = 10 * time.Second
+
+// HealthState summarises a bus for readiness probes
+type HealthState string
+
+const (
+	// HealthHealthy means the bus is open and no conveyor is full
+	HealthHealthy HealthState = "healthy"
+	// HealthDegraded means some, but not all, conveyors are full
+	HealthDegraded HealthState = "degraded"
+	// HealthUnhealthy means the bus is closed, every conveyor is full, or one has stalled
+	HealthUnhealthy HealthState = "unhealthy"
+)
+
+// HealthStatus is a point-in-time health report, shaped for serving as JSON
+type HealthStatus struct {
+	State     HealthState      `json:"state"`
+	Open      bool             `json:"open"`
+	Consumers int              `json:"consumers"` // handler-based consumers attached to live conveyors
+	Conveyors []ConveyorHealth `json:"conveyors"` // live conveyors only
+}
+
+// ConveyorHealth is the health of one live conveyor
+type ConveyorHealth struct {
+	Line          int      `json:"line"`
+	Label         string   `json:"label,omitempty"`
+	Consumers     int      `json:"consumers"`
+	ConsumerNames []string `json:"consumer_names,omitempty"` // consumers started with WithConsumerName
+	Saturation    float64  `json:"saturation"`               // depth over capacity; 0 for an unbuffered conveyor
+	Stalled       bool     `json:"stalled"`                  // full for longer than the stall threshold
+}
+
+// WithStallThreshold sets how long a conveyor may stay full before Health reports it stalled
+func WithStallThreshold(d time.Duration) Option {
+	return func(c *busConfig) {
+		c.stallThreshold = d
+	}
+}
+
+// Health reports whether the bus is open, how many consumers are attached and how full each live
+// conveyor is. A conveyor that has been full for longer than the stall threshold with nothing
+// taken off it is stalled, which tells a deadlocked bus apart from a merely busy one. Only
+// consumers started with the bus's Consume methods, ConsumeAll or NewMerger are counted, not
+// code reading Conveyors directly.
+func (bus *MainBus[T]) Health() HealthStatus {
+	h := HealthStatus{Open: !bus.isClosed()}
+	t := bus.table()
+	full, stalled := 0, false
+	for _, line := range t.live {
+		b := t.belts[line]
+		ch := ConveyorHealth{Line: line, Label: b.label, Consumers: int(b.consumers.Load()), ConsumerNames: b.consumerNames()}
+		if c := b.capacity(); c > 0 {
+			ch.Saturation = float64(b.depth()) / float64(c)
+		}
+		if ch.Saturation >= 1 {
+			full++
+			since := b.fullSince.Load()
+			ch.Stalled = since != 0 && bus.since(time.Unix(0, since)) > bus.stallThreshold
+			stalled = stalled || ch.Stalled
+		}
+		h.Consumers += ch.Consumers
+		h.Conveyors = append(h.Conveyors, ch)
+	}
+	switch {
+	case !h.Open || stalled || (full > 0 && full == len(t.live)):
+		h.State = HealthUnhealthy
+	case full > 0:
+		h.State = HealthDegraded
+	default:
+		h.State = HealthHealthy
+	}
+	return h
+}
+
+// trackFull notes when a conveyor fills up completely, by clock, and when it stops being full
+func (b *belt[T]) trackFull(clock Clock) {
+	if c := b.capacity(); c == 0 || b.depth() < c {
+		b.fullSince.Store(0)
+	} else {
+		b.fullSince.CompareAndSwap(0, clock.Now().UnixNano())
+	}
+}
+
+// attach counts and registers an unnamed consumer on line until its detach func is called
+func (bus *MainBus[T]) attach(line int) *consumer {
+	return bus.attachAs(line, "")
+}
+
+// Consumers returns how many consumers are attached to the live conveyors, counted as Health
+// does: a consumer reading several conveyors, such as ConsumeAll, counts once per conveyor
+func (bus *MainBus[T]) Consumers() int {
+	t := bus.table()
+	n := 0
+	for _, line := range t.live {
+		n += int(t.belts[line].consumers.Load())
+	}
+	return n
+}
+
+// WaitForConsumers blocks until at least n consumers are attached, as counted by Consumers, so
+// producers can hold off until something drains the conveyors instead of filling them in a
+// cold-start burst. Consumers count from the moment their Consume method starts until it
+// returns. A non-positive timeout waits without limit. It returns an error wrapping
+// context.DeadlineExceeded if the consumers do not attach in time, and ErrBusClosed if the bus
+// closes first.
+func (bus *MainBus[T]) WaitForConsumers(n int, timeout time.Duration) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if bus.isClosed() {
+			return ErrBusClosed
+		}
+		got := bus.Consumers()
+		if got >= n {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return fmt.Errorf("main bus %q: %d of %d consumers attached after %v: %w", bus.Resource, got, n, timeout, context.DeadlineExceeded)
+		}
+	}
+}
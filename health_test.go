@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthStates(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2), WithStrategy(StrategyRoundRobin), WithStallThreshold(30*time.Millisecond))
+	if h := bus.Health(); h.State != HealthHealthy || !h.Open || h.Consumers != 0 || len(h.Conveyors) != 2 {
+		t.Fatalf("idle bus: %+v", h)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeAck(1, &wg, func(Event[int]) error { return nil })
+	for bus.Health().Consumers != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// fill line 0 only; line 1 is consumed as it fills
+	bus.Produce(Event[int]{})
+	bus.Produce(Event[int]{})
+	bus.Produce(Event[int]{})
+	h := bus.Health()
+	if h.State != HealthDegraded || h.Conveyors[0].Saturation != 1 || h.Conveyors[0].Stalled {
+		t.Fatalf("one full conveyor: %+v", h)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if h := bus.Health(); h.State != HealthUnhealthy || !h.Conveyors[0].Stalled || h.Conveyors[1].Stalled {
+		t.Fatalf("conveyor full past the stall threshold: %+v", h)
+	}
+	<-bus.Conveyors[0]
+	bus.onConsumed(0)
+	if h := bus.Health(); h.State != HealthHealthy {
+		t.Fatalf("after taking an event off the stalled conveyor: %+v", h)
+	}
+
+	bus.Close()
+	wg.Wait()
+	if h := bus.Health(); h.State != HealthUnhealthy || h.Open || h.Consumers != 0 {
+		t.Fatalf("closed bus: %+v", h)
+	}
+}
+
+func TestHTTPHealth(t *testing.T) {
+	bus := NewMainBus[int]("iron")
+	srv := httptest.NewServer(BusHTTPHandler(bus))
+	defer srv.Close()
+	get := func() (int, HealthStatus) {
+		resp, err := http.Get(srv.URL + "/health")
+		if err != nil {
+			t.Fatalf("GET /health: %v", err)
+		}
+		defer resp.Body.Close()
+		var h HealthStatus
+		if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+			t.Fatalf("decoding health: %v", err)
+		}
+		return resp.StatusCode, h
+	}
+	if code, h := get(); code != http.StatusOK || h.State != HealthHealthy {
+		t.Fatalf("open bus: %d %+v", code, h)
+	}
+	bus.Close()
+	if code, h := get(); code != http.StatusServiceUnavailable || h.State != HealthUnhealthy {
+		t.Fatalf("closed bus: %d %+v", code, h)
+	}
+}
+
+func TestWaitForConsumersGatesProducer(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(1))
+	var handled atomic.Int32
+	var wg sync.WaitGroup
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for line := range 2 {
+			wg.Add(1)
+			go bus.ConsumeWith(line, &wg, func(Event[int]) { handled.Add(1) })
+		}
+	}()
+	if err := bus.WaitForConsumers(2, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if n := bus.Consumers(); n < 2 {
+		t.Fatalf("gate opened with %d consumers", n)
+	}
+	// producing starts only now that both conveyors are being drained
+	for i := range 20 {
+		if err := bus.Produce(Event[int]{ID: i + 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus.Close()
+	wg.Wait()
+	if handled.Load() != 20 {
+		t.Errorf("handled %d events, want 20", handled.Load())
+	}
+	if n := bus.Consumers(); n != 0 {
+		t.Errorf("%d consumers still counted after they exited", n)
+	}
+}
+
+func TestWaitForConsumersTimeout(t *testing.T) {
+	bus := NewMainBus[int](Iron)
+	err := bus.WaitForConsumers(1, 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want a deadline error", err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		bus.Close()
+	}()
+	if err := bus.WaitForConsumers(1, 0); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("err = %v, want ErrBusClosed", err)
+	}
+}
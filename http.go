@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxProduceBody caps the size of a POST /produce request body
+const maxProduceBody = 1 << 20
+
+// httpProduceTimeout bounds how long POST /produce waits for room on a full conveyor (or for a
+// rate-limit token) before answering 503
+const httpProduceTimeout = 250 * time.Millisecond
+
+// BusHTTPHandler exposes a bus over HTTP. POST /produce decodes an event with the bus codec
+// (JSON, as produced by Event.MarshalJSON, by default), decompressing a body sent with a gzip
+// or zstd Content-Encoding, and produces it with the request context,
+// answering 202 once it is accepted, 400 for a malformed event and 503 if the bus is closed, the
+// overflow policy dropped the event, or the conveyors stayed full for httpProduceTimeout. An
+// event without a resource gets the bus resource. GET /metrics returns the bus Metrics as JSON.
+// GET /health returns the bus Health as JSON, with status 503 when it is unhealthy so it can
+// back a readiness probe.
+func BusHTTPHandler[T any](bus *MainBus[T]) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /produce", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxProduceBody))
+		if err != nil {
+			http.Error(w, "reading event: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		comp := CompressionNone
+		if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+			if comp, err = parseCompression(enc); err != nil {
+				http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		ev, err := decodeEvent(bus.codec, comp, body)
+		if err != nil {
+			http.Error(w, "malformed event: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ev.Resource == "" {
+			ev.Resource = bus.Resource
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), httpProduceTimeout)
+		defer cancel()
+		switch err := bus.ProduceContext(ctx, ev); {
+		case err == nil:
+			w.WriteHeader(http.StatusAccepted)
+		case errors.Is(err, ErrBusClosed), errors.Is(err, ErrEventDropped),
+			errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(bus.Metrics())
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		h := bus.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if h.State == HealthUnhealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+	return mux
+}
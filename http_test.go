@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// post sends body to POST /produce and returns the status code
+func post(t *testing.T, srv *httptest.Server, body string) int {
+	t.Helper()
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Post(srv.URL+"/produce", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /produce: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHTTPProduce(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(1))
+	bus.RemoveConveyor(1)
+	srv := httptest.NewServer(BusHTTPHandler(bus))
+	defer srv.Close()
+
+	if code := post(t, srv, `{"id":1,"value":"plate"}`); code != http.StatusAccepted {
+		t.Fatalf("valid event: %d, want 202", code)
+	}
+	if code := post(t, srv, `{"id":`); code != http.StatusBadRequest {
+		t.Fatalf("malformed JSON: %d, want 400", code)
+	}
+	start := time.Now()
+	if code := post(t, srv, `{"id":2,"value":"plate"}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("full bus: %d, want 503", code)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("full bus took %v to answer", d)
+	}
+
+	ev := <-bus.Conveyors[0]
+	if ev.ID != 1 || ev.Resource != "iron" || ev.Value != "plate" {
+		t.Fatalf("produced %+v", ev)
+	}
+	bus.Close()
+	if code := post(t, srv, `{"id":3}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("closed bus: %d, want 503", code)
+	}
+}
+
+func TestHTTPProduceDropped(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithBuffer(0), WithOverflowPolicy(OverflowDropNewest))
+	defer bus.Close()
+	srv := httptest.NewServer(BusHTTPHandler(bus))
+	defer srv.Close()
+
+	if code := post(t, srv, `{"id":1}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("dropped event: %d, want 503", code)
+	}
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	bus := NewMainBus[string]("iron")
+	defer bus.Close()
+	bus.Produce(Event[string]{ID: 1})
+	srv := httptest.NewServer(BusHTTPHandler(bus))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	var m BusMetrics
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		t.Fatalf("decode metrics: %v", err)
+	}
+	if m.Produced[0]+m.Produced[1] != 1 {
+		t.Fatalf("metrics %+v, want one produced event", m)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// StopReason tells why a consumer returned
+type StopReason int
+
+const (
+	// StopClosed means the conveyor was closed and drained
+	StopClosed StopReason = iota
+	// StopIdle means no event arrived within the idle timeout
+	StopIdle
+	// StopPoisoned means the consumer took a pill sent by Poison
+	StopPoisoned
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopClosed:
+		return "closed"
+	case StopIdle:
+		return "idle"
+	case StopPoisoned:
+		return "poisoned"
+	default:
+		return "unknown"
+	}
+}
+
+// ConsumeWithIdleTimeout consumes a specific conveyor like ConsumeWith, but returns StopIdle once
+// no event has arrived for idle, which suits short-lived batch jobs that should exit when the
+// belt goes quiet. Closing the conveyor still stops it immediately with StopClosed. Time spent
+// paused does not count as idle.
+func (bus *MainBus[T]) ConsumeWithIdleTimeout(line int, wg *sync.WaitGroup, handler func(Event[T]), idle time.Duration) StopReason {
+	defer wg.Done()
+	self := bus.attach(line)
+	defer self.detach()
+	c := bus.conveyor(line)
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		if bus.Paused(line) {
+			bus.waitResumed(context.Background(), line)
+			timer.Reset(idle)
+		}
+		select {
+		case ev, ok := <-c:
+			if !ok && bus.reopened(line, &c) {
+				continue
+			}
+			if !ok {
+				return StopClosed
+			}
+			if bus.poisoned(line, ev) {
+				return StopPoisoned
+			}
+			bus.deliver(line, ev, handler)
+			self.done()
+			timer.Reset(idle)
+		case <-timer.C:
+			return StopIdle
+		}
+	}
+}
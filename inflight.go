@@ -0,0 +1,83 @@
+package main
+
+import "context"
+
+// WithMaxTotalInFlight caps the events buffered across all conveyors of the bus at n, however
+// they are spread, so a bus with many conveyors cannot grow without bound: once n events are
+// buffered or being sent, Produce, ProduceContext, ProduceBatch and Producer.Send block until
+// consumers make room, whatever the overflow policy, and TryProduce fails. Events moved between
+// conveyors by the bus itself, broadcasts and restores are not held back, though they count
+// toward the cap. A non-positive n disables the cap.
+func WithMaxTotalInFlight(n int) Option {
+	return func(c *busConfig) {
+		c.maxInFlight = n
+	}
+}
+
+// The WithMaxTotalInFlight cap is kept with one counter, bus.inflight, of the events buffered on
+// the conveyors plus those admitted and still being sent. A producer takes its slot in admit and
+// keeps it once the event lands; the slot is given back by vacate when the event leaves a
+// conveyor, by being consumed, evicted or lost with a removed conveyor, or when it never landed.
+// Events placed without admission take theirs with occupy. Each vacate wakes one producer
+// blocked at the cap through bus.room, and a producer that gets in passes the wake-up on while
+// room is left, so freeing several slots at once wakes as many producers.
+
+// admit waits until the bus is under its WithMaxTotalInFlight cap and takes a slot for one event,
+// which the caller must give back with vacate unless the event lands. It returns ctx.Err() or
+// ErrBusClosed if the wait is cut short.
+func (bus *MainBus[T]) admit(ctx context.Context) error {
+	for !bus.tryAdmit() {
+		select {
+		case <-bus.room:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-bus.done:
+			return ErrBusClosed
+		}
+	}
+	if bus.maxInFlight > 0 && bus.inflight.Load() < int64(bus.maxInFlight) {
+		bus.wake()
+	}
+	return nil
+}
+
+// tryAdmit takes a slot for one event if the bus is under its cap
+func (bus *MainBus[T]) tryAdmit() bool {
+	return bus.maxInFlight <= 0 || bus.reserve(1)
+}
+
+// reserve takes n slots at once if that many are free
+func (bus *MainBus[T]) reserve(n int) bool {
+	for {
+		cur := bus.inflight.Load()
+		if cur+int64(n) > int64(bus.maxInFlight) {
+			return false
+		}
+		if bus.inflight.CompareAndSwap(cur, cur+int64(n)) {
+			return true
+		}
+	}
+}
+
+// occupy counts n events placed on the conveyors without admission
+func (bus *MainBus[T]) occupy(n int) {
+	if bus.maxInFlight > 0 {
+		bus.inflight.Add(int64(n))
+	}
+}
+
+// vacate gives back the slot of one event and wakes a producer waiting for it
+func (bus *MainBus[T]) vacate() {
+	if bus.maxInFlight > 0 {
+		bus.inflight.Add(-1)
+		bus.wake()
+	}
+}
+
+// wake lets one producer blocked in admit try again
+func (bus *MainBus[T]) wake() {
+	select {
+	case bus.room <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxTotalInFlightBlocksAtCap(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(10), WithStrategy(StrategyRoundRobin), WithMaxTotalInFlight(3))
+	defer bus.Close()
+	for i := 0; i < 3; i++ { // conveyors 0 to 2, each far from full
+		if err := bus.Produce(Event[int]{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bus.TryProduce(Event[int]{ID: 3}) {
+		t.Fatal("TryProduce succeeded at the global cap")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.ProduceContext(ctx, Event[int]{ID: 3}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ProduceContext at the cap = %v, want a deadline error", err)
+	}
+
+	produced := make(chan error, 1)
+	go func() { produced <- bus.Produce(Event[int]{ID: 4}) }()
+	select {
+	case err := <-produced:
+		t.Fatalf("Produce returned %v at the global cap, want it to block", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if _, err := CollectN(bus, 0, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-produced:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Produce still blocked after a consumer made room")
+	}
+	if d := bus.TotalDepth(); d != 3 {
+		t.Fatalf("TotalDepth = %d, want 3", d)
+	}
+}
+
+func TestMaxTotalInFlightUnblocksOnClose(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithBuffer(4), WithMaxTotalInFlight(1))
+	bus.Produce(Event[int]{ID: 1})
+	produced := make(chan error, 1)
+	go func() { produced <- bus.Produce(Event[int]{ID: 2}) }()
+	time.Sleep(10 * time.Millisecond)
+	bus.Close()
+	select {
+	case err := <-produced:
+		if !errors.Is(err, ErrBusClosed) {
+			t.Fatalf("blocked Produce = %v, want ErrBusClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Produce still blocked after Close")
+	}
+}
+
+func TestMaxTotalInFlightWakesEveryFreedSlot(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(10), WithMaxTotalInFlight(3))
+	defer bus.Close()
+	for i := 0; i < 3; i++ {
+		if err := bus.Produce(Event[int]{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	produced := make(chan error, 3)
+	for i := 3; i < 6; i++ {
+		go func() { produced <- bus.Produce(Event[int]{ID: i}) }()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(bus.DrainAll()); n != 3 {
+		t.Fatalf("drained %d events, want the 3 under the cap", n)
+	}
+	// three slots freed at once let all three blocked producers in
+	for range 3 {
+		select {
+		case err := <-produced:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a producer is still blocked after the drain freed its slot")
+		}
+	}
+	if d := bus.TotalDepth(); d != 3 || bus.TryProduce(Event[int]{ID: 6}) {
+		t.Fatalf("TotalDepth = %d and TryProduce succeeded, want 3 buffered at the cap", d)
+	}
+}
+
+func TestMaxTotalInFlightCountsBroadcasts(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(10), WithMaxTotalInFlight(3))
+	defer bus.Close()
+	if err := bus.Broadcast(Event[int]{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if !bus.TryProduce(Event[int]{ID: 2}) {
+		t.Fatal("TryProduce failed with one slot left after a broadcast to two conveyors")
+	}
+	if bus.TryProduce(Event[int]{ID: 3}) {
+		t.Fatal("TryProduce succeeded over the cap")
+	}
+	bus.DrainAll()
+	if !bus.TryProduce(Event[int]{ID: 4}) {
+		t.Fatal("TryProduce failed once the broadcast copies were consumed")
+	}
+}
+
+func TestMaxTotalInFlightFreesCoalescedSlots(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithBuffer(4), WithMaxTotalInFlight(3), WithBufferFactory[int](NewCoalescingBuffer[int]))
+	defer bus.Close()
+	bus.RemoveConveyor(1)
+	// every event has the same ID, so all but the one handed out first merge into a single one
+	for i := range 10 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := bus.ProduceContext(ctx, Event[int]{ID: 7, Value: i})
+		cancel()
+		if err != nil {
+			t.Fatalf("event %d: %v, want the merged events' slots given back", i, err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if d := bus.TotalDepth(); d != 2 {
+		t.Fatalf("TotalDepth = %d, want 2", d)
+	}
+	if !bus.TryProduce(Event[int]{ID: 8}) {
+		t.Fatal("TryProduce failed with two events buffered under a cap of three")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// valueTypes maps type tags to the Go types registered with RegisterValueType, and back
+var valueTypes = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{byName: map[string]reflect.Type{}, byType: map[reflect.Type]string{}}
+
+// builtinTags are the type tags used for common values that need no registration
+var builtinTags = map[reflect.Type]string{
+	reflect.TypeFor[string]():         "string",
+	reflect.TypeFor[bool]():           "bool",
+	reflect.TypeFor[int]():            "int",
+	reflect.TypeFor[int64]():          "int64",
+	reflect.TypeFor[float64]():        "float64",
+	reflect.TypeFor[[]byte]():         "bytes",
+	reflect.TypeFor[map[string]any](): "map",
+	reflect.TypeFor[[]any]():          "slice",
+}
+
+// RegisterValueType registers a custom payload type under name, so events with an interface
+// Value (such as Event[any]) holding that type round-trip through JSON. proto is any value of
+// the type, e.g. IronPlate{}.
+func RegisterValueType(name string, proto any) {
+	t := reflect.TypeOf(proto)
+	valueTypes.Lock()
+	defer valueTypes.Unlock()
+	valueTypes.byName[name] = t
+	valueTypes.byType[t] = name
+}
+
+// eventJSON is the wire shape of an Event
+type eventJSON struct {
+	ID       int               `json:"id"`
+	Resource string            `json:"resource"`
+	Type     string            `json:"type,omitempty"`
+	Value    json.RawMessage   `json:"value"`
+	Time     time.Time         `json:"time"`
+	Priority int               `json:"priority,omitempty"`
+	Carrier  map[string]string `json:"carrier,omitempty"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// MarshalJSON encodes the event, tagging Value with its type so it can be restored when the
+// event's value type is an interface. Events whose Value is a string, bool, int, int64, float64,
+// []byte or nil are written directly, without encoding/json and its reflection, into the same
+// bytes encoding/json would produce.
+func (ev Event[T]) MarshalJSON() ([]byte, error) {
+	if data, ok := appendEventJSON(nil, ev); ok {
+		return data, nil
+	}
+	return ev.marshalReflect()
+}
+
+// marshalReflect is MarshalJSON through encoding/json, for values off the fast path
+func (ev Event[T]) marshalReflect() ([]byte, error) {
+	value, err := json.Marshal(ev.Value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(eventJSON{
+		ID:       ev.ID,
+		Resource: ev.Resource,
+		Type:     valueTag(ev.Value),
+		Value:    value,
+		Time:     ev.Time,
+		Priority: ev.Priority,
+		Carrier:  ev.Carrier,
+
+		CorrelationID: ev.CorrelationID,
+	})
+}
+
+// UnmarshalJSON decodes an event. Concrete value types decode directly. For interface value
+// types the type tag selects a builtin or registered type; untagged or unknown values decode
+// into their natural JSON type (string, float64, bool, map[string]any, []any). The values
+// MarshalJSON writes without reflection are read without it too, the rest of the event still
+// through encoding/json.
+func (ev *Event[T]) UnmarshalJSON(data []byte) error {
+	var raw eventJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	value, err := decodeValue[T](raw.Type, raw.Value)
+	if err != nil {
+		return err
+	}
+	*ev = Event[T]{ID: raw.ID, Resource: raw.Resource, Value: value, Time: raw.Time, Priority: raw.Priority, Carrier: raw.Carrier, CorrelationID: raw.CorrelationID}
+	return nil
+}
+
+// valueTag returns the type tag for v, or "" if its type is neither builtin nor registered
+func valueTag(v any) string {
+	if tag, ok := fastTag(v); ok {
+		return tag
+	}
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return ""
+	}
+	if tag, ok := builtinTags[t]; ok {
+		return tag
+	}
+	valueTypes.RLock()
+	defer valueTypes.RUnlock()
+	return valueTypes.byType[t]
+}
+
+// decodeValue decodes raw into a T, using tag to pick the dynamic type when T is an interface
+func decodeValue[T any](tag string, raw json.RawMessage) (T, error) {
+	var value T
+	if len(raw) == 0 {
+		return value, nil
+	}
+	if v, ok := decodeFast[T](tag, raw); ok {
+		return v, nil
+	}
+	return decodeValueReflect[T](tag, raw)
+}
+
+// decodeValueReflect is decodeValue through encoding/json, for values off the fast path
+func decodeValueReflect[T any](tag string, raw json.RawMessage) (T, error) {
+	var value T
+	if reflect.TypeFor[T]().Kind() != reflect.Interface {
+		err := json.Unmarshal(raw, &value)
+		return value, err
+	}
+
+	t := typeForTag(tag)
+	if t == nil {
+		var natural any
+		if err := json.Unmarshal(raw, &natural); err != nil {
+			return value, err
+		}
+		return assignValue[T](natural)
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return value, err
+	}
+	return assignValue[T](ptr.Elem().Interface())
+}
+
+// typeForTag resolves a builtin or registered type tag
+func typeForTag(tag string) reflect.Type {
+	if tag == "" {
+		return nil
+	}
+	for t, name := range builtinTags {
+		if name == tag {
+			return t
+		}
+	}
+	valueTypes.RLock()
+	defer valueTypes.RUnlock()
+	return valueTypes.byName[tag]
+}
+
+// assignValue converts a decoded dynamic value to the interface type T
+func assignValue[T any](v any) (T, error) {
+	if v == nil {
+		var zero T
+		return zero, nil
+	}
+	value, ok := v.(T)
+	if !ok {
+		return value, fmt.Errorf("main bus: decoded value of type %T does not implement %v", v, reflect.TypeFor[T]())
+	}
+	return value, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// WithConveyorLabels names the conveyors, in line order, for logs, metrics and health reports;
+// an empty label leaves that conveyor known by its index. There must be one label per conveyor
+// built, after rounding up to an even count; conveyors added later have none.
+func WithConveyorLabels(labels []string) Option {
+	return func(c *busConfig) {
+		c.labels = labels
+	}
+}
+
+// validateLabels checks that there is one label per conveyor
+func validateLabels(labels []string, lines int) error {
+	if len(labels) != lines {
+		return fmt.Errorf("%w: %d labels for %d conveyors", ErrInvalidConfig, len(labels), lines)
+	}
+	return nil
+}
+
+// Label returns the label of a conveyor, or its index when it has none. It panics if line is
+// out of range.
+func (bus *MainBus[T]) Label(line int) string {
+	return bus.belt(line).labelOr(line)
+}
+
+// labelOr returns the conveyor label, or line as a string when it has none
+func (b *belt[T]) labelOr(line int) string {
+	if b.label != "" {
+		return b.label
+	}
+	return strconv.Itoa(line)
+}
+
+// WithConsumerName names a consumer so its log lines and the bus Health can tell it apart from
+// others on the same conveyor
+func WithConsumerName(name string) ConsumeOption {
+	return func(c *consumeConfig) {
+		c.name = name
+	}
+}
+
+// attachAs is attach for a consumer registering its name, unless it is empty
+func (bus *MainBus[T]) attachAs(line int, name string) *consumer {
+	b := bus.belt(line)
+	b.consumers.Add(1)
+	if name != "" {
+		b.namesMu.Lock()
+		b.names = append(b.names, name)
+		b.namesMu.Unlock()
+	}
+	c := &consumer{name: name, line: line, started: bus.now()}
+	bus.consumerSet.add(c)
+	c.detach = func() {
+		bus.consumerSet.remove(c)
+		if name != "" {
+			b.namesMu.Lock()
+			if i := slices.Index(b.names, name); i >= 0 {
+				b.names = slices.Delete(b.names, i, i+1)
+			}
+			b.namesMu.Unlock()
+		}
+		b.consumers.Add(-1)
+		b.finish()
+	}
+	bus.startAutoscale()
+	return c
+}
+
+// consumerNames returns the names of the consumers attached to the conveyor, sorted
+func (b *belt[T]) consumerNames() []string {
+	b.namesMu.Lock()
+	defer b.namesMu.Unlock()
+	if len(b.names) == 0 {
+		return nil
+	}
+	names := slices.Clone(b.names)
+	slices.Sort(names)
+	return names
+}
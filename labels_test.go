@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConveyorLabels(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithConveyorLabels([]string{"ore", ""}))
+	defer bus.Close()
+	if got := bus.Label(0); got != "ore" {
+		t.Errorf("Label(0) = %q, want ore", got)
+	}
+	if got := bus.Label(1); got != "1" {
+		t.Errorf("Label(1) = %q, want the index", got)
+	}
+	if got := bus.Metrics().Labels; !slices.Equal(got, []string{"ore", "1"}) {
+		t.Errorf("Metrics().Labels = %v", got)
+	}
+	if h := bus.Health(); h.Conveyors[0].Label != "ore" || h.Conveyors[1].Label != "" {
+		t.Errorf("Health labels = %q, %q", h.Conveyors[0].Label, h.Conveyors[1].Label)
+	}
+}
+
+func TestConveyorLabelsCountMismatch(t *testing.T) {
+	_, err := NewMainBusChecked[int](Iron, WithLines(4), WithConveyorLabels([]string{"a", "b"}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("err = %v, want ErrInvalidConfig", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewMainBus did not panic on a label count mismatch")
+		}
+	}()
+	NewMainBus[int](Iron, WithLines(4), WithConveyorLabels([]string{"a"}))
+}
+
+func TestNamedConsumerLogsAndHealth(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	logger := slog.New(slog.NewTextHandler(&lockedWriter{w: &buf, mu: &mu}, nil))
+	bus := NewMainBus[int](Iron, WithLines(2), WithLogger(logger), WithConveyorLabels([]string{"ore", "scrap"}))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.Consume(0, &wg, WithConsumerName("smelter"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !slices.Equal(bus.Health().Conveyors[0].ConsumerNames, []string{"smelter"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("ConsumerNames = %v", bus.Health().Conveyors[0].ConsumerNames)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := range 8 {
+		if err := bus.Produce(Event[int]{Value: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for bus.Depth(0) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	bus.Close()
+	wg.Wait()
+
+	mu.Lock()
+	out := buf.String()
+	mu.Unlock()
+	if !strings.Contains(out, "label=ore") || !strings.Contains(out, "consumer=smelter") {
+		t.Errorf("log line lacks label or consumer name:\n%s", out)
+	}
+	if names := bus.Health().Conveyors[0].ConsumerNames; names != nil {
+		t.Errorf("ConsumerNames after exit = %v", names)
+	}
+}
+
+type lockedWriter struct {
+	w  *bytes.Buffer
+	mu *sync.Mutex
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,41 @@
+package main
+
+// Lag returns how far the consumers of a conveyor are behind its producers: the highest event ID
+// produced on it minus its Checkpoint, the highest ID consumed, which counts events in flight in
+// handlers and IDs skipped by the bus as well as those still buffered. It is the classic signal
+// for consumer alerting: a lag that keeps growing means consumers are not keeping up. IDs must be
+// positive and increase in the order events are produced, as those from NextID do; once an event
+// without a positive ID lands on the conveyor, Lag falls back to its buffer depth. Before the
+// first consume it counts from just below the first ID produced. Lag panics if line is out of
+// range.
+func (bus *MainBus[T]) Lag(line int) int64 {
+	return bus.belt(line).lag()
+}
+
+// lag computes Lag for one conveyor
+func (b *belt[T]) lag() int64 {
+	if b.unordered.Load() {
+		return int64(b.depth())
+	}
+	head := b.head.Load()
+	if head == 0 {
+		return 0
+	}
+	done := max(b.offset.Load(), b.first.Load()-1)
+	return max(head-done, 0)
+}
+
+// noteProduced records the ID of an event landing on the conveyor for lag
+func (b *belt[T]) noteProduced(id int) {
+	if id <= 0 {
+		b.unordered.Store(true)
+		return
+	}
+	b.first.CompareAndSwap(0, int64(id))
+	for {
+		cur := b.head.Load()
+		if int64(id) <= cur || b.head.CompareAndSwap(cur, int64(id)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLagGrowsThenShrinks(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(16), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	for range 4 {
+		bus.ProduceNew("iron", 0) // IDs 1 and 3 on conveyor 0
+	}
+	if lag := bus.Lag(0); lag != 3 {
+		t.Fatalf("Lag after producing IDs 1 and 3 = %d, want 3", lag)
+	}
+	for range 4 {
+		bus.ProduceNew("iron", 0) // IDs 5 and 7
+	}
+	if lag := bus.Lag(0); lag != 7 {
+		t.Fatalf("Lag after producing ahead = %d, want 7", lag)
+	}
+	if _, err := CollectN(bus, 0, 3, time.Second); err != nil { // up to ID 5
+		t.Fatal(err)
+	}
+	if lag := bus.Lag(0); lag != 2 {
+		t.Fatalf("Lag after consuming up to ID 5 = %d, want 2", lag)
+	}
+	if _, err := CollectN(bus, 0, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if lag, m := bus.Lag(0), bus.Metrics(); lag != 0 || m.Lag[0] != 0 || m.Lag[1] != 7 {
+		t.Fatalf("Lag = %d, Metrics().Lag = %v; want 0 and [0 7]", lag, m.Lag)
+	}
+}
+
+func TestLagFallsBackToDepth(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(16), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	for range 6 {
+		bus.Produce(Event[int]{}) // no IDs
+	}
+	if lag := bus.Lag(0); lag != 3 {
+		t.Fatalf("Lag = %d, want the depth 3", lag)
+	}
+	CollectN(bus, 0, 2, time.Second)
+	if lag := bus.Lag(0); lag != 1 {
+		t.Fatalf("Lag = %d, want the depth 1", lag)
+	}
+}
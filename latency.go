@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// latencySubBuckets splits every power-of-two range of the histogram, bounding the error of a
+// reported percentile to 1/latencySubBuckets (12.5%)
+const (
+	latencySubBits    = 3
+	latencySubBuckets = 1 << latencySubBits
+	latencyBuckets    = (64 - latencySubBits + 1) * latencySubBuckets
+)
+
+// latencyHist is a fixed-size, lock-free log-linear histogram of nanosecond durations
+type latencyHist struct {
+	counts   [latencyBuckets]atomic.Uint64
+	count    atomic.Uint64
+	min, max atomic.Int64 // valid once count > 0
+}
+
+// latencyBucket maps a duration in nanoseconds to its bucket
+func latencyBucket(ns uint64) int {
+	if ns < latencySubBuckets {
+		return int(ns)
+	}
+	e := bits.Len64(ns) - 1
+	sub := (ns >> (e - latencySubBits)) & (latencySubBuckets - 1)
+	return (e-latencySubBits+1)*latencySubBuckets + int(sub)
+}
+
+// latencyBounds returns the smallest and largest duration in nanoseconds that fall in bucket i
+func latencyBounds(i int) (lo, hi uint64) {
+	if i < latencySubBuckets {
+		return uint64(i), uint64(i)
+	}
+	e := i/latencySubBuckets + latencySubBits - 1
+	width := uint64(1) << (e - latencySubBits)
+	lo = (latencySubBuckets + uint64(i%latencySubBuckets)) * width
+	return lo, lo + width - 1
+}
+
+// observe records one duration; negative durations count as zero
+func (h *latencyHist) observe(d time.Duration) {
+	ns := max(int64(d), 0)
+	h.counts[latencyBucket(uint64(ns))].Add(1)
+	if h.count.Add(1) == 1 {
+		h.min.Store(ns)
+		h.max.Store(ns)
+	}
+	for cur := h.min.Load(); ns < cur && !h.min.CompareAndSwap(cur, ns); cur = h.min.Load() {
+	}
+	for cur := h.max.Load(); ns > cur && !h.max.CompareAndSwap(cur, ns); cur = h.max.Load() {
+	}
+}
+
+// LatencySnapshot summarises how long events waited on a conveyor, from Event.Time to the moment
+// a consumer took them off. Percentiles are accurate to within 12.5%.
+type LatencySnapshot struct {
+	Count         uint64
+	Min, Max      time.Duration
+	P50, P95, P99 time.Duration
+}
+
+// snapshot computes the summary. Observations racing with it may be partly included.
+func (h *latencyHist) snapshot() LatencySnapshot {
+	var counts [latencyBuckets]uint64
+	var total uint64
+	for i := range counts {
+		counts[i] = h.counts[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return LatencySnapshot{}
+	}
+	s := LatencySnapshot{Count: total, Min: time.Duration(h.min.Load()), Max: time.Duration(h.max.Load())}
+	quantile := func(q float64) time.Duration {
+		rank := uint64(math.Ceil(q * float64(total)))
+		var seen uint64
+		for i, n := range counts {
+			if seen += n; seen >= rank {
+				lo, hi := latencyBounds(i)
+				mid := time.Duration(lo + (hi-lo)/2)
+				return min(max(mid, s.Min), s.Max)
+			}
+		}
+		return s.Max
+	}
+	s.P50, s.P95, s.P99 = quantile(0.50), quantile(0.95), quantile(0.99)
+	return s
+}
+
+// LatencyStats reports the time events spent buffered on a conveyor before a consumer took them,
+// measured from Event.Time. Events without a Time are not counted.
+func (bus *MainBus[T]) LatencyStats(line int) LatencySnapshot {
+	return bus.belt(line).latency.snapshot()
+}
+
+// observeLatency records how long ev waited on line
+func (bus *MainBus[T]) observeLatency(line int, ev Event[T]) {
+	if !ev.Time.IsZero() {
+		bus.belt(line).latency.observe(bus.since(ev.Time))
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLatencyBucketsCoverBounds(t *testing.T) {
+	for _, ns := range []uint64{0, 1, 7, 8, 9, 15, 16, 1000, 123456789, 1 << 40, 1<<63 - 1} {
+		lo, hi := latencyBounds(latencyBucket(ns))
+		if ns < lo || ns > hi {
+			t.Fatalf("%d mapped to bucket [%d, %d]", ns, lo, hi)
+		}
+	}
+}
+
+func TestLatencyStatsPercentiles(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(128))
+	bus.RemoveConveyor(1)
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		// event i has already waited i milliseconds
+		bus.Produce(Event[int]{ID: i, Time: now.Add(-time.Duration(i) * time.Millisecond)})
+	}
+	bus.Produce(Event[int]{ID: 0}) // no timestamp, not counted
+	bus.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeWith(0, &wg, func(Event[int]) {})
+
+	s := bus.LatencyStats(0)
+	if s.Count != 100 {
+		t.Fatalf("Count = %d, want 100", s.Count)
+	}
+	// allow the histogram's 12.5% error plus the time taken to produce and consume
+	near := func(name string, got, want time.Duration) {
+		if got < want*85/100 || got > want*115/100+5*time.Millisecond {
+			t.Errorf("%s = %v, want about %v", name, got, want)
+		}
+	}
+	near("Min", s.Min, time.Millisecond)
+	near("P50", s.P50, 50*time.Millisecond)
+	near("P95", s.P95, 95*time.Millisecond)
+	near("P99", s.P99, 99*time.Millisecond)
+	near("Max", s.Max, 100*time.Millisecond)
+	if s.Min > s.P50 || s.P50 > s.P95 || s.P95 > s.P99 || s.P99 > s.Max {
+		t.Errorf("summary out of order: %+v", s)
+	}
+	if empty := NewMainBus[int]("copper").LatencyStats(0); empty != (LatencySnapshot{}) {
+		t.Errorf("idle conveyor reported %+v", empty)
+	}
+}
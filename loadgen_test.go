@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadGenHitsTargetRate(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(64), WithStrategy(StrategyRoundRobin))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var ids []int
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(ev Event[int]) {
+			mu.Lock()
+			ids = append(ids, ev.ID)
+			mu.Unlock()
+		})
+	}
+	const rate, duration = 1000, 300 * time.Millisecond
+	r := LoadGen(bus, rate, duration, func(id int) int { return id * 10 })
+	bus.Close()
+	wg.Wait()
+
+	if r.Target != rate || r.Dropped != 0 || r.Failed != 0 {
+		t.Fatalf("report %+v, want target %d with nothing dropped or failed", r, rate)
+	}
+	if math.Abs(r.Rate-rate)/rate > 0.2 {
+		t.Fatalf("achieved %.0f events/s, want within 20%% of %d", r.Rate, rate)
+	}
+	if want := int(rate * duration.Seconds()); math.Abs(float64(r.Produced-want)) > 0.2*float64(want) {
+		t.Fatalf("produced %d events, want about %d", r.Produced, want)
+	}
+	if len(r.PerConveyor) != 2 || r.PerConveyor[0]+r.PerConveyor[1] != r.Produced || r.PerConveyor[0]-r.PerConveyor[1] > 1 {
+		t.Fatalf("per conveyor %v for %d produced, want an even round-robin split", r.PerConveyor, r.Produced)
+	}
+	if len(ids) != r.Produced {
+		t.Fatalf("consumed %d events, report counts %d", len(ids), r.Produced)
+	}
+}
+
+func TestLoadGenCountsDrops(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(4), WithOverflowPolicy(OverflowDropNewest))
+	defer bus.Close()
+	// nothing consumes, so all but the buffered events are dropped
+	r := LoadGen(bus, 1000, 50*time.Millisecond, func(id int) int { return id })
+	if r.Produced != bus.Capacity(0)+bus.Capacity(1) || r.Dropped == 0 {
+		t.Fatalf("report %+v, want the %d buffered slots produced and the rest dropped", r, bus.Capacity(0)+bus.Capacity(1))
+	}
+}
+
+func TestLoadGenFollowsBusClock(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(128), WithClock(clock), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	done := make(chan LoadReport)
+	go func() { done <- LoadGen(bus, 100, time.Second, func(id int) int { return id }) }()
+	// 100 events/s is one every 10ms on the fake clock: the first goes out at once and each step
+	// releases ten more
+	for step := 0; step < 10; step++ {
+		if step > 0 {
+			clock.Advance(100 * time.Millisecond)
+		}
+		deadline := time.Now().Add(time.Second)
+		for bus.TotalDepth() < 10*step+1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(3 * clockPoll)
+		if n := bus.TotalDepth(); n != 10*step+1 {
+			t.Fatalf("%d events produced after %v on the bus clock, want %d", n, time.Duration(step)*100*time.Millisecond, 10*step+1)
+		}
+	}
+	clock.Advance(99 * time.Millisecond)
+	for deadline := time.Now().Add(time.Second); bus.TotalDepth() < 100 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Millisecond)
+	var r LoadReport
+	select {
+	case r = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("LoadGen still running after its duration passed on the bus clock")
+	}
+	if r.Duration != time.Second {
+		t.Fatalf("duration %v, want the second that passed on the bus clock", r.Duration)
+	}
+	// the event due exactly at the deadline may or may not make it
+	if r.Produced < 100 || r.Produced > 101 || r.Rate != float64(r.Produced) {
+		t.Fatalf("produced %d at %.1f events/s, want 100 at 100 events/s", r.Produced, r.Rate)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger is the default bus logger, keeping the library quiet unless WithLogger is given
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// WithLogger routes the bus logs (consumed events from Consume, recovered panics, drops,
+// rejections and persistence errors) to l. A nil logger keeps the default, which discards
+// everything.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *busConfig) {
+		c.logger = l
+	}
+}
+
+// logEvent logs msg about ev with the bus resource and, if line is not negative, the conveyor
+func (bus *MainBus[T]) logEvent(level slog.Level, msg string, line int, ev Event[T], attrs ...slog.Attr) {
+	if !bus.logger.Enabled(context.Background(), level) {
+		return
+	}
+	base := []slog.Attr{slog.String("resource", bus.Resource)}
+	if line >= 0 {
+		base = append(base, slog.Int("line", line))
+		if l := bus.belt(line).label; l != "" {
+			base = append(base, slog.String("label", l))
+		}
+	}
+	base = append(base, slog.Int("id", ev.ID), slog.Any("value", ev.Value))
+	bus.logger.LogAttrs(context.Background(), level, msg, append(base, attrs...)...)
+}
@@ -1,71 +1,859 @@
 package main
 
 import (
+	"context"
+	"crypto/cipher"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
+	"log/slog"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Event represents an item transported on the conveyors (e.g., iron plate)
-type Event struct {
+// ErrBusClosed is returned when producing to, or closing, a bus that has already been closed
+var ErrBusClosed = errors.New("main bus is closed")
+
+// ErrInvalidConfig is wrapped by the errors NewMainBusChecked returns for unusable settings
+var ErrInvalidConfig = errors.New("invalid main bus configuration")
+
+// Event represents an item transported on the conveyors (e.g., iron plate), carrying a payload of type T
+type Event[T any] struct {
 	ID       int
 	Resource string
-	Value    any
+	Value    T
 	Time     time.Time
+	Priority int               // higher values are served first by a PriorityBus
+	Carrier  map[string]string // trace context propagated by WithTracing; nil otherwise
+
+	// CorrelationID ties together the events of one workflow, such as the parts of a saga, for
+	// ConsumeCorrelated; "" if the event belongs to none
+	CorrelationID string
+
+	// OnDelivered and OnDropped, if set, report the fate of a produced event: exactly one of them
+	// runs, once, when the event is consumed or when it is dropped, expired or dead-lettered, with
+	// the reason. They run on the goroutine settling the event, usually its consumer's, so they
+	// must be fast. See Produce for the details. They are not encoded by any codec.
+	OnDelivered func()
+	OnDropped   func(reason string)
+
+	poison  bool     // a pill sent by Poison, stopping the consumer that takes it
+	barrier *barrier // queued by Flush, reached once the consumer that takes it is done
 }
 
 // Conveyor represents a single belt (a channel)
-type Conveyor chan Event
+type Conveyor[T any] chan Event[T]
 
 // MainBus represents a resource-specific main bus with multiple parallel conveyors
-type MainBus struct {
-	Resource  string
-	Conveyors []Conveyor
+type MainBus[T any] struct {
+	Resource string
+	Strategy SelectStrategy
+
+	// Conveyors lists every conveyor by line. It is replaced, never modified in place, when
+	// conveyors are added or removed; removed lines keep their (closed) conveyor.
+	Conveyors []Conveyor[T]
+
+	// OnPanic, if set, is called with the offending event whenever a consumer handler panics.
+	// The panic is always recovered and logged, and the consumer moves on to the next event.
+	OnPanic func(ev Event[T], r any)
+
+	// OnDeadLetterDropped, if set, is called with every rejected event lost because the
+	// dead-letter conveyor was full; see WithDeadLetterOverflow
+	OnDeadLetterDropped func(ev Event[T])
+
+	logger *slog.Logger // never nil; discards unless built WithLogger
+	tracer trace.Tracer // nil unless built WithTracing
+
+	next        atomic.Uint64                // round-robin cursor
+	weights     atomic.Pointer[[]int]        // StrategyWeighted weights by line; nil weighs every line 1
+	keyFunc     KeyFunc[T]                   // StrategyHashKey routing key; nil unless built WithKeyFunc
+	urgent      int                          // lowest Priority StrategyPriorityAware treats as high priority
+	vnodes      int                          // StrategyConsistentHash ring points per conveyor; see WithVirtualNodes
+	enricher    Enricher[T]                  // applied before consumer handlers; nil unless built WithEnricher
+	router      RoutingStrategy[T]           // StrategyCustom routing; nil unless built WithRoutingStrategy
+	buffers     BufferFactory[T]             // builds each conveyor's Buffer; nil unless built WithBufferFactory
+	rand        *lockedRand                  // seeded by WithRandSource
+	codec       Codec[T]                     // wire format for persistence and transports; never nil
+	validators  []Validator[T]               // run on every produced event; see WithValidators
+	compression Compression                  // applied to encoded events on disk and on the wire
+	aead        cipher.AEAD                  // encrypts the persistence log; nil without WithEncryption
+	ids         atomic.Int64                 // last ID handed out by NextID
+	lines       atomic.Pointer[lineTable[T]] // current conveyor layout
+
+	deadLetters        chan DeadLetter[T] // nil unless built WithDeadLetter
+	rejected           atomic.Uint64      // events parked on deadLetters
+	deadLetterDropped  atomic.Uint64      // dead letters lost to WithDeadLetterOverflow
+	deadLetterOverflow OverflowPolicy
+	limiter            tokenBucket // unlimited unless built WithRateLimit or SetRate is called
+	overflow           OverflowPolicy
+	degraded           atomic.Bool   // OverflowDropOldest applies instead of overflow; see WithAdaptiveOverflow
+	degradations       atomic.Uint64 // times degraded was set
+	ttl                time.Duration // events older than this are expired on consume; 0 disables
+	dedup              DedupStore    // shared by ConsumeDedup consumers; nil unless built WithDedupStore
+	typeStats          bool          // count consumed events by Value type; see WithTypeStats
+	clock              Clock         // the current time; the wall clock unless built WithClock
+	maxInFlight        int           // cap on events buffered across the bus; 0 for none
+	inflight           atomic.Int64  // events buffered or being sent, counted against maxInFlight
+	room               chan struct{} // wakes a producer blocked at maxInFlight; see vacate
+	stallThreshold     time.Duration // how long a full conveyor may go untouched before Health calls it stalled
+	journal            *eventLog     // nil unless built WithPersistence
+	checkpoints        string        // file checkpoints are saved to; "" unless built WithPersistence
+
+	mwMu       sync.RWMutex // guards middleware and changes to mirrors
+	middleware []Middleware[T]
+
+	mirrors       atomic.Pointer[[]*MainBus[T]] // replicas attached with Mirror
+	mirrorDropped atomic.Uint64                 // events a replica could not take
+	stuckHandlers atomic.Int64                  // timed-out handlers still running
+
+	rates      atomic.Pointer[rateSampler] // counter samples for RateStats; nil until SampleRates
+	autoscaler *autoscaler                 // nil unless built WithAutoscale
+
+	highWater, lowWater float64 // backpressure watermarks; disabled when highWater is 0
+	pressure, relieved  chan struct{}
+
+	consumerSet consumerSet   // consumers attached, for ListConsumers
+	sessions    atomic.Uint64 // sessions handed out by Session, spreading them over the conveyors
+	admin       chan AdminCommand
+	adminOnce   sync.Once     // starts the control goroutine serving admin
+	delays      delayQueue[T] // events held by ProduceAt
+
+	snapMu sync.Mutex  // serializes Snapshot, Rebalance and Inspect calls
+	hold   produceHold // pauses producers during Snapshot
+
+	mu        sync.RWMutex  // guards the layout, closed and sends on the dead-letter conveyor
+	closed    bool          // conveyors have been closed; guarded by mu
+	done      chan struct{} // closed once the bus stops accepting events, releasing blocked producers
+	closing   chan struct{} // closed just before the conveyors are, releasing blocked Reject calls
+	stopOnce  sync.Once
+	closeOnce sync.Once
+	shutdown  atomic.Bool // set by the first Shutdown call
 }
 
-// NewMainBus creates a new main bus for a given resource with N parallel conveyors and a buffer size
-func NewMainBus(resource string, lines int, buffer int) *MainBus {
-	if lines%2 != 0 {
-		lines++ // ensure even number of conveyors, following Factorio convention
+// Defaults used by NewMainBus when WithLines or WithBuffer are not given
+const (
+	DefaultLines  = 2
+	DefaultBuffer = 16
+)
+
+// NewMainBus creates a new main bus for a given resource. By default it has the conveyor count
+// and buffer size the resource was registered with, or DefaultLines conveyors of DefaultBuffer
+// events each for a resource never registered, and routes events at random; opts change the
+// layout and enable optional features. Fewer than one line is raised to DefaultLines, and an odd
+// line count is rounded up to the next even number. A negative buffer, invalid weights, a key
+// func, codec or validator for another event type, an encryption key of the wrong size, or bad
+// autoscale settings panic,
+// and a persistence log that cannot be opened leaves
+// the bus running without persistence, with a warning sent to the bus logger or, when none was
+// given, the standard log package. Use NewMainBusChecked to get errors for these instead.
+func NewMainBus[T any](resource Resource, opts ...Option) *MainBus[T] {
+	cfg := newBusConfig(resource, opts)
+	if cfg.buffer < 0 {
+		panic(fmt.Sprintf("main bus %q: negative buffer %d", resource, cfg.buffer))
 	}
-	bus := &MainBus{Resource: resource}
-	for i := 0; i < lines; i++ {
-		bus.Conveyors = append(bus.Conveyors, make(Conveyor, buffer))
+	if cfg.weights != nil {
+		if err := validateWeights(cfg.weights, busLines(cfg.lines)); err != nil {
+			panic(fmt.Sprintf("main bus %q: %v", resource, err))
+		}
 	}
+	if _, err := keyFuncFor[T](cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if _, err := enricherFor[T](cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if _, err := routerFor[T](cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if _, err := bufferFactoryFor[T](cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if _, err := codecFor[T](cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if _, err := validatorsFor[T](cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if _, err := aeadFor(cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if err := validateAutoscale(cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if err := validateSaturation(cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if err := validateAdaptive(cfg); err != nil {
+		panic(fmt.Sprintf("main bus %q: %v", resource, err))
+	}
+	if cfg.labels != nil {
+		if err := validateLabels(cfg.labels, busLines(cfg.lines)); err != nil {
+			panic(fmt.Sprintf("main bus %q: %v", resource, err))
+		}
+	}
+	bus, err := newMainBus[T](string(resource), cfg)
+	if err != nil {
+		// an explicitly requested feature failing must not be silent under the default logger
+		if cfg.logger == nil {
+			log.Printf("[MainBus-%s] persistence disabled: %v", resource, err)
+		} else {
+			bus.logger.Warn("persistence disabled", "resource", resource, "error", err)
+		}
+	}
+	bus.startSaturationAlert(cfg)
+	bus.startAdaptiveOverflow(cfg)
 	return bus
 }
 
-// Produce sends an event to a random conveyor on the main bus
-func (bus *MainBus) Produce(ev Event) {
-	if len(bus.Conveyors) == 0 {
-		return
+// NewMainBusChecked is like NewMainBus but reports misconfiguration instead of building a broken
+// bus: a negative conveyor or dead-letter buffer, weights that do not fit the conveyors, or a
+// key func, codec or validator for another event type return an error wrapping
+// ErrInvalidConfig, and a persistence log that cannot be opened returns that error. It logs a
+// note when the line count is clamped or rounded up to an even number.
+func NewMainBusChecked[T any](resource Resource, opts ...Option) (*MainBus[T], error) {
+	cfg := newBusConfig(resource, opts)
+	if cfg.buffer < 0 {
+		return nil, fmt.Errorf("main bus %q: %w: buffer %d is negative", resource, ErrInvalidConfig, cfg.buffer)
+	}
+	if cfg.deadLetter && cfg.deadLetterBuffer < 0 {
+		return nil, fmt.Errorf("main bus %q: %w: dead-letter buffer %d is negative", resource, ErrInvalidConfig, cfg.deadLetterBuffer)
+	}
+	if cfg.weights != nil {
+		if err := validateWeights(cfg.weights, busLines(cfg.lines)); err != nil {
+			return nil, fmt.Errorf("main bus %q: %w", resource, err)
+		}
+	}
+	if _, err := keyFuncFor[T](cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if _, err := enricherFor[T](cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if _, err := routerFor[T](cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if _, err := bufferFactoryFor[T](cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if _, err := codecFor[T](cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if _, err := validatorsFor[T](cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
 	}
-	idx := rand.Intn(len(bus.Conveyors))
-	bus.Conveyors[idx] <- ev
+	if _, err := aeadFor(cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if err := validateAutoscale(cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if err := validateSaturation(cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if err := validateAdaptive(cfg); err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if cfg.labels != nil {
+		if err := validateLabels(cfg.labels, busLines(cfg.lines)); err != nil {
+			return nil, fmt.Errorf("main bus %q: %w", resource, err)
+		}
+	}
+	bus, err := newMainBus[T](string(resource), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("main bus %q: %w", resource, err)
+	}
+	if n := busLines(cfg.lines); n != cfg.lines {
+		bus.logger.Info("adjusted conveyor count", "resource", resource, "requested", cfg.lines, "lines", n)
+	}
+	bus.startSaturationAlert(cfg)
+	bus.startAdaptiveOverflow(cfg)
+	return bus, nil
+}
+
+// newBusConfig applies opts over the defaults of resource
+func newBusConfig(resource Resource, opts []Option) busConfig {
+	d := defaultsFor(resource)
+	cfg := busConfig{lines: d.lines, buffer: d.buffer, stallThreshold: DefaultStallThreshold, urgent: DefaultPriorityThreshold}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// busLines returns the number of conveyors actually built for a requested line count
+func busLines(n int) int {
+	if n < 1 {
+		return DefaultLines
+	}
+	if n%2 != 0 {
+		n++ // ensure even number of conveyors, following Factorio convention
+	}
+	return n
+}
+
+// newMainBus builds a bus from validated settings. If the persistence log cannot be opened it
+// returns the bus without persistence alongside the error.
+func newMainBus[T any](resource string, cfg busConfig) (*MainBus[T], error) {
+	lines := busLines(cfg.lines)
+	bus := &MainBus[T]{Resource: resource, Strategy: cfg.strategy, logger: cfg.logger, tracer: cfg.tracer, done: make(chan struct{}), closing: make(chan struct{})}
+	if bus.logger == nil {
+		bus.logger = discardLogger
+	}
+	bus.buffers, _ = bufferFactoryFor[T](cfg)
+	belts := make([]*belt[T], lines)
+	for i := range belts {
+		belts[i] = newBelt[T](cfg.buffer, bus.buffers, bus.vacate)
+		if i < len(cfg.labels) {
+			belts[i].label = cfg.labels[i]
+		}
+	}
+	bus.publish(belts)
+	if cfg.weights != nil {
+		w := append([]int(nil), cfg.weights...)
+		bus.weights.Store(&w)
+	}
+	bus.keyFunc, _ = keyFuncFor[T](cfg)
+	bus.enricher, _ = enricherFor[T](cfg)
+	bus.router, _ = routerFor[T](cfg)
+	bus.rand = newLockedRand(cfg)
+	bus.codec, _ = codecFor[T](cfg)
+	bus.validators, _ = validatorsFor[T](cfg)
+	bus.compression = cfg.compression
+	bus.aead, _ = aeadFor(cfg)
+	bus.autoscaler = newAutoscaler(cfg)
+	bus.clock = clockFor(cfg)
+	bus.maxInFlight = cfg.maxInFlight
+	bus.room = make(chan struct{}, 1)
+	bus.limiter.clock = bus.clock
+	bus.limiter.setRate(cfg.rateLimit)
+	bus.overflow = cfg.overflow
+	bus.ttl = cfg.ttl
+	bus.urgent = cfg.urgent
+	bus.vnodes = cfg.vnodes
+	bus.dedup = cfg.dedupStore
+	bus.typeStats = cfg.typeStats
+	bus.stallThreshold = cfg.stallThreshold
+	bus.highWater, bus.lowWater = cfg.highWater, cfg.lowWater
+	bus.pressure, bus.relieved = make(chan struct{}, 1), make(chan struct{}, 1)
+	if cfg.deadLetter {
+		bus.deadLetters = make(chan DeadLetter[T], max(cfg.deadLetterBuffer, 0))
+		bus.deadLetterOverflow = cfg.deadLetterOverflow
+	}
+	if cfg.persistPath != "" {
+		l, err := openEventLog(cfg.persistPath, cfg.fsyncInterval, logHeader(bus.codec, bus.compression, bus.aead != nil))
+		if err != nil {
+			return bus, err
+		}
+		bus.journal = l
+		bus.checkpoints = cfg.persistPath + checkpointSuffix
+		if err := bus.loadCheckpoints(); err != nil {
+			return bus, err
+		}
+	}
+	return bus, nil
 }
 
-// Consume starts consuming a specific conveyor until it is closed
-func (bus *MainBus) Consume(line int, wg *sync.WaitGroup) {
+// CloneConfig builds a new, empty bus for resource with the receiver's current conveyor count,
+// buffer size (that of its first live conveyor), buffer factory, strategy, including weights, key
+// func, priority threshold and custom routing strategy, overflow policy and rate limit. The clone
+// gets fresh conveyors and counters: buffered events, consumers, middleware and every other
+// option, persistence included, are not cloned. An odd count left by RemoveConveyor is rounded up as for any new bus.
+func (bus *MainBus[T]) CloneConfig(resource Resource) *MainBus[T] {
+	t := bus.table()
+	opts := []Option{
+		WithLines(len(t.live)),
+		WithBuffer(cap(t.belts[t.live[0]].c())),
+		WithStrategy(bus.Strategy),
+		WithOverflowPolicy(bus.overflow),
+		WithRateLimit(bus.limiter.eventsPerSec()),
+	}
+	if w := bus.weights.Load(); w != nil {
+		// a conveyor added by rounding up weighs 1, like lines beyond the end of the weights
+		live := make([]int, busLines(len(t.live)))
+		for i := range live {
+			live[i] = 1
+			if i < len(t.live) && t.live[i] < len(*w) {
+				live[i] = (*w)[t.live[i]]
+			}
+		}
+		opts = append(opts, WithWeights(live), WithStrategy(bus.Strategy))
+	}
+	if bus.keyFunc != nil {
+		opts = append(opts, WithKeyFunc(bus.keyFunc), WithStrategy(bus.Strategy))
+	}
+	if bus.Strategy == StrategyPriorityAware {
+		opts = append(opts, WithPriorityThreshold(bus.urgent))
+	}
+	if bus.Strategy == StrategyConsistentHash {
+		opts = append(opts, WithVirtualNodes(bus.vnodes))
+	}
+	if bus.router != nil && bus.Strategy == StrategyCustom {
+		opts = append(opts, WithRoutingStrategy(bus.router))
+	}
+	if bus.buffers != nil {
+		opts = append(opts, WithBufferFactory(bus.buffers))
+	}
+	return NewMainBus[T](resource, opts...)
+}
+
+// NewMainBusPositional creates a bus from the original positional parameters.
+//
+// Deprecated: use NewMainBus with WithLines, WithBuffer and WithStrategy.
+func NewMainBusPositional[T any](resource string, lines int, buffer int, strategy SelectStrategy, opts ...Option) *MainBus[T] {
+	return NewMainBus[T](Resource(resource), append([]Option{WithLines(lines), WithBuffer(buffer), WithStrategy(strategy)}, opts...)...)
+}
+
+// Produce sends an event to a conveyor chosen by the bus strategy, blocking until it is accepted.
+// It returns ErrBusClosed if the bus is closed before the event could be enqueued.
+//
+// An accepted event with an OnDelivered or OnDropped callback gets exactly one of them called:
+// OnDelivered once a consumer's handler returns (for ConsumeAck, once it acknowledges), or
+// OnDropped with the reason when the event is dropped by the overflow policy, a conveyor removal
+// or a forced Shutdown, expires, is skipped by SeekTo, is dead-lettered, or makes its handler
+// panic. Callbacks run on the goroutine settling the event, normally its consumer's, so they must
+// be fast. An event refused with an error other than ErrEventDropped runs neither, and events
+// read straight off Conveyors run neither until something settles them. Mirror copies carry no
+// callbacks, and the copies of a Broadcast share one fate, settled by the first consumer.
+func (bus *MainBus[T]) Produce(ev Event[T]) error {
+	return bus.ProduceContext(context.Background(), ev)
+}
+
+// ProduceContext sends an event to a conveyor chosen by the bus strategy, giving up when ctx is cancelled or
+// its deadline passes. It returns ctx.Err() if the conveyor did not accept the event in time,
+// ErrBusClosed if the bus is (or becomes) closed, and ErrEventDropped if OverflowDropNewest
+// discarded it. When the bus is rate limited it first waits
+// for a token. A full conveyor is handled according to the bus OverflowPolicy. Middleware
+// registered with Use runs first.
+func (bus *MainBus[T]) ProduceContext(ctx context.Context, ev Event[T]) error {
+	return bus.chain(func(ev Event[T]) error {
+		return bus.produce(ctx, ev)
+	})(ev)
+}
+
+// produce is the end of the middleware chain for Produce and ProduceContext
+func (bus *MainBus[T]) produce(ctx context.Context, ev Event[T]) error {
+	_, err := bus.produceVia(ctx, ev, bus.route)
+	return err
+}
+
+// ProduceReturn produces an event like Produce and also returns the conveyor the bus strategy
+// placed it on, for checking routing and correlating with per-conveyor metrics. The line is -1
+// when the event was not placed, whether middleware, validation, the overflow policy or a
+// closed bus stopped it.
+func (bus *MainBus[T]) ProduceReturn(ev Event[T]) (line int, err error) {
+	line = -1
+	err = bus.chain(func(ev Event[T]) error {
+		var err error
+		if line, err = bus.produceVia(context.Background(), ev, bus.route); err != nil {
+			line = -1
+		}
+		return err
+	})(ev)
+	return line, err
+}
+
+// produceVia is produce with place choosing the conveyor and sending the event to it. It returns
+// the conveyor place chose.
+func (bus *MainBus[T]) produceVia(ctx context.Context, ev Event[T], place func(context.Context, Event[T], bool) (int, error)) (int, error) {
+	if bus.isClosed() {
+		return -1, ErrBusClosed
+	}
+	if err := bus.validate(ev); err != nil {
+		return -1, err
+	}
+	if err := bus.limiter.wait(ctx); err != nil {
+		return -1, err
+	}
+	if err := bus.admit(ctx); err != nil {
+		bus.limiter.cancel()
+		return -1, err
+	}
+	ev, span := bus.startProduceSpan(ctx, withFate(ev))
+	line, err := place(ctx, ev, true)
+	bus.endProduceSpan(span, line, err)
+	if err != nil {
+		bus.vacate()
+		return line, err
+	}
+	bus.mirror(ev)
+	return line, nil
+}
+
+// NextID returns a new event ID, unique and monotonically increasing within this bus
+func (bus *MainBus[T]) NextID() int {
+	return int(bus.ids.Add(1))
+}
+
+// ProduceNew builds an event with the next ID and the current time, produces it, and returns it
+// so the caller can log or correlate it
+func (bus *MainBus[T]) ProduceNew(resource string, value T) (Event[T], error) {
+	ev := Event[T]{ID: bus.NextID(), Resource: resource, Value: value, Time: bus.now()}
+	return ev, bus.Produce(ev)
+}
+
+// ErrConveyorFull is returned by TryProduceErr when no conveyor the event may go to has room for
+// it at that instant, or the WithMaxTotalInFlight cap is reached
+var ErrConveyorFull = errors.New("main bus: conveyors full")
+
+// ErrRateLimited is returned by TryProduceErr when the rate limit has no token left
+var ErrRateLimited = errors.New("main bus: rate limit exhausted")
+
+// TryProduce attempts to place an event on the strategy's conveyor without blocking. If the chosen
+// conveyor is full, each remaining conveyor is tried once in turn. A false result means no
+// conveyor had free capacity at that instant, the rate limit or the WithMaxTotalInFlight cap
+// was exhausted, or a validator rejected the event; it was not enqueued. Middleware registered with Use runs first; an error
+// from it also yields false. TryProduceErr tells these cases apart.
+func (bus *MainBus[T]) TryProduce(ev Event[T]) bool {
+	return bus.TryProduceErr(ev) == nil
+}
+
+// TryProduceErr is TryProduce returning why an event was not enqueued: ErrConveyorFull,
+// ErrRateLimited, ErrBusClosed, an error wrapping ErrInvalidEvent from a validator, or the error
+// of a middleware
+func (bus *MainBus[T]) TryProduceErr(ev Event[T]) error {
+	return bus.chain(func(ev Event[T]) error {
+		err := bus.tryProduce(ev)
+		if err == nil {
+			bus.mirror(ev)
+		}
+		return err
+	})(ev)
+}
+
+// tryProduce is the end of the middleware chain for TryProduceErr
+func (bus *MainBus[T]) tryProduce(ev Event[T]) error {
+	t := bus.table()
+	n := len(t.live)
+	switch {
+	case bus.isClosed():
+		return ErrBusClosed
+	case n == 0:
+		return fmt.Errorf("main bus %q: %w", bus.Resource, ErrNoConveyors)
+	}
+	if err := bus.validate(ev); err != nil {
+		return err
+	}
+	if !bus.limiter.allow() {
+		return ErrRateLimited
+	}
+	if !bus.tryAdmit() {
+		bus.limiter.cancel()
+		return ErrConveyorFull
+	}
+	ev = withFate(ev)
+	start, pinned := bus.selectLine(t, ev)
+	if pinned {
+		// a keyed event may only go to its own conveyor, or per-key order would break
+		if bus.offer(start, t.belts[start], ev, true) {
+			return nil
+		}
+		bus.limiter.cancel()
+		bus.vacate()
+		return ErrConveyorFull
+	}
+	for i := range t.live {
+		if t.live[i] == start {
+			start = i
+			break
+		}
+	}
+	for i := 0; i < n; i++ {
+		line := t.live[(start+i)%n]
+		if bus.offer(line, t.belts[line], ev, true) {
+			return nil
+		}
+	}
+	bus.limiter.cancel()
+	bus.vacate()
+	return ErrConveyorFull
+}
+
+// offer places ev on one conveyor only if it has room right now, persisting it if record is set
+func (bus *MainBus[T]) offer(line int, b *belt[T], ev Event[T], record bool) bool {
+	if !bus.hold.tryEnter() {
+		return false
+	}
+	defer bus.hold.leave()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return false
+	}
+	select {
+	case b.c() <- ev:
+		bus.accept(line, ev, record)
+		return true
+	default:
+		return false
+	}
+}
+
+// Consume starts consuming a specific conveyor until it is closed, logging each event at info
+// level to the bus logger
+func (bus *MainBus[T]) Consume(line int, wg *sync.WaitGroup, opts ...ConsumeOption) {
+	attrs := []slog.Attr{}
+	if name := newConsumeConfig(opts).name; name != "" {
+		attrs = append(attrs, slog.String("consumer", name))
+	}
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		bus.logEvent(slog.LevelInfo, "event consumed", line, ev, append(attrs, slog.Time("event_time", ev.Time))...)
+	}, opts...)
+}
+
+// ConsumeWith consumes a specific conveyor until it is closed, calling handler for each event.
+// The handler runs synchronously on the consumer goroutine, so per-conveyor order is preserved.
+func (bus *MainBus[T]) ConsumeWith(line int, wg *sync.WaitGroup, handler func(Event[T]), opts ...ConsumeOption) {
+	bus.ConsumeContext(context.Background(), line, wg, handler, opts...)
+}
+
+// ConsumeContext consumes a specific conveyor like ConsumeWith, but also returns as soon as ctx
+// is cancelled. Events still buffered at that point are left on the conveyor.
+func (bus *MainBus[T]) ConsumeContext(ctx context.Context, line int, wg *sync.WaitGroup, handler func(Event[T]), opts ...ConsumeOption) {
 	defer wg.Done()
-	for ev := range bus.Conveyors[line] {
-		fmt.Printf("[Consumer-%s-L%d] ID:%d Value:%v Time:%s\n", bus.Resource, line, ev.ID, ev.Value, ev.Time.Format("15:04:05"))
+	cfg := newConsumeConfig(opts)
+	self := bus.attachAs(line, cfg.name)
+	defer self.detach()
+	c := bus.conveyor(line)
+	deliver := bus.deliver
+	if cfg.raw {
+		deliver = bus.deliverRaw
+	}
+	for bus.waitResumed(ctx, line) {
+		select {
+		case ev, ok := <-c:
+			if !ok && bus.reopened(line, &c) {
+				continue
+			}
+			if !ok || bus.poisoned(line, ev) {
+				return
+			}
+			// the conveyor may have been paused while this receive was waiting
+			bus.waitResumed(ctx, line)
+			deliver(line, ev, handler)
+			self.done()
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
-// Close closes all conveyors in the main bus
-func (bus *MainBus) Close() {
-	for _, c := range bus.Conveyors {
-		close(c)
+// ConsumeUntil is ConsumeContext for callers using a stop channel rather than a context: it
+// returns once stop is closed or the conveyor is closed, whichever comes first, leaving the
+// events still buffered on the conveyor.
+func (bus *MainBus[T]) ConsumeUntil(line int, wg *sync.WaitGroup, handler func(Event[T]), stop <-chan struct{}, opts ...ConsumeOption) {
+	bus.ConsumeContext(stopContext{context.Background(), stop}, line, wg, handler, opts...)
+}
+
+// stopContext is a context cancelled by closing stop, seen at once by Err unlike a context
+// cancelled from a goroutine waiting on stop
+type stopContext struct {
+	context.Context
+	stop <-chan struct{}
+}
+
+// Done implements context.Context
+func (c stopContext) Done() <-chan struct{} { return c.stop }
+
+// Err implements context.Context
+func (c stopContext) Err() error {
+	select {
+	case <-c.stop:
+		return context.Canceled
+	default:
+		return nil
 	}
 }
 
-func main() {
-	rand.Seed(time.Now().UnixNano())
+// deliver runs the consume-side pipeline for one event taken off a conveyor: expired events are
+// diverted, the rest are enriched and handed to handler, after which the event counts as
+// delivered unless handler panicked
+func (bus *MainBus[T]) deliver(line int, ev Event[T], handler func(Event[T])) {
+	bus.dispatch(line, ev, bus.enriched(handler), true)
+}
 
+// deliverRaw is deliver without enrichment, for handlers that enrich events themselves
+func (bus *MainBus[T]) deliverRaw(line int, ev Event[T], handler func(Event[T])) {
+	bus.dispatch(line, ev, handler, true)
+}
+
+// dispatch is deliver, leaving the event's fate to the caller unless settle is set
+func (bus *MainBus[T]) dispatch(line int, ev Event[T], handler func(Event[T]), settle bool) {
+	defer bus.onConsumed(line)
+	if ev.poison {
+		return // swallowed by a consumer that does not stop for it
+	}
+	if ev.barrier != nil {
+		ev.barrier.pass()
+		return
+	}
+	b := bus.belt(line)
+	if bus.typeStats {
+		b.types.count(ev.Value)
+	}
+	if b.passed(ev) {
+		b.stats.seeked.Add(1)
+		dropped(ev, "behind seek offset")
+		return
+	}
+	defer b.advance(int64(ev.ID))
+	bus.observeLatency(line, ev)
+	if bus.expired(ev) {
+		bus.expire(line, ev)
+		return
+	}
+	span := bus.startConsumeSpan(line, ev)
+	defer span.End()
+	r := bus.handle(line, ev, handler)
+	if r != nil {
+		span.SetStatus(codes.Error, fmt.Sprint("handler panicked: ", r))
+	}
+	switch {
+	case !settle:
+	case r != nil:
+		dropped(ev, "handler panicked")
+	default:
+		delivered(ev)
+	}
+}
+
+// handle runs handler for one event, recovering a panic so a single bad event cannot take
+// down the whole conveyor. It returns the recovered value, or nil if handler returned normally.
+func (bus *MainBus[T]) handle(line int, ev Event[T], handler func(Event[T])) (panicked any) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = r
+			bus.logEvent(slog.LevelError, "recovered handler panic", line, ev, slog.Any("panic", r))
+			if bus.OnPanic != nil {
+				bus.OnPanic(ev, r)
+			}
+		}
+	}()
+	handler(ev)
+	return nil
+}
+
+// Close closes all conveyors in the main bus. It is safe to call more than once and from
+// several goroutines; every call after the first returns ErrBusClosed.
+func (bus *MainBus[T]) Close() error {
+	bus.stop()
+	if !bus.closeConveyors() {
+		return ErrBusClosed
+	}
+	return nil
+}
+
+// Drain stops accepting new events, waits for consumers to empty every conveyor and then closes
+// the bus. Produces made once Drain has started return ErrBusClosed. If ctx is done before the
+// buffers empty, Drain returns an error and leaves the conveyors open; Close can then be used to
+// force them shut.
+func (bus *MainBus[T]) Drain(ctx context.Context) error {
+	bus.stop()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		bus.mu.RLock()
+		closed, depth := bus.closed, bus.TotalDepth()
+		bus.mu.RUnlock()
+		if closed {
+			return ErrBusClosed
+		}
+		if depth == 0 {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("main bus: drain stopped with %d events buffered: %w", depth, ctx.Err())
+		}
+	}
+	if !bus.closeConveyors() {
+		return ErrBusClosed
+	}
+	return nil
+}
+
+// Shutdown is the bounded shutdown services should call: it stops accepting produces, waits for
+// consumers to empty the conveyors as Drain does and closes the bus. If ctx is done first, the
+// bus is closed anyway and the events still buffered are taken off and discarded, counted in
+// BusMetrics.Dropped, and the error returned says how many were dropped and wraps ctx.Err();
+// an event a consumer was handling at that point is still handled. Only the first call does
+// anything: later calls, and calls on a bus already closed, return ErrBusClosed.
+func (bus *MainBus[T]) Shutdown(ctx context.Context) error {
+	if !bus.shutdown.CompareAndSwap(false, true) {
+		return ErrBusClosed
+	}
+	err := bus.Drain(ctx)
+	if err == nil || errors.Is(err, ErrBusClosed) {
+		return err
+	}
+	if !bus.closeConveyors() {
+		return ErrBusClosed
+	}
+	dropped := 0
+	for line, b := range bus.table().belts {
+		for ev := range b.out() {
+			bus.vacate()
+			bus.drop(slog.LevelWarn, line, ev, "shutdown timed out")
+			dropped++
+		}
+		b.finish()
+	}
+	return fmt.Errorf("main bus %q: shutdown dropped %d buffered events: %w", bus.Resource, dropped, ctx.Err())
+}
+
+// closeConveyors closes every conveyor and the dead-letter belt. It reports false if they were
+// already closed.
+func (bus *MainBus[T]) closeConveyors() bool {
+	bus.closeOnce.Do(func() { close(bus.closing) })
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		return false
+	}
+	bus.closed = true
+	for _, b := range bus.table().belts {
+		b.close()
+	}
+	if bus.deadLetters != nil {
+		close(bus.deadLetters)
+	}
+	if bus.journal != nil {
+		if err := bus.journal.close(); err != nil {
+			bus.logger.Error("closing event log failed", "resource", bus.Resource, "error", err)
+		}
+		if err := bus.SaveCheckpoints(); err != nil {
+			bus.logger.Error("saving checkpoints failed", "resource", bus.Resource, "error", err)
+		}
+	}
+	return true
+}
+
+// stop marks the bus as no longer accepting events and wakes producers blocked on full conveyors
+func (bus *MainBus[T]) stop() {
+	bus.stopOnce.Do(func() { close(bus.done) })
+}
+
+// isClosed reports whether the bus has stopped accepting events
+func (bus *MainBus[T]) isClosed() bool {
+	select {
+	case <-bus.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func main() {
 	// Create two independent resource main buses
-	ironBus := NewMainBus("iron", 4, 20)
-	copperBus := NewMainBus("copper", 2, 20)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	registry := NewBusRegistry[string]()
+	ironBus := registry.Register(Iron, 4, 20, WithLogger(logger))
+	copperBus := registry.Register(Copper, 2, 20, WithLogger(logger))
 
 	var wg sync.WaitGroup
 
@@ -81,17 +869,23 @@ func main() {
 		go copperBus.Consume(i, &wg)
 	}
 
+	// Ctrl-C drains both buses and stops the producers early
+	ctx := InstallSignalHandler(context.Background(), ironBus, copperBus)
+
 	// Producers sending events
-	for i := 0; i < 10; i++ {
-		ironBus.Produce(Event{ID: i, Resource: "iron", Value: "Iron Plate", Time: time.Now()})
-		copperBus.Produce(Event{ID: i, Resource: "copper", Value: "Copper Plate", Time: time.Now()})
-		time.Sleep(100 * time.Millisecond)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for i := 0; i < 10 && ctx.Err() == nil; i++ {
+		registry.ProduceTo(Iron, Event[string]{ID: i, Resource: string(Iron), Value: "Iron Plate", Time: time.Now()})
+		registry.ProduceTo(Copper, Event[string]{ID: i, Resource: string(Copper), Value: "Copper Plate", Time: time.Now()})
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+		}
 	}
 
-	// Finish
-	time.Sleep(1 * time.Second)
-	ironBus.Close()
-	copperBus.Close()
+	// Finish: drain whatever is left, unless a signal already did
+	shutdownBuses(SignalDrainTimeout, ironBus, copperBus)
 
 	wg.Wait()
 	fmt.Println("All main buses completed processing.")
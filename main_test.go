@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCloseAndProduceConcurrently(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(8))
+	var consumers sync.WaitGroup
+	for line := range bus.Conveyors {
+		consumers.Add(1)
+		go bus.ConsumeWith(line, &consumers, func(Event[int]) {})
+	}
+
+	var closes, produced, rejected atomic.Int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for g := 0; g < 16; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			for i := 0; i < 500; i++ {
+				switch err := bus.Produce(Event[int]{ID: i}); {
+				case err == nil:
+					produced.Add(1)
+				case errors.Is(err, ErrBusClosed):
+					rejected.Add(1)
+				default:
+					t.Errorf("Produce: %v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			if bus.Close() == nil {
+				closes.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	consumers.Wait()
+
+	if n := closes.Load(); n != 1 {
+		t.Fatalf("%d Close calls succeeded, want exactly 1", n)
+	}
+	if err := bus.Close(); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("second Close = %v, want ErrBusClosed", err)
+	}
+	if err := bus.Produce(Event[int]{}); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Produce after Close = %v, want ErrBusClosed", err)
+	}
+	if got := produced.Load() + rejected.Load(); got != 16*500 {
+		t.Fatalf("%d produces accounted for, want %d", got, 16*500)
+	}
+	var consumed uint64
+	for _, n := range bus.Metrics().Consumed {
+		consumed += n
+	}
+	if consumed != uint64(produced.Load()) {
+		t.Fatalf("consumed %d events, want all %d accepted ones", consumed, produced.Load())
+	}
+}
+
+func TestConsumeContextCancelMidStream(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithBuffer(16), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	for i := 0; i < 10; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var handled atomic.Int64
+	wg.Add(1)
+	go bus.ConsumeContext(ctx, 0, &wg, func(Event[int]) {
+		if handled.Add(1) == 2 {
+			cancel()
+		}
+	})
+
+	exited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not exit after cancellation")
+	}
+	if n := handled.Load(); n != 2 {
+		t.Fatalf("handled %d events, want 2", n)
+	}
+	if d := bus.Depth(0); d != 3 {
+		t.Fatalf("depth = %d, want the 3 remaining events left buffered", d)
+	}
+}
+
+func TestConsumeUntilStopMidStream(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithBuffer(16), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	for i := 0; i < 20; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var handled atomic.Int64
+	wg.Add(1)
+	go bus.ConsumeUntil(0, &wg, func(Event[int]) {
+		if handled.Add(1) == 2 {
+			close(stop)
+		}
+	}, stop)
+
+	exited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not exit after stop was closed")
+	}
+	if n := handled.Load(); n != 2 {
+		t.Fatalf("handled %d events, want 2", n)
+	}
+	if d := bus.Depth(0); d != 8 {
+		t.Fatalf("depth = %d, want the 8 remaining events left buffered", d)
+	}
+}
+
+func TestHandlerPanicIsRecovered(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithStrategy(StrategyRoundRobin))
+	var panicked []int
+	bus.OnPanic = func(ev Event[int], r any) {
+		if r != "bad event" {
+			t.Errorf("OnPanic got %v", r)
+		}
+		panicked = append(panicked, ev.ID)
+	}
+	for i := 0; i < 10; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	bus.Close()
+
+	var handled []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeWith(0, &wg, func(ev Event[int]) {
+		if ev.ID == 4 {
+			panic("bad event")
+		}
+		handled = append(handled, ev.ID)
+	})
+
+	if len(panicked) != 1 || panicked[0] != 4 {
+		t.Fatalf("OnPanic saw %v, want [4]", panicked)
+	}
+	if want := []int{0, 2, 6, 8}; fmt.Sprint(handled) != fmt.Sprint(want) {
+		t.Fatalf("handled %v, want %v", handled, want)
+	}
+}
+
+func TestProduceNewUniqueIDs(t *testing.T) {
+	const producers, each = 16, 200
+	bus := NewMainBus[int]("iron", WithBuffer(producers*each))
+	var wg sync.WaitGroup
+	for g := 0; g < producers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				if _, err := bus.ProduceNew("iron", i); err != nil {
+					t.Errorf("ProduceNew: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	bus.Close()
+
+	seen := make(map[int]bool)
+	for _, c := range bus.Conveyors {
+		for ev := range c {
+			if seen[ev.ID] {
+				t.Fatalf("duplicate ID %d", ev.ID)
+			}
+			seen[ev.ID] = true
+		}
+	}
+	if len(seen) != producers*each {
+		t.Fatalf("%d distinct IDs, want %d", len(seen), producers*each)
+	}
+	if next := bus.NextID(); next != producers*each+1 {
+		t.Fatalf("NextID = %d, want %d", next, producers*each+1)
+	}
+}
+
+func TestProduceReturnRoundRobin(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	for i := 0; i < 8; i++ {
+		line, err := bus.ProduceReturn(Event[int]{ID: i})
+		if err != nil || line != i%4 {
+			t.Fatalf("ProduceReturn %d = %d, %v; want conveyor %d", i, line, err, i%4)
+		}
+	}
+	for line := 0; line < 4; line++ {
+		if n := bus.Metrics().Produced[line]; n != 2 {
+			t.Fatalf("conveyor %d produced %d, want 2", line, n)
+		}
+	}
+	bus.Close()
+	if line, err := bus.ProduceReturn(Event[int]{ID: 9}); line != -1 || !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("ProduceReturn on a closed bus = %d, %v; want -1, ErrBusClosed", line, err)
+	}
+}
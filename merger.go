@@ -0,0 +1,64 @@
+package main
+
+import "sync"
+
+// NewMerger fans in every conveyor of the given buses into a single unbuffered conveyor.
+// Each source conveyor is copied by its own goroutine, so events from one conveyor keep
+// their order while events from different conveyors may interleave. The merged conveyor is
+// closed once all sources are closed and drained, or after the returned stop function is called;
+// an event already taken off a source conveyor when stop is called is discarded. Events count as
+// delivered once they are read from the merged conveyor.
+func NewMerger[T any](sources []*MainBus[T]) (Conveyor[T], func()) {
+	out := make(Conveyor[T])
+	quit := make(chan struct{})
+	var once sync.Once
+	var wg sync.WaitGroup
+
+	for _, bus := range sources {
+		for line, b := range bus.table().belts {
+			c := b.out()
+			wg.Add(1)
+			go func(bus *MainBus[T], line int, c Conveyor[T]) {
+				defer wg.Done()
+				self := bus.attach(line)
+				defer self.detach()
+				for {
+					select {
+					case ev, ok := <-c:
+						if !ok && bus.reopened(line, &c) {
+							continue
+						}
+						if !ok {
+							return
+						}
+						bus.onConsumed(line)
+						self.done()
+						if ev.poison {
+							continue
+						}
+						if ev.barrier != nil {
+							ev.barrier.pass()
+							continue
+						}
+						select {
+						case out <- ev:
+							delivered(ev)
+						case <-quit:
+							dropped(ev, "merger stopped")
+							return
+						}
+					case <-quit:
+						return
+					}
+				}
+			}(bus, line, c)
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, func() { once.Do(func() { close(quit) }) }
+}
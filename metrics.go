@@ -0,0 +1,144 @@
+package main
+
+import "sync/atomic"
+
+// lineStats holds the counters tracked for a single conveyor
+type lineStats struct {
+	produced  atomic.Uint64
+	consumed  atomic.Uint64
+	dropped   atomic.Uint64
+	deduped   atomic.Uint64
+	expired   atomic.Uint64
+	retried   atomic.Uint64
+	failed    atomic.Uint64
+	timedOut  atomic.Uint64
+	coalesced atomic.Uint64
+	sampled   atomic.Uint64
+	skipped   atomic.Uint64
+	seeked    atomic.Uint64
+	pressured atomic.Bool // above the high watermark and not yet back below the low one
+}
+
+// onProduced records the event with ID id accepted by a conveyor
+func (bus *MainBus[T]) onProduced(line, id int) {
+	b := bus.belt(line)
+	b.stats.produced.Add(1)
+	b.noteProduced(id)
+	b.trackFull(bus.clock)
+	bus.checkPressure(line)
+}
+
+// onConsumed records an event taken off a conveyor
+func (bus *MainBus[T]) onConsumed(line int) {
+	b := bus.belt(line)
+	b.stats.consumed.Add(1)
+	bus.vacate()
+	b.trackFull(bus.clock)
+	bus.checkPressure(line)
+}
+
+// BusMetrics is a point-in-time view of per-conveyor activity, indexed by line, plus the
+// bus-wide dead-letter, dead-letter drop and mirror drop counts. Labels holds each conveyor's
+// label, or its index when it has none, and Lag each conveyor's Lag.
+type BusMetrics struct {
+	DeadLettered  uint64 `json:"dead_lettered"`
+	MirrorDropped uint64 `json:"mirror_dropped"`
+	// DeadLetterDropped counts rejected events lost to a full dead-letter conveyor; any increase
+	// means failures are piling up faster than they are handled
+	DeadLetterDropped uint64 `json:"dead_letter_dropped"`
+	// StuckHandlers counts ConsumeWithHandlerTimeout handlers still running past their timeout,
+	// each holding a leaked goroutine
+	StuckHandlers int64 `json:"stuck_handlers"`
+	// OverflowDegraded tells whether WithAdaptiveOverflow has the bus dropping the oldest events
+	// under sustained overload, and Degradations how many times it switched to that mode
+	OverflowDegraded bool   `json:"overflow_degraded"`
+	Degradations     uint64 `json:"degradations"`
+
+	Labels   []string `json:"labels"`
+	Produced []uint64 `json:"produced"`
+	Consumed []uint64 `json:"consumed"`
+	Dropped  []uint64 `json:"dropped"`
+	Deduped  []uint64 `json:"deduped"`
+	Expired  []uint64 `json:"expired"`
+	Retried  []uint64 `json:"retried"`
+	Failed   []uint64 `json:"failed"`
+	TimedOut []uint64 `json:"timed_out"`
+	// Coalesced counts events a CoalescingBuffer, such as WithCoalesceKey's, replaced with a
+	// newer one
+	Coalesced []uint64 `json:"coalesced"`
+	Sampled   []uint64 `json:"sampled"`
+	Skipped   []uint64 `json:"skipped"`
+	Seeked    []uint64 `json:"seeked"`
+	Depth     []int    `json:"depth"`
+	Capacity  []int    `json:"capacity"`
+	Lag       []int64  `json:"lag"`
+}
+
+// Metrics returns the event counts and current buffer usage of every conveyor
+func (bus *MainBus[T]) Metrics() BusMetrics {
+	belts := bus.table().belts
+	n := len(belts)
+	m := BusMetrics{
+		DeadLettered:      bus.rejected.Load(),
+		MirrorDropped:     bus.mirrorDropped.Load(),
+		DeadLetterDropped: bus.deadLetterDropped.Load(),
+		StuckHandlers:     bus.stuckHandlers.Load(),
+		OverflowDegraded:  bus.degraded.Load(),
+		Degradations:      bus.degradations.Load(),
+		Labels:            make([]string, n),
+		Produced:          make([]uint64, n),
+		Consumed:          make([]uint64, n),
+		Dropped:           make([]uint64, n),
+		Deduped:           make([]uint64, n),
+		Expired:           make([]uint64, n),
+		Retried:           make([]uint64, n),
+		Failed:            make([]uint64, n),
+		TimedOut:          make([]uint64, n),
+		Coalesced:         make([]uint64, n),
+		Sampled:           make([]uint64, n),
+		Skipped:           make([]uint64, n),
+		Seeked:            make([]uint64, n),
+		Depth:             make([]int, n),
+		Capacity:          make([]int, n),
+		Lag:               make([]int64, n),
+	}
+	for i, b := range belts {
+		m.Labels[i] = b.labelOr(i)
+		m.Produced[i] = b.stats.produced.Load()
+		m.Consumed[i] = b.stats.consumed.Load()
+		m.Dropped[i] = b.stats.dropped.Load()
+		m.Deduped[i] = b.stats.deduped.Load()
+		m.Expired[i] = b.stats.expired.Load()
+		m.Retried[i] = b.stats.retried.Load()
+		m.Failed[i] = b.stats.failed.Load()
+		m.TimedOut[i] = b.stats.timedOut.Load()
+		m.Coalesced[i] = b.stats.coalesced.Load()
+		m.Sampled[i] = b.stats.sampled.Load()
+		m.Skipped[i] = b.stats.skipped.Load()
+		m.Seeked[i] = b.stats.seeked.Load()
+		m.Depth[i] = b.depth()
+		m.Capacity[i] = b.capacity()
+		m.Lag[i] = b.lag()
+	}
+	return m
+}
+
+// Depth returns the number of events buffered on a conveyor. Like indexing Conveyors directly,
+// it panics if line is out of range.
+func (bus *MainBus[T]) Depth(line int) int {
+	return bus.belt(line).depth()
+}
+
+// Capacity returns the buffer size of a conveyor, panicking if line is out of range
+func (bus *MainBus[T]) Capacity(line int) int {
+	return bus.belt(line).capacity()
+}
+
+// TotalDepth returns the number of events buffered across all conveyors
+func (bus *MainBus[T]) TotalDepth() int {
+	total := 0
+	for _, b := range bus.table().belts {
+		total += b.depth()
+	}
+	return total
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestMetricsCounts(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	for i := 0; i < 6; i++ {
+		if err := bus.Produce(Event[int]{ID: i}); err != nil {
+			t.Fatalf("Produce: %v", err)
+		}
+	}
+	m := bus.Metrics()
+	for line := range bus.Conveyors {
+		if m.Produced[line] != 3 || m.Consumed[line] != 0 || m.Depth[line] != 3 || m.Capacity[line] != 8 {
+			t.Fatalf("line %d before consuming: %+v", line, m)
+		}
+	}
+
+	// consume two events off line 0, then drain line 1 after closing
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := 0
+	wg.Add(1)
+	bus.ConsumeContext(ctx, 0, &wg, func(Event[int]) {
+		if handled++; handled == 2 {
+			cancel()
+		}
+	})
+	bus.Close()
+	wg.Add(1)
+	bus.ConsumeWith(1, &wg, func(Event[int]) {})
+
+	m = bus.Metrics()
+	want := []struct {
+		produced, consumed uint64
+		depth              int
+	}{{3, 2, 1}, {3, 3, 0}}
+	for line, w := range want {
+		if m.Produced[line] != w.produced || m.Consumed[line] != w.consumed || m.Depth[line] != w.depth {
+			t.Fatalf("line %d: produced %d consumed %d depth %d, want %+v", line, m.Produced[line], m.Consumed[line], m.Depth[line], w)
+		}
+	}
+	if bus.TotalDepth() != 1 {
+		t.Fatalf("TotalDepth = %d, want 1", bus.TotalDepth())
+	}
+}
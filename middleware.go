@@ -0,0 +1,47 @@
+package main
+
+// ProduceFunc is a step in the produce pipeline
+type ProduceFunc[T any] func(Event[T]) error
+
+// Middleware wraps a ProduceFunc with cross-cutting logic such as timestamping, validation or
+// tracing. It may modify the event before calling next, or return an error without calling
+// next to stop the produce.
+type Middleware[T any] func(next ProduceFunc[T]) ProduceFunc[T]
+
+// Use registers middleware applied to every Produce, ProduceContext, TryProduce and Producer.Send
+// call. Middleware runs in registration order: the first registered sees the event first. An
+// error returned by any middleware short-circuits the chain and is returned to the producer.
+func (bus *MainBus[T]) Use(mw Middleware[T]) {
+	bus.mwMu.Lock()
+	defer bus.mwMu.Unlock()
+	bus.middleware = append(bus.middleware, mw)
+}
+
+// chain wraps final with the registered middleware
+func (bus *MainBus[T]) chain(final ProduceFunc[T]) ProduceFunc[T] {
+	bus.mwMu.RLock()
+	defer bus.mwMu.RUnlock()
+	next := final
+	for i := len(bus.middleware) - 1; i >= 0; i-- {
+		next = bus.middleware[i](next)
+	}
+	return next
+}
+
+// TimestampMiddleware sets Time to the current time on events produced without one
+func TimestampMiddleware[T any](next ProduceFunc[T]) ProduceFunc[T] {
+	return ClockTimestampMiddleware[T](realClock{})(next)
+}
+
+// ClockTimestampMiddleware is TimestampMiddleware reading the time from c, typically the
+// FakeClock a test bus was built WithClock
+func ClockTimestampMiddleware[T any](c Clock) Middleware[T] {
+	return func(next ProduceFunc[T]) ProduceFunc[T] {
+		return func(ev Event[T]) error {
+			if ev.Time.IsZero() {
+				ev.Time = c.Now()
+			}
+			return next(ev)
+		}
+	}
+}
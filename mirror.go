@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Mirror tees every event the bus accepts from Produce, ProduceContext, ProduceBatch or
+// TryProduce onto replica as well, e.g. for a hot standby or an analytics bus. Several replicas
+// may be attached. Mirroring is best-effort and never holds up the primary: each replica is
+// offered the event without blocking, as with TryProduce, and a replica that is full, rate
+// limited or closed misses it, counted in BusMetrics.MirrorDropped. Replicas get the event as
+// the primary accepted it, after the primary's middleware, and neither run their own middleware
+// nor mirror it further. Mirroring a bus to itself returns an error wrapping ErrInvalidConfig.
+func (bus *MainBus[T]) Mirror(replica *MainBus[T]) error {
+	if replica == bus {
+		return fmt.Errorf("main bus %q: %w: cannot mirror a bus to itself", bus.Resource, ErrInvalidConfig)
+	}
+	bus.mwMu.Lock()
+	defer bus.mwMu.Unlock()
+	mirrors := append([]*MainBus[T](nil), bus.mirrorList()...)
+	mirrors = append(mirrors, replica)
+	bus.mirrors.Store(&mirrors)
+	return nil
+}
+
+// mirrorList returns the current replicas
+func (bus *MainBus[T]) mirrorList() []*MainBus[T] {
+	if p := bus.mirrors.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// mirror offers an accepted event to every replica
+func (bus *MainBus[T]) mirror(ev Event[T]) {
+	for _, replica := range bus.mirrorList() {
+		if replica.tryProduce(withoutFate(ev)) != nil {
+			bus.mirrorDropped.Add(1)
+			bus.logEvent(slog.LevelDebug, "mirror dropped event", -1, ev, slog.String("replica", replica.Resource))
+		}
+	}
+}
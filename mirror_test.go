@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	primary := NewMainBus[int]("iron", WithBuffer(8))
+	standby := NewMainBus[int]("iron-standby", WithBuffer(8))
+	analytics := NewMainBus[int]("iron-analytics", WithLines(1), WithBuffer(1))
+	analytics.RemoveConveyor(1)
+	defer primary.Close()
+	defer standby.Close()
+	defer analytics.Close()
+	if err := primary.Mirror(standby); err != nil {
+		t.Fatalf("Mirror: %v", err)
+	}
+	if err := primary.Mirror(analytics); err != nil {
+		t.Fatalf("Mirror: %v", err)
+	}
+	if err := primary.Mirror(primary); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("self mirror: %v, want ErrInvalidConfig", err)
+	}
+
+	primary.Produce(Event[int]{ID: 1})
+	primary.TryProduce(Event[int]{ID: 2})
+	primary.ProduceBatch([]Event[int]{{ID: 3}})
+	if primary.TotalDepth() != 3 || standby.TotalDepth() != 3 {
+		t.Fatalf("primary %d, standby %d events; want 3 each", primary.TotalDepth(), standby.TotalDepth())
+	}
+	// the analytics replica only has room for one; the rest are counted, not waited for
+	if analytics.TotalDepth() != 1 || primary.Metrics().MirrorDropped != 2 {
+		t.Fatalf("analytics %d events, %d mirror drops; want 1 and 2", analytics.TotalDepth(), primary.Metrics().MirrorDropped)
+	}
+	// replicas do not mirror onwards
+	standby.Mirror(primary)
+	primary.Produce(Event[int]{ID: 4})
+	if primary.TotalDepth() != 4 || standby.TotalDepth() != 4 {
+		t.Fatalf("primary %d, standby %d events after a cycle; want 4 each", primary.TotalDepth(), standby.TotalDepth())
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures an optional MainBus feature at construction time
+type Option func(*busConfig)
+
+// busConfig collects the settings applied by options before the bus is built
+type busConfig struct {
+	lines            int
+	buffer           int
+	strategy         SelectStrategy
+	urgent           int // StrategyPriorityAware threshold
+	vnodes           int // StrategyConsistentHash points per conveyor
+	deadLetter       bool
+	deadLetterBuffer int
+	rateLimit        int
+	highWater        float64
+	lowWater         float64
+	overflow         OverflowPolicy
+	persistPath      string
+	fsyncInterval    time.Duration
+	ttl              time.Duration
+	logger           *slog.Logger
+	tracer           trace.Tracer
+	weights          []int
+	keyFunc          any   // KeyFunc[T] for the bus event type
+	validators       []any // Validator[T]s for the bus event type
+	stallThreshold   time.Duration
+	codec            any // Codec[T] for the bus event type
+	compression      Compression
+	labels           []string
+	encryptionKey    []byte
+	autoscale        *autoscaleConfig
+	consumerFactory  ConsumerFactory
+	autoscalePeriod  time.Duration
+
+	deadLetterOverflow OverflowPolicy
+	clock              Clock
+	saturation         *saturationConfig
+	saturationInterval time.Duration
+	adaptive           *adaptiveConfig
+	adaptiveSustain    time.Duration
+	onOverflowMode     func(OverflowPolicy)
+	enricher           any // Enricher[T] for the bus event type
+	maxInFlight        int
+	router             any // RoutingStrategy[T] for the bus event type
+	bufferFactory      any // BufferFactory[T] for the bus event type
+	randSeed           *int64
+	dedupStore         DedupStore
+	typeStats          bool
+}
+
+// WithLines sets how many parallel conveyors the bus has. Odd counts are rounded up to keep
+// the bus symmetric, and counts below one fall back to DefaultLines.
+func WithLines(n int) Option {
+	return func(c *busConfig) {
+		c.lines = n
+	}
+}
+
+// WithBuffer sets the buffer size of each conveyor
+func WithBuffer(n int) Option {
+	return func(c *busConfig) {
+		c.buffer = n
+	}
+}
+
+// WithStrategy sets how produced events are routed across the conveyors
+func WithStrategy(s SelectStrategy) Option {
+	return func(c *busConfig) {
+		c.strategy = s
+	}
+}
+
+// WithWeights sets one routing weight per conveyor for StrategyWeighted, which it also selects.
+// The bus must end up with exactly len(weights) lines, after odd counts are rounded up.
+func WithWeights(weights []int) Option {
+	return func(c *busConfig) {
+		c.strategy = StrategyWeighted
+		c.weights = weights
+	}
+}
+
+// WithPriorityThreshold sets the lowest Priority StrategyPriorityAware routes as high priority,
+// and selects that strategy
+func WithPriorityThreshold(threshold int) Option {
+	return func(c *busConfig) {
+		c.strategy = StrategyPriorityAware
+		c.urgent = threshold
+	}
+}
+
+// WithKeyFunc sets the routing key of each event for StrategyHashKey, which it also selects unless
+// WithVirtualNodes chose StrategyConsistentHash. The func must take the bus event type.
+func WithKeyFunc[T any](f KeyFunc[T]) Option {
+	return func(c *busConfig) {
+		if c.strategy != StrategyConsistentHash {
+			c.strategy = StrategyHashKey
+		}
+		c.keyFunc = f
+	}
+}
+
+// WithDeadLetter gives the bus a dead-letter conveyor with the given buffer size, used by
+// Reject and ConsumeWithReject to park events that could not be processed
+func WithDeadLetter(buffer int) Option {
+	return func(c *busConfig) {
+		c.deadLetter = true
+		c.deadLetterBuffer = buffer
+	}
+}
+
+// WithRateLimit caps how many events per second may be produced onto the bus, modelling belt
+// throughput. Produce and ProduceContext wait for capacity; TryProduce fails instead.
+func WithRateLimit(eventsPerSec int) Option {
+	return func(c *busConfig) {
+		c.rateLimit = eventsPerSec
+	}
+}
+
+// WithWatermarks enables backpressure signals: Pressure fires when a conveyor fills to at least
+// high (a fraction of its buffer, e.g. 0.8) and Relieved fires once it drains to low or below
+func WithWatermarks(high, low float64) Option {
+	return func(c *busConfig) {
+		c.highWater = high
+		c.lowWater = low
+	}
+}
+
+// WithOverflowPolicy sets what Produce does when the chosen conveyor is full. Dropped events
+// are counted in BusMetrics.Dropped.
+func WithOverflowPolicy(p OverflowPolicy) Option {
+	return func(c *busConfig) {
+		c.overflow = p
+	}
+}
+
+// WithPersistence appends every event enqueued by Produce, ProduceContext or TryProduce to the
+// file at path, one per line, for crash recovery with ReplayFile. Lines hold the bus codec's
+// encoding: plain JSON by default, base64 for binary codecs such as GobCodec. Events are written
+// just after they land on a conveyor, before that conveyor can be closed, so every event
+// accepted by a produce call is on the log once Close returns. Broadcast control events and
+// events forwarded by RemoveConveyor are not logged. Consume offsets are kept next to the log;
+// see SaveCheckpoints.
+func WithPersistence(path string) Option {
+	return func(c *busConfig) {
+		c.persistPath = path
+	}
+}
+
+// WithFsyncInterval sets how often the persistence log is fsynced. Zero (the default) fsyncs
+// after every write; a positive interval syncs in the background, trading a window of possible
+// loss on crash for much cheaper writes.
+func WithFsyncInterval(d time.Duration) Option {
+	return func(c *busConfig) {
+		c.fsyncInterval = d
+	}
+}
+
+// WithTTL expires events that have waited on a conveyor longer than ttl, measured from
+// Event.Time when a handler-based consumer takes them. Expired events go to the dead-letter
+// conveyor when there is one and are dropped otherwise; either way they are counted in
+// BusMetrics.Expired and never reach the handler.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *busConfig) {
+		c.ttl = ttl
+	}
+}
+
+// ConsumeOption configures a handler-based consumer
+type ConsumeOption func(*consumeConfig)
+
+// consumeConfig collects the settings applied by consume options
+type consumeConfig struct {
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	onBreakerChange  func(BreakerState)
+	maxDeliveries    int
+	eventTime        bool
+	name             string
+	raw              bool
+}
+
+// newConsumeConfig applies opts over the defaults
+func newConsumeConfig(opts []ConsumeOption) consumeConfig {
+	var cfg consumeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
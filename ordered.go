@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ConsumeMergedOrdered consumes every conveyor from a single goroutine, like ConsumeAll, but hands
+// handler the events of all conveyors in Event.Time order, as a k-way merge of the conveyors. It
+// buffers up to lookahead events per conveyor (at least one) and emits the earliest buffered
+// event once every open conveyor has one buffered or some conveyor's buffer is full; a closed
+// conveyor stops counting once its buffer is empty. The order is exact when each conveyor is
+// sorted by time. For streams that are only nearly sorted it is approximate: an event arriving
+// after lookahead later events already buffered on another conveyor, or after its own conveyor
+// sat empty while others filled up, is emitted late, out of order. Events can therefore wait in
+// the buffer while some conveyor stays empty, until it receives an event or closes. Ties go to
+// the lowest line. It returns once all conveyors are closed and drained, and like ConsumeAll it
+// is not held back by Pause.
+func (bus *MainBus[T]) ConsumeMergedOrdered(wg *sync.WaitGroup, handler func(Event[T]), lookahead int) {
+	defer wg.Done()
+	lookahead = max(lookahead, 1)
+	belts := bus.table().belts
+	cases := make([]reflect.SelectCase, len(belts))
+	pending := make([][]Event[T], len(belts))
+	selves := make([]*consumer, len(belts))
+	for i, b := range belts {
+		selves[i] = bus.attach(i)
+		defer selves[i].detach()
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(b.out())}
+	}
+	for open := len(cases); ; {
+		if line := nextOrdered(cases, pending, lookahead); line >= 0 {
+			ev := pending[line][0]
+			pending[line] = pending[line][1:]
+			bus.deliver(line, ev, handler)
+			selves[line].done()
+			continue
+		}
+		if open == 0 {
+			return
+		}
+		// an empty conveyor might hold back the merge, so it is read first
+		if got, closed := bus.tryEmpty(cases, pending); got || closed > 0 {
+			open -= closed
+			continue
+		}
+		line, v, ok := reflect.Select(cases)
+		if !ok && bus.reopenedCase(line, &cases[line]) {
+			continue
+		}
+		if !ok {
+			cases[line].Chan = reflect.Value{} // a zero Chan is never selected again
+			open--
+			continue
+		}
+		pending[line] = append(pending[line], v.Interface().(Event[T]))
+	}
+}
+
+// nextOrdered returns the line whose buffered head should be emitted next, or -1 if the merge has
+// to wait: while an open conveyor has nothing buffered and no buffer is full, an earlier event
+// may still arrive
+func nextOrdered[T any](cases []reflect.SelectCase, pending [][]Event[T], lookahead int) int {
+	earliest, waiting, full := -1, false, false
+	for line, evs := range pending {
+		switch {
+		case len(evs) >= lookahead:
+			full = true
+		case len(evs) == 0:
+			waiting = waiting || cases[line].Chan.IsValid()
+			continue
+		}
+		if earliest < 0 || evs[0].Time.Before(pending[earliest][0].Time) {
+			earliest = line
+		}
+	}
+	if waiting && !full {
+		return -1
+	}
+	return earliest
+}
+
+// tryEmpty receives without blocking from every open conveyor with nothing buffered, reporting
+// whether it got an event and how many conveyors it found closed
+func (bus *MainBus[T]) tryEmpty(cases []reflect.SelectCase, pending [][]Event[T]) (got bool, closed int) {
+	for line, c := range cases {
+		if len(pending[line]) > 0 || !c.Chan.IsValid() {
+			continue
+		}
+		v, ok := c.Chan.TryRecv()
+		switch {
+		case ok:
+			pending[line] = append(pending[line], v.Interface().(Event[T]))
+			got = true
+		case v.IsValid() && !bus.reopenedCase(line, &cases[line]):
+			cases[line].Chan = reflect.Value{}
+			closed++
+		}
+	}
+	return got, closed
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsumeMergedOrderedInterleaved(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(16))
+	base := time.Unix(1000, 0)
+	// line 0 holds the even seconds and line 1 the odd ones, each sorted
+	for i := range 10 {
+		c := bus.Conveyors[i%2]
+		c <- Event[int]{ID: i + 1, Value: i, Time: base.Add(time.Duration(i) * time.Second)}
+	}
+	bus.Close()
+
+	var got []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeMergedOrdered(&wg, func(ev Event[int]) { got = append(got, ev.Value) }, 4)
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("got %v, want 0..9 in time order", got)
+		}
+	}
+	if len(got) != 10 {
+		t.Fatalf("got %d events, want 10", len(got))
+	}
+}
+
+func TestConsumeMergedOrderedWaitsForEmptyConveyor(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(16))
+	base := time.Unix(1000, 0)
+	out := make(chan int, 16)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeMergedOrdered(&wg, func(ev Event[int]) { out <- ev.Value }, 3)
+
+	bus.Conveyors[0] <- Event[int]{ID: 1, Value: 2, Time: base.Add(2 * time.Second)}
+	select {
+	case v := <-out:
+		t.Fatalf("emitted %d while line 1 was still empty", v)
+	case <-time.After(20 * time.Millisecond):
+	}
+	// the late but earlier event on line 1 still comes first
+	bus.Conveyors[1] <- Event[int]{ID: 2, Value: 1, Time: base.Add(time.Second)}
+	if v := <-out; v != 1 {
+		t.Fatalf("first event = %d, want 1", v)
+	}
+	// with line 1 empty again, a full lookahead on line 0 forces the merge on
+	for i := 3; i <= 5; i++ {
+		bus.Conveyors[0] <- Event[int]{ID: i, Value: i, Time: base.Add(time.Duration(i) * time.Second)}
+	}
+	if v := <-out; v != 2 {
+		t.Fatalf("second event = %d, want 2", v)
+	}
+	bus.Close()
+	wg.Wait()
+	close(out)
+	var rest []int
+	for v := range out {
+		rest = append(rest, v)
+	}
+	if len(rest) != 3 || rest[0] != 3 || rest[2] != 5 {
+		t.Errorf("remaining events = %v, want [3 4 5]", rest)
+	}
+	if consumed := consumedTotal(bus); consumed != 5 {
+		t.Errorf("consumed %d, want 5", consumed)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrEventDropped is returned by Produce and ProduceContext when OverflowDropNewest discarded the
+// event because its conveyor was full
+var ErrEventDropped = errors.New("main bus: event dropped, conveyor full")
+
+// OverflowPolicy decides what Produce does when the chosen conveyor is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the conveyor to have room (the default)
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the incoming event, and the produce call returns ErrEventDropped
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest buffered event to make room for the incoming one.
+	// Unbuffered conveyors have nothing to discard, so they behave like OverflowDropNewest.
+	OverflowDropOldest
+)
+
+// route places ev on a live conveyor chosen by the bus strategy, picking again if the chosen
+// conveyor is removed while the producer waits on it. With record set the event is also written
+// to the persistence log. It returns the line last tried, or -1 if there was none.
+func (bus *MainBus[T]) route(ctx context.Context, ev Event[T], record bool) (int, error) {
+	for {
+		if bus.isClosed() {
+			return -1, ErrBusClosed
+		}
+		t := bus.table()
+		if len(t.live) == 0 {
+			return -1, fmt.Errorf("main bus %q: %w", bus.Resource, ErrNoConveyors)
+		}
+		line, _ := bus.selectLine(t, ev)
+		if err := bus.send(ctx, line, t.belts[line], ev, record); err != errBeltClosed {
+			return line, err
+		}
+	}
+}
+
+// send places ev on one conveyor according to the overflow policy. It returns errBeltClosed if
+// the conveyor is closed before the event could be placed.
+func (bus *MainBus[T]) send(ctx context.Context, line int, b *belt[T], ev Event[T], record bool) error {
+	if err := bus.hold.enter(ctx, bus.done); err != nil {
+		return err
+	}
+	defer bus.hold.leave()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return errBeltClosed
+	}
+	c := b.c()
+	policy := bus.overflowPolicy()
+	if policy == OverflowDropOldest && cap(c) == 0 {
+		policy = OverflowDropNewest
+	}
+	switch policy {
+	case OverflowDropNewest:
+		select {
+		case c <- ev:
+			bus.accept(line, ev, record)
+			return nil
+		default:
+			bus.drop(slog.LevelWarn, line, ev, "conveyor full")
+			return ErrEventDropped
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case c <- ev:
+				bus.accept(line, ev, record)
+				return nil
+			default:
+			}
+			select {
+			case old := <-c:
+				bus.vacate()
+				bus.drop(slog.LevelWarn, line, old, "evicted by newer event")
+			default:
+			}
+		}
+	default:
+		select {
+		case c <- ev:
+			bus.accept(line, ev, record)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-bus.done:
+			return ErrBusClosed
+		case <-b.closing:
+			return errBeltClosed
+		}
+	}
+}
+
+// accept records an event that has landed on line, writing it to the persistence log if record
+// is set. Callers hold the conveyor's read lock, so the write completes before the conveyor (and
+// with it the log) can be closed.
+func (bus *MainBus[T]) accept(line int, ev Event[T], record bool) {
+	bus.onProduced(line, ev.ID)
+	if record {
+		bus.persist(ev)
+	}
+}
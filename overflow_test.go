@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fullBus returns a bus routing only to line 0, which holds events 0 and 1 in a buffer of 2
+func fullBus(t *testing.T, policy OverflowPolicy) *MainBus[int] {
+	t.Helper()
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(2), WithOverflowPolicy(policy))
+	if err := bus.RemoveConveyor(1); err != nil {
+		t.Fatalf("RemoveConveyor: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := bus.Produce(Event[int]{ID: i}); err != nil {
+			t.Fatalf("Produce: %v", err)
+		}
+	}
+	return bus
+}
+
+// buffered closes the bus and returns the IDs left on line 0
+func buffered(bus *MainBus[int]) []int {
+	bus.Close()
+	var ids []int
+	for ev := range bus.Conveyors[0] {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func TestOverflowBlock(t *testing.T) {
+	bus := fullBus(t, OverflowBlock)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.ProduceContext(ctx, Event[int]{ID: 2}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ProduceContext on a full conveyor = %v, want DeadlineExceeded", err)
+	}
+	if d := bus.Metrics().Dropped[0]; d != 0 {
+		t.Fatalf("dropped = %d, want 0", d)
+	}
+	if ids := buffered(bus); len(ids) != 2 || ids[0] != 0 || ids[1] != 1 {
+		t.Fatalf("buffered %v, want [0 1]", ids)
+	}
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	bus := fullBus(t, OverflowDropNewest)
+	if err := bus.Produce(Event[int]{ID: 2}); !errors.Is(err, ErrEventDropped) {
+		t.Fatalf("Produce on a full conveyor = %v, want ErrEventDropped", err)
+	}
+	if d := bus.Metrics().Dropped[0]; d != 1 {
+		t.Fatalf("dropped = %d, want 1", d)
+	}
+	if ids := buffered(bus); len(ids) != 2 || ids[0] != 0 || ids[1] != 1 {
+		t.Fatalf("buffered %v, want [0 1]", ids)
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	bus := fullBus(t, OverflowDropOldest)
+	if err := bus.Produce(Event[int]{ID: 2}); err != nil {
+		t.Fatalf("Produce: %v", err)
+	}
+	if d := bus.Metrics().Dropped[0]; d != 1 {
+		t.Fatalf("dropped = %d, want 1", d)
+	}
+	if ids := buffered(bus); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("buffered %v, want [1 2]", ids)
+	}
+}
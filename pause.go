@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// lineGate lets a conveyor's consumers be held without closing the conveyor
+type lineGate struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	paused bool
+}
+
+// Pause halts delivery on a conveyor: its consumers block before taking the next event, while
+// producers may keep filling the buffer up to capacity. Nothing is dropped.
+func (bus *MainBus[T]) Pause(line int) {
+	g := &bus.belt(line).gate
+	g.mu.Lock()
+	g.paused = true
+	g.mu.Unlock()
+}
+
+// Resume restarts delivery on a paused conveyor, letting its consumers catch up
+func (bus *MainBus[T]) Resume(line int) {
+	g := &bus.belt(line).gate
+	g.mu.Lock()
+	g.paused = false
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+// Paused reports whether a conveyor is currently paused
+func (bus *MainBus[T]) Paused(line int) bool {
+	g := &bus.belt(line).gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// waitResumed blocks while the conveyor is paused. It returns false if ctx is done first.
+func (bus *MainBus[T]) waitResumed(ctx context.Context, line int) bool {
+	g := &bus.belt(line).gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		stop := context.AfterFunc(ctx, func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.cond.Broadcast()
+		})
+		defer stop()
+		for g.paused && ctx.Err() == nil {
+			g.cond.Wait()
+		}
+	}
+	return ctx.Err() == nil
+}
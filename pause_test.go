@@ -0,0 +1,42 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	var handled atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(Event[int]) { handled.Add(1) })
+
+	bus.Pause(0)
+	if !bus.Paused(0) {
+		t.Fatal("line 0 not reported paused")
+	}
+	for i := 0; i < 16; i++ {
+		bus.Produce(Event[int]{ID: i}) // 8 per line fills both buffers without blocking
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := handled.Load(); n != 0 {
+		t.Fatalf("handled %d events while paused", n)
+	}
+	if d := bus.Depth(0); d < 7 { // one event may be held by the paused consumer
+		t.Fatalf("depth = %d while paused, want the buffer to fill", d)
+	}
+
+	bus.Resume(0)
+	deadline := time.Now().Add(time.Second)
+	for handled.Load() < 8 {
+		if time.Now().After(deadline) {
+			t.Fatalf("handled %d of 8 events after resume", handled.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	bus.Close()
+	wg.Wait()
+}
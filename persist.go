@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// eventLog appends produced events to a file, one encoded event per line
+type eventLog struct {
+	mu       sync.Mutex
+	f        *os.File
+	syncEach bool          // fsync after every write rather than periodically
+	stop     chan struct{} // stops the periodic fsync goroutine
+	done     chan struct{}
+}
+
+// logHeaderPrefix starts the header line naming the format of a log that is not plain JSON
+const logHeaderPrefix = "#mainbus "
+
+// logHeader returns the header line of a log written with codec c and compression comp,
+// encrypted if encrypted is set. Plain JSON logs have none, so they stay ordinary
+// newline-delimited JSON.
+func logHeader[T any](c Codec[T], comp Compression, encrypted bool) string {
+	if textCodec(c) && comp == CompressionNone && !encrypted {
+		return ""
+	}
+	header := fmt.Sprintf("%scodec=%s compression=%s", logHeaderPrefix, codecName(c), comp)
+	if encrypted {
+		header += " encryption=" + encryptionScheme
+	}
+	return header
+}
+
+// parseLogHeader splits a header line into its codec name, compression and whether the records
+// are encrypted
+func parseLogHeader(line string) (codec string, comp Compression, encrypted bool, err error) {
+	fields := make(map[string]string)
+	for _, f := range strings.Fields(strings.TrimPrefix(line, logHeaderPrefix)) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			return "", 0, false, fmt.Errorf("malformed log header %q", line)
+		}
+		fields[k] = v
+	}
+	codec, ok := fields["codec"]
+	if !ok {
+		return "", 0, false, fmt.Errorf("malformed log header %q", line)
+	}
+	if comp, err = parseCompression(fields["compression"]); err != nil {
+		return "", 0, false, err
+	}
+	switch scheme := fields["encryption"]; scheme {
+	case "":
+	case encryptionScheme:
+		encrypted = true
+	default:
+		return "", 0, false, fmt.Errorf("unknown encryption %q", scheme)
+	}
+	return codec, comp, encrypted, nil
+}
+
+// readLogHeader returns the header line of the log at path, "" if it has none, and whether the
+// file is empty or missing
+func readLogHeader(path string) (header string, empty bool, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", true, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	start, err := r.Peek(len(logHeaderPrefix))
+	if len(start) == 0 {
+		return "", true, nil
+	}
+	if err != nil || string(start) != logHeaderPrefix {
+		return "", false, nil
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+	return strings.TrimSuffix(line, "\n"), false, nil
+}
+
+// openEventLog opens path for appending, writing header first to a new log. A log already
+// written in another format is refused with an error wrapping ErrInvalidConfig. A zero interval
+// fsyncs after every write; otherwise the file is fsynced every interval in the background.
+func openEventLog(path string, interval time.Duration, header string) (*eventLog, error) {
+	existing, empty, err := readLogHeader(path)
+	if err != nil {
+		return nil, err
+	}
+	if !empty && existing != header {
+		describe := func(h string) string {
+			if h == "" {
+				return "plain JSON"
+			}
+			return strings.TrimPrefix(h, logHeaderPrefix)
+		}
+		return nil, fmt.Errorf("%w: log %s holds %s, bus writes %s", ErrInvalidConfig, path, describe(existing), describe(header))
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if empty && header != "" {
+		if _, err := f.WriteString(header + "\n"); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	l := &eventLog{f: f, syncEach: interval <= 0, stop: make(chan struct{}), done: make(chan struct{})}
+	if l.syncEach {
+		close(l.done)
+		return l, nil
+	}
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.mu.Lock()
+				l.f.Sync()
+				l.mu.Unlock()
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+	return l, nil
+}
+
+// append writes one record
+func (l *eventLog) append(record []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(record, '\n')); err != nil {
+		return err
+	}
+	if l.syncEach {
+		return l.f.Sync()
+	}
+	return nil
+}
+
+// close stops background syncing, syncs a final time and closes the file
+func (l *eventLog) close() error {
+	if !l.syncEach {
+		close(l.stop)
+	}
+	<-l.done
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Sync(); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// persist appends an enqueued event to the bus log, if persistence is enabled. Write failures
+// cannot be reported to the producer, whose event is already on a conveyor, so they are logged.
+func (bus *MainBus[T]) persist(ev Event[T]) {
+	if bus.journal == nil {
+		return
+	}
+	record, err := bus.encodeLine(ev)
+	if err == nil {
+		err = bus.journal.append(record)
+	}
+	if err != nil {
+		bus.logEvent(slog.LevelError, "persisting event failed", -1, ev, slog.Any("error", err))
+	}
+}
+
+// ReplayFile reads an event log written WithPersistence and produces every event in it onto
+// bus, in file order, to rebuild state after a restart. Replaying onto a bus that persists to
+// the same file appends the events again, after the ones being replayed; the bus Checkpoints,
+// restored from that log, let its consumers skip the events they handled before the restart. Events dropped by the overflow policy are skipped. The
+// log header tells how the log was compressed and whether it was encrypted, whatever the
+// settings of bus, but bus must use the codec the log was written with and, for an encrypted log,
+// the same WithEncryption key; a wrong key fails with ErrDecrypt.
+func ReplayFile[T any](path string, bus *MainBus[T]) error {
+	return replayFile(path, bus, nil)
+}
+
+// ReplayRange is ReplayFile re-producing only the events stamped within [from, to) for which
+// filter returns true, to replay the slice of history around a past incident. A zero from or to
+// leaves that end of the window open, and a nil filter keeps every event in it. Each event is
+// checked on its own, so logs that are not in time order, such as ones appended by several
+// processes or by an earlier replay, are read to the end and every matching event is replayed
+// in file order.
+func ReplayRange[T any](path string, bus *MainBus[T], from, to time.Time, filter func(Event[T]) bool) error {
+	return replayFile(path, bus, func(ev Event[T]) bool {
+		switch {
+		case !from.IsZero() && ev.Time.Before(from):
+			return false
+		case !to.IsZero() && !ev.Time.Before(to):
+			return false
+		}
+		return filter == nil || filter(ev)
+	})
+}
+
+// replayFile is ReplayFile producing only the events keep returns true for, or all of them if
+// keep is nil
+func replayFile[T any](path string, bus *MainBus[T], keep func(Event[T]) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// stop at the current end, so events re-logged by the replay itself are not read back
+	scanner := bufio.NewScanner(io.LimitReader(f, fi.Size()))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	comp, encrypted := CompressionNone, false
+	for n := 1; scanner.Scan(); n++ {
+		if n == 1 && bytes.HasPrefix(scanner.Bytes(), []byte(logHeaderPrefix)) {
+			codec, c, enc, err := parseLogHeader(scanner.Text())
+			if err != nil {
+				return fmt.Errorf("main bus: %s: %w", path, err)
+			}
+			if codec != codecName(bus.codec) {
+				return fmt.Errorf("main bus: %s: %w: log uses codec %s, bus uses %s", path, ErrInvalidConfig, codec, codecName(bus.codec))
+			}
+			if enc && bus.aead == nil {
+				return fmt.Errorf("main bus: %s: %w: log is encrypted and bus has no key", path, ErrInvalidConfig)
+			}
+			comp, encrypted = c, enc
+			continue
+		}
+		ev, err := bus.decodeLine(comp, encrypted, scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("main bus: %s line %d: %w", path, n, err)
+		}
+		if keep != nil && !keep(ev) {
+			continue
+		}
+		if err := bus.Produce(ev); err != nil && !errors.Is(err, ErrEventDropped) {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Replay produces events onto bus while reproducing the gaps between their Time fields, scaled
+// by speed: 2.0 replays twice as fast, 1.0 in real time, and 0 (or less) as fast as possible.
+// Timestamps that go backwards are treated as a zero gap. Events dropped by the overflow policy
+// are skipped. Replay stops with ctx.Err() when ctx is cancelled.
+func Replay[T any](ctx context.Context, events []Event[T], bus *MainBus[T], speed float64) error {
+	for i, ev := range events {
+		if i > 0 && speed > 0 {
+			gap := ev.Time.Sub(events[i-1].Time)
+			if gap > 0 {
+				t := time.NewTimer(time.Duration(float64(gap) / speed))
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+		if err := bus.ProduceContext(ctx, ev); err != nil && !errors.Is(err, ErrEventDropped) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countLines returns the number of lines in the file at path
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	n := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); n++ {
+	}
+	return n
+}
+
+func TestPersistenceLogsEveryAcceptedEventDespiteClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	bus := NewMainBus[int]("iron", WithBuffer(1024), WithPersistence(path), WithFsyncInterval(time.Hour))
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if bus.Produce(Event[int]{ID: i}) == nil {
+					accepted.Add(1)
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	bus.Close()
+	wg.Wait()
+
+	if n := countLines(t, path); n != int(accepted.Load()) {
+		t.Fatalf("log holds %d events, want the %d accepted ones", n, accepted.Load())
+	}
+}
+
+func TestReplayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	bus := NewMainBus[string]("iron", WithPersistence(path))
+	for i := 0; i < 5; i++ {
+		bus.Produce(Event[string]{ID: i, Value: "plate"})
+	}
+	bus.Close()
+
+	restored := NewMainBus[string]("iron")
+	if err := ReplayFile(path, restored); err != nil {
+		t.Fatalf("ReplayFile: %v", err)
+	}
+	if d := restored.TotalDepth(); d != 5 {
+		t.Fatalf("replayed %d events, want 5", d)
+	}
+}
+
+func TestReplayRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	bus := NewMainBus[string]("iron", WithPersistence(path), WithBuffer(16))
+	// minutes 0 to 9, written out of time order
+	for _, min := range []int{3, 0, 7, 5, 1, 9, 4, 8, 2, 6} {
+		value := "plate"
+		if min%2 == 1 {
+			value = "gear"
+		}
+		bus.Produce(Event[string]{ID: min + 1, Value: value, Time: epoch.Add(time.Duration(min) * time.Minute)})
+	}
+	bus.Close()
+
+	restored := NewMainBus[string]("iron", WithLines(1), WithBuffer(16))
+	restored.RemoveConveyor(1)
+	from, to := epoch.Add(2*time.Minute), epoch.Add(7*time.Minute)
+	plates := func(ev Event[string]) bool { return ev.Value == "plate" }
+	if err := ReplayRange(path, restored, from, to, plates); err != nil {
+		t.Fatalf("ReplayRange: %v", err)
+	}
+	var got []int
+	for _, ev := range restored.DrainAll() {
+		got = append(got, int(ev.Time.Sub(epoch)/time.Minute))
+	}
+	// the even minutes in [2, 7), in file order
+	if want := []int{4, 2, 6}; !slices.Equal(got, want) {
+		t.Fatalf("replayed minutes %v, want %v", got, want)
+	}
+
+	if err := ReplayRange(path, restored, time.Time{}, epoch.Add(2*time.Minute), nil); err != nil {
+		t.Fatalf("ReplayRange with an open start: %v", err)
+	}
+	if d := restored.TotalDepth(); d != 2 {
+		t.Fatalf("replayed %d events before minute 2, want 2", d)
+	}
+}
+
+// benchProduce measures Produce on a bus drained by one consumer per conveyor
+func benchProduce(b *testing.B, opts ...Option) {
+	bus := NewMainBus[int]("iron", append([]Option{WithBuffer(256)}, opts...)...)
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bus.Produce(Event[int]{ID: i, Value: i})
+	}
+	b.StopTimer()
+	bus.Close()
+	wg.Wait()
+}
+
+func BenchmarkProducePersistenceOff(b *testing.B) {
+	benchProduce(b)
+}
+
+func BenchmarkProducePersistenceFsyncEach(b *testing.B) {
+	benchProduce(b, WithPersistence(filepath.Join(b.TempDir(), "events.log")))
+}
+
+func BenchmarkProducePersistenceFsyncPeriodic(b *testing.B) {
+	benchProduce(b, WithPersistence(filepath.Join(b.TempDir(), "events.log")), WithFsyncInterval(100*time.Millisecond))
+}
+
+func TestPersistenceOpenFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "events.log")
+	if _, err := NewMainBusChecked[int]("iron", WithPersistence(path)); err == nil {
+		t.Fatal("NewMainBusChecked accepted an unopenable log")
+	}
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	bus := NewMainBus[int]("iron", WithPersistence(path))
+	defer bus.Close()
+	if !strings.Contains(buf.String(), "persistence disabled") {
+		t.Fatalf("no warning logged by default, got %q", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// Stage is a running processing step between buses
+type Stage struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	credits func() int // nil without flow control
+}
+
+// Stop cancels the stage and waits for its goroutines to exit. Events still buffered on the
+// source bus are left there.
+func (s *Stage) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// Done is closed once the stage has finished, either because its source was drained and closed
+// or because Stop was called
+func (s *Stage) Done() <-chan struct{} {
+	return s.done
+}
+
+// Credits returns the credits the destination of a PipeWithCredits stage currently grants, that
+// is how many more events the stage may forward before parking. It is 0 for other stages.
+func (s *Stage) Credits() int {
+	if s.credits == nil {
+		return 0
+	}
+	return s.credits()
+}
+
+// Pipe consumes every conveyor of src, applies transform and produces the results onto dst,
+// like an assembler turning iron plates into gears on the next bus. Events for which transform
+// returns false are filtered out. The stage finishes on its own once src is closed and drained.
+// If dst is closed the stage stops instead, leaving the remaining events on src; the event that
+// could not be delivered is logged to dst's logger.
+func Pipe[A, B any](src *MainBus[A], dst *MainBus[B], transform func(Event[A]) (Event[B], bool)) *Stage {
+	return pipeRoute(src, func(ev Event[A]) (*MainBus[B], Event[B], bool) {
+		out, ok := transform(ev)
+		return dst, out, ok
+	})
+}
+
+// pipeRoute is Pipe with route choosing the destination of each event as well as transforming it
+func pipeRoute[A, B any](src *MainBus[A], route func(Event[A]) (*MainBus[B], Event[B], bool)) *Stage {
+	return pipeWith(src, route, nil)
+}
+
+// pipeWith is pipeRoute taking a credit from gate, unless it is nil, before each send
+func pipeWith[A, B any](src *MainBus[A], route func(Event[A]) (*MainBus[B], Event[B], bool), gate *creditGate[B]) *Stage {
+	ctx, cancel := context.WithCancel(context.Background())
+	stage := &Stage{cancel: cancel, done: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	for line := range src.table().belts {
+		wg.Add(1)
+		go src.ConsumeContext(ctx, line, &wg, func(ev Event[A]) {
+			dst, out, ok := route(ev)
+			if !ok {
+				return
+			}
+			var err error
+			if gate != nil {
+				if err = gate.acquire(ctx); err == nil {
+					err = dst.ProduceContext(ctx, out)
+					gate.release()
+				}
+			} else {
+				err = dst.ProduceContext(ctx, out)
+			}
+			if errors.Is(err, ErrBusClosed) {
+				dst.logEvent(slog.LevelWarn, "event dropped", -1, out, slog.String("reason", "pipe destination closed"))
+				dropped(out, "pipe destination closed")
+				cancel()
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(stage.done)
+	}()
+	return stage
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPipeChain(t *testing.T) {
+	ore := NewMainBus[int]("ore")
+	plates := NewMainBus[int]("plates")
+	gears := NewMainBus[string]("gears", WithBuffer(64))
+
+	// smelt every ore, then turn only the even plates into gears
+	smelt := Pipe(ore, plates, func(ev Event[int]) (Event[int], bool) {
+		ev.Value *= 10
+		return ev, true
+	})
+	assemble := Pipe(plates, gears, func(ev Event[int]) (Event[string], bool) {
+		return Event[string]{ID: ev.ID, Value: "gear-" + strconv.Itoa(ev.Value)}, ev.ID%2 == 0
+	})
+
+	for i := 0; i < 10; i++ {
+		ore.Produce(Event[int]{ID: i, Value: i})
+	}
+	ore.Close()
+	<-smelt.Done()
+	plates.Close()
+	<-assemble.Done()
+	gears.Close()
+
+	var got []string
+	for _, c := range gears.Conveyors {
+		for ev := range c {
+			got = append(got, ev.Value)
+		}
+	}
+	sort.Strings(got)
+	want := []string{"gear-0", "gear-20", "gear-40", "gear-60", "gear-80"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPipeStopsWhenDestinationCloses(t *testing.T) {
+	src := NewMainBus[int]("src", WithBuffer(8))
+	dst := NewMainBus[int]("dst")
+	dst.Close()
+	stage := Pipe(src, dst, func(ev Event[int]) (Event[int], bool) { return ev, true })
+	for i := 0; i < 4; i++ {
+		src.Produce(Event[int]{ID: i})
+	}
+	select {
+	case <-stage.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stage kept running after its destination closed")
+	}
+	if d := src.TotalDepth(); d < 2 {
+		t.Fatalf("source depth = %d, want at most one event taken per line", d)
+	}
+}
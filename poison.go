@@ -0,0 +1,25 @@
+package main
+
+import "context"
+
+// Poison stops the consumers of the bus without closing it, so it can still be produced to and
+// new consumers attached later. It sends a pill, an internal event, to every live conveyor,
+// waiting for room as Broadcast does; the pill queues behind the events already buffered, so a
+// consumer handles those first and returns, as on a closed conveyor, once it takes the pill.
+// Each pill stops one consumer: call Poison once per consumer on a conveyor to stop them all.
+// ConsumeWithIdleTimeout returns StopPoisoned, and BusReader io.EOF. Pills never reach handlers,
+// Snapshot, DrainAll, Inspect or a Merger; consumers reading several conveyors at once, ConsumeAll
+// and ConsumeMergedOrdered, do not stop for them but swallow them, while code reading Conveyors
+// or a Receiver directly gets them as events with no ID. A closed bus returns ErrBusClosed.
+func (bus *MainBus[T]) Poison() error {
+	return bus.broadcast(context.Background(), Event[T]{Resource: bus.Resource, poison: true}, nil)
+}
+
+// poisoned reports whether ev is a pill, counting it as consumed from line if so
+func (bus *MainBus[T]) poisoned(line int, ev Event[T]) bool {
+	if !ev.poison {
+		return false
+	}
+	bus.onConsumed(line)
+	return true
+}
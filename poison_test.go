@@ -0,0 +1,93 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor fails t unless wg is done within a second
+func waitFor(t *testing.T, wg *sync.WaitGroup, what string) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestPoisonStopsConsumersAndKeepsBusOpen(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	var handled atomic.Int64
+	handler := func(ev Event[int]) {
+		if ev.ID == 0 {
+			t.Errorf("handler got the pill %+v", ev)
+		}
+		handled.Add(1)
+	}
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, handler)
+	}
+	for id := 1; id <= 4; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	if err := bus.Poison(); err != nil {
+		t.Fatalf("Poison: %v", err)
+	}
+	waitFor(t, &wg, "poisoned consumers")
+	if n := handled.Load(); n != 4 {
+		t.Fatalf("handled %d events before stopping, want the 4 queued ahead of the pill", n)
+	}
+	if n := len(bus.ListConsumers()); n != 0 {
+		t.Fatalf("%d consumers still attached", n)
+	}
+
+	// the bus is still open: produce more and attach new consumers
+	for id := 5; id <= 8; id++ {
+		if err := bus.Produce(Event[int]{ID: id}); err != nil {
+			t.Fatalf("Produce after Poison: %v", err)
+		}
+	}
+	var idle, returned sync.WaitGroup
+	reasons := make([]StopReason, len(bus.Conveyors))
+	for line := range bus.Conveyors {
+		idle.Add(1)
+		returned.Add(1)
+		go func() {
+			defer returned.Done()
+			reasons[line] = bus.ConsumeWithIdleTimeout(line, &idle, handler, time.Minute)
+		}()
+	}
+	bus.Poison()
+	waitFor(t, &returned, "re-attached consumers")
+	if n := handled.Load(); n != 8 {
+		t.Fatalf("handled %d events in all, want 8", n)
+	}
+	for line, r := range reasons {
+		if r != StopPoisoned {
+			t.Fatalf("consumer of conveyor %d stopped with %v, want %v", line, r, StopPoisoned)
+		}
+	}
+	if m := bus.Metrics(); m.Produced[0] != m.Consumed[0] || bus.TotalDepth() != 0 {
+		t.Fatalf("produced %v, consumed %v with %d buffered: pills unaccounted", m.Produced, m.Consumed, bus.TotalDepth())
+	}
+}
+
+func TestPoisonSkippedByDrainAll(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8))
+	defer bus.Close()
+	bus.Produce(Event[int]{ID: 1})
+	bus.Poison()
+	if evs := bus.DrainAll(); len(evs) != 1 || evs[0].ID != 1 {
+		t.Fatalf("DrainAll = %+v, want only event 1", evs)
+	}
+}
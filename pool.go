@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ConsumePool consumes a specific conveyor with several worker goroutines ranging over it, so a
+// slow handler can run in parallel. Like ConsumeWith it marks wg done once, after every worker
+// has exited. Events on the conveyor are no longer handled in order: workers race for the next
+// event and may finish out of sequence.
+func (bus *MainBus[T]) ConsumePool(line int, workers int, wg *sync.WaitGroup, handler func(Event[T])) {
+	defer wg.Done()
+	if workers < 1 {
+		workers = 1
+	}
+	var pool sync.WaitGroup
+	pool.Add(workers)
+	for i := 0; i < workers; i++ {
+		go bus.ConsumeWith(line, &pool, handler)
+	}
+	pool.Wait()
+}
+
+// ConsumeBounded consumes a specific conveyor from one goroutine that hands each event to its
+// own handler goroutine, with at most maxInflight (at least 1) handlers running at once. It waits
+// for a free slot before taking the next event off the conveyor, so handlers start in conveyor
+// order and a saturated consumer leaves events buffered, but they may finish in any order. Once
+// the conveyor is closed and drained it waits for the handlers still running before marking wg
+// done.
+func (bus *MainBus[T]) ConsumeBounded(line int, wg *sync.WaitGroup, handler func(Event[T]), maxInflight int) {
+	defer wg.Done()
+	self := bus.attach(line)
+	defer self.detach()
+	slots := make(chan struct{}, max(maxInflight, 1))
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+	c := bus.conveyor(line)
+	for bus.waitResumed(context.Background(), line) {
+		slots <- struct{}{}
+		ev, ok := <-c
+		if !ok && bus.reopened(line, &c) {
+			<-slots
+			continue
+		}
+		if !ok || bus.poisoned(line, ev) {
+			return
+		}
+		if ev.barrier != nil {
+			inflight.Wait() // the barrier is reached once the events before it are handled
+		}
+		// the conveyor may have been paused while this receive was waiting
+		bus.waitResumed(context.Background(), line)
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			defer func() { <-slots }()
+			bus.deliver(line, ev, handler)
+			self.done()
+		}()
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsumeBoundedLimitsConcurrency(t *testing.T) {
+	const limit, n = 3, 40
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(n), WithStrategy(StrategyRoundRobin))
+	for i := range n {
+		bus.Produce(Event[int]{ID: i + 1})
+	}
+	var running, peak, handled atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(2)
+	handler := func(Event[int]) {
+		now := running.Add(1)
+		for p := peak.Load(); now > p && !peak.CompareAndSwap(p, now); p = peak.Load() {
+		}
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		handled.Add(1)
+	}
+	go bus.ConsumeBounded(0, &wg, handler, limit)
+	go bus.ConsumeBounded(1, &wg, func(Event[int]) { handled.Add(1) }, 0)
+	bus.Close()
+	wg.Wait()
+
+	if got := handled.Load(); got != n {
+		t.Errorf("handled %d events before wg was done, want %d", got, n)
+	}
+	if p := peak.Load(); p > limit {
+		t.Errorf("%d handlers ran at once, limit %d", p, limit)
+	} else if p < limit {
+		t.Errorf("peak concurrency %d, want the limit %d to be used", p, limit)
+	}
+	if consumed := consumedTotal(bus); consumed != n {
+		t.Errorf("consumed %d, want %d", consumed, n)
+	}
+}
+
+func TestConsumeBoundedStartsInOrder(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(16), WithStrategy(StrategyRoundRobin))
+	for i := range 16 {
+		bus.Produce(Event[int]{ID: i + 1})
+	}
+	bus.Close()
+	var mu sync.Mutex
+	var started []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeBounded(0, &wg, func(ev Event[int]) {
+		mu.Lock()
+		started = append(started, ev.ID)
+		mu.Unlock()
+	}, 1)
+	if len(started) != 8 {
+		t.Fatalf("started %d handlers, want 8", len(started))
+	}
+	for i, id := range started {
+		if id != 2*i+1 {
+			t.Fatalf("started %v, want the odd IDs in order", started)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPrefillOverflow is wrapped by the errors Prefill and PrefillRoundRobin return when the
+// events do not fit in the conveyors
+var ErrPrefillOverflow = errors.New("main bus: prefill exceeds conveyor capacity")
+
+// Prefill enqueues events across the live conveyors with the bus strategy, in slice order and
+// without ever blocking, to give tests and load simulations a known initial buffer state before
+// consumers start. Like Restore, the events skip middleware and the rate limit but are written
+// to the persistence log. If the events are more than the free room across the bus, nothing is
+// enqueued; otherwise they are placed one at a time, and a strategy sending one to a full
+// conveyor stops the prefill there. Both return an error wrapping ErrPrefillOverflow that says
+// how many were placed. A closed bus returns ErrBusClosed.
+func (bus *MainBus[T]) Prefill(events []Event[T]) error {
+	if bus.isClosed() {
+		return ErrBusClosed
+	}
+	t := bus.table()
+	free := 0
+	for _, line := range t.live {
+		b := t.belts[line]
+		free += b.capacity() - b.depth()
+	}
+	if len(events) > free {
+		return fmt.Errorf("main bus %q: %d events for %d free slots: %w", bus.Resource, len(events), free, ErrPrefillOverflow)
+	}
+	for i, ev := range events {
+		line, _ := bus.selectLine(t, ev)
+		if !bus.offer(line, t.belts[line], ev, true) {
+			return bus.prefillFailed(i, len(events), line)
+		}
+		bus.occupy(1)
+	}
+	return nil
+}
+
+// PrefillRoundRobin is Prefill spreading the events evenly over the live conveyors whatever the
+// bus strategy: event i goes to the i-th live conveyor, wrapping around. Each conveyor's share is
+// checked against its free room first, so nothing is enqueued if any would overflow.
+func (bus *MainBus[T]) PrefillRoundRobin(events []Event[T]) error {
+	if bus.isClosed() {
+		return ErrBusClosed
+	}
+	t := bus.table()
+	n := len(t.live)
+	if n == 0 {
+		if len(events) == 0 {
+			return nil
+		}
+		return fmt.Errorf("main bus %q: no live conveyor for %d events: %w", bus.Resource, len(events), ErrPrefillOverflow)
+	}
+	for i, line := range t.live {
+		b := t.belts[line]
+		share := len(events) / n
+		if i < len(events)%n {
+			share++
+		}
+		if free := b.capacity() - b.depth(); share > free {
+			return fmt.Errorf("main bus %q: %d events for conveyor %d with %d free slots: %w", bus.Resource, share, line, free, ErrPrefillOverflow)
+		}
+	}
+	for i, ev := range events {
+		line := t.live[i%n]
+		if !bus.offer(line, t.belts[line], ev, true) {
+			return bus.prefillFailed(i, len(events), line)
+		}
+		bus.occupy(1)
+	}
+	return nil
+}
+
+// prefillFailed reports a prefill stopped after placed of total events because line was full,
+// closed or held by Snapshot
+func (bus *MainBus[T]) prefillFailed(placed, total, line int) error {
+	if bus.isClosed() {
+		return fmt.Errorf("main bus %q: prefilled %d of %d events: %w", bus.Resource, placed, total, ErrBusClosed)
+	}
+	return fmt.Errorf("main bus %q: prefilled %d of %d events, conveyor %d took no more: %w", bus.Resource, placed, total, line, ErrPrefillOverflow)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func prefillEvents(n int) []Event[int] {
+	evs := make([]Event[int], n)
+	for i := range evs {
+		evs[i] = Event[int]{ID: i + 1}
+	}
+	return evs
+}
+
+func TestPrefillRoundRobinDepths(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(5), WithStrategy(StrategyLeastLoaded))
+	defer bus.Close()
+	if err := bus.PrefillRoundRobin(prefillEvents(10)); err != nil {
+		t.Fatal(err)
+	}
+	for line, want := range []int{3, 3, 2, 2} {
+		if got := bus.Depth(line); got != want {
+			t.Errorf("conveyor %d holds %d events, want %d", line, got, want)
+		}
+	}
+	// conveyor 0 is the first to take an event, so it holds IDs 1, 5 and 9 in order
+	evs := bus.Inspect(0)
+	if len(evs) != 3 || evs[0].ID != 1 || evs[1].ID != 5 || evs[2].ID != 9 {
+		t.Errorf("conveyor 0 holds %v, want IDs 1, 5, 9", evs)
+	}
+}
+
+func TestPrefillRoundRobinOverflow(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2))
+	defer bus.Close()
+	bus.PrefillRoundRobin(prefillEvents(1)) // conveyor 0 is left with one free slot
+	if err := bus.PrefillRoundRobin(prefillEvents(3)); !errors.Is(err, ErrPrefillOverflow) {
+		t.Fatalf("PrefillRoundRobin: %v, want ErrPrefillOverflow", err)
+	}
+	if d := bus.TotalDepth(); d != 1 {
+		t.Errorf("overflowing prefill left %d events buffered, want only the earlier one", d)
+	}
+}
+
+func TestPrefillStrategy(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	if err := bus.Prefill(prefillEvents(7)); err != nil {
+		t.Fatal(err)
+	}
+	if bus.Depth(0) != 4 || bus.Depth(1) != 3 {
+		t.Fatalf("depths %d and %d, want 4 and 3", bus.Depth(0), bus.Depth(1))
+	}
+	if err := bus.Prefill(prefillEvents(2)); !errors.Is(err, ErrPrefillOverflow) {
+		t.Fatalf("Prefill past the free room: %v, want ErrPrefillOverflow", err)
+	}
+	if d := bus.TotalDepth(); d != 7 {
+		t.Errorf("overflowing prefill changed the depth to %d", d)
+	}
+}
+
+func TestPrefillStopsAtFullConveyor(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(3), WithStrategy(StrategyHashKey), WithKeyFunc(func(Event[int]) string { return "same" }))
+	defer bus.Close()
+	// every event hashes to one conveyor, which fills after three
+	err := bus.Prefill(prefillEvents(5))
+	if !errors.Is(err, ErrPrefillOverflow) {
+		t.Fatalf("Prefill: %v, want ErrPrefillOverflow", err)
+	}
+	if d := bus.TotalDepth(); d != 3 {
+		t.Errorf("prefill placed %d events, want 3", d)
+	}
+}
+
+func TestPrefillClosed(t *testing.T) {
+	bus := NewMainBus[int]("iron")
+	bus.Close()
+	if err := bus.Prefill(prefillEvents(1)); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Prefill: %v, want ErrBusClosed", err)
+	}
+	if err := bus.PrefillRoundRobin(prefillEvents(1)); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("PrefillRoundRobin: %v, want ErrBusClosed", err)
+	}
+}
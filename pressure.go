@@ -0,0 +1,41 @@
+package main
+
+// Pressure returns a channel that receives a signal whenever a conveyor fills past the high
+// watermark set WithWatermarks. Signals are sent without blocking and coalesce while unread.
+func (bus *MainBus[T]) Pressure() <-chan struct{} {
+	return bus.pressure
+}
+
+// Relieved returns a channel that receives a signal whenever a conveyor that crossed the high
+// watermark drains back down to the low watermark
+func (bus *MainBus[T]) Relieved() <-chan struct{} {
+	return bus.relieved
+}
+
+// checkPressure compares a conveyor's fill level against the watermarks and emits a signal when
+// it crosses into or out of the pressured state
+func (bus *MainBus[T]) checkPressure(line int) {
+	if bus.highWater <= 0 {
+		return
+	}
+	b := bus.belt(line)
+	if b.capacity() == 0 {
+		return
+	}
+	fill := float64(b.depth()) / float64(b.capacity())
+	st := &b.stats
+	switch {
+	case fill >= bus.highWater && st.pressured.CompareAndSwap(false, true):
+		signal(bus.pressure)
+	case fill <= bus.lowWater && st.pressured.CompareAndSwap(true, false):
+		signal(bus.relieved)
+	}
+}
+
+// signal performs a non-blocking send on a notification channel
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+)
+
+// prioritizedLine is one conveyor read by ConsumePrioritized
+type prioritizedLine[T any] struct {
+	bus   *MainBus[T]
+	level int // index of bus in the priority order
+	line  int
+	self  *consumer
+}
+
+// ConsumePrioritized consumes every conveyor of the given buses from a single goroutine with
+// strict priority: each event is taken from the first bus in order with one ready, so copper is
+// only handled while iron has nothing buffered, and the goroutine blocks only once every bus is
+// empty. The conveyors of one bus share its priority and are checked in line order. handler is
+// told which bus the event came from. A bus that is never empty starves the ones after it; with
+// maxSkip above zero, once maxSkip events in a row have come from the same bus the next event is
+// taken from the buses after it in order, when one has any, before priority applies again. It
+// returns once all conveyors of all buses are closed and drained, and like ConsumeAll it is not
+// held back by Pause.
+func ConsumePrioritized[T any](wg *sync.WaitGroup, handler func(src *MainBus[T], ev Event[T]), order []*MainBus[T], maxSkip int) {
+	defer wg.Done()
+	var lines []prioritizedLine[T]
+	var cases []reflect.SelectCase
+	for level, bus := range order {
+		for line, b := range bus.table().belts {
+			self := bus.attach(line)
+			defer self.detach()
+			lines = append(lines, prioritizedLine[T]{bus, level, line, self})
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(b.out())})
+		}
+	}
+	deliver := func(i int, v reflect.Value) {
+		l := lines[i]
+		l.bus.deliver(l.line, v.Interface().(Event[T]), func(ev Event[T]) { handler(l.bus, ev) })
+		l.self.done()
+	}
+	// closed reports whether a receive that was not ok found conveyor i closed for good
+	closed := func(i int) bool {
+		if lines[i].bus.reopenedCase(lines[i].line, &cases[i]) {
+			return false
+		}
+		cases[i].Chan = reflect.Value{} // a zero Chan is never received from again
+		return true
+	}
+	last, run := -1, 0 // the level events last came from and how many in a row
+	for open := len(cases); open > 0; {
+		start := 0
+		if maxSkip > 0 && run >= maxSkip {
+			start = prioritizedStart(lines, last+1)
+		}
+		i, v := -1, reflect.Value{}
+		for n := range len(cases) {
+			j := (start + n) % len(cases)
+			if !cases[j].Chan.IsValid() {
+				continue
+			}
+			got, ok := cases[j].Chan.TryRecv()
+			if ok {
+				i, v = j, got
+				break
+			}
+			if got.IsValid() && closed(j) {
+				open--
+			}
+		}
+		if i < 0 {
+			if open == 0 {
+				return
+			}
+			var ok bool
+			if i, v, ok = reflect.Select(cases); !ok {
+				if closed(i) {
+					open--
+				}
+				continue
+			}
+		}
+		if level := lines[i].level; level == last {
+			run++
+		} else {
+			last, run = level, 1
+		}
+		deliver(i, v)
+	}
+}
+
+// prioritizedStart returns the index of the first conveyor of priority level start, wrapping
+// past the last level back to the first
+func prioritizedStart[T any](lines []prioritizedLine[T], start int) int {
+	for i, l := range lines {
+		if l.level >= start {
+			return i
+		}
+	}
+	return 0
+}
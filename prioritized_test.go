@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConsumePrioritizedDrainsHigherFirst(t *testing.T) {
+	iron := NewMainBus[int]("iron")
+	copper := NewMainBus[int]("copper")
+	for id := 1; id <= 6; id++ {
+		iron.Produce(Event[int]{ID: id})
+		copper.Produce(Event[int]{ID: 100 + id})
+	}
+	iron.Close()
+	copper.Close()
+	var got []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	ConsumePrioritized(&wg, func(src *MainBus[int], ev Event[int]) { got = append(got, src.Resource) },
+		[]*MainBus[int]{iron, copper}, 0)
+	want := []string{"iron", "iron", "iron", "iron", "iron", "iron", "copper", "copper", "copper", "copper", "copper", "copper"}
+	if len(got) != len(want) {
+		t.Fatalf("handled %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("handled %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConsumePrioritizedMaxSkip(t *testing.T) {
+	iron := NewMainBus[int]("iron")
+	copper := NewMainBus[int]("copper")
+	for id := 1; id <= 6; id++ {
+		iron.Produce(Event[int]{ID: id})
+	}
+	for id := 1; id <= 2; id++ {
+		copper.Produce(Event[int]{ID: 100 + id})
+	}
+	iron.Close()
+	copper.Close()
+	var got []string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	ConsumePrioritized(&wg, func(src *MainBus[int], ev Event[int]) { got = append(got, src.Resource) },
+		[]*MainBus[int]{iron, copper}, 2)
+	want := "[iron iron copper iron iron copper iron iron]"
+	if s := fmt.Sprint(got); s != want {
+		t.Fatalf("handled %s, want %s", s, want)
+	}
+}
+
+func TestConsumePrioritizedBlocksUntilReady(t *testing.T) {
+	iron := NewMainBus[int]("iron")
+	copper := NewMainBus[int]("copper")
+	got := make(chan string, 2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go ConsumePrioritized(&wg, func(src *MainBus[int], ev Event[int]) { got <- src.Resource },
+		[]*MainBus[int]{iron, copper}, 0)
+	copper.Produce(Event[int]{ID: 1})
+	if r := <-got; r != "copper" {
+		t.Fatalf("handled an event from %s, want copper", r)
+	}
+	iron.Close()
+	copper.Close()
+	wg.Wait()
+}
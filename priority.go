@@ -0,0 +1,235 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrPriorityTooLow is returned by ProducePriority on a full bus evicting with SetEviction when
+// the new event does not outrank any queued one
+var ErrPriorityTooLow = errors.New("priority bus: full, and the event outranks nothing queued")
+
+// PriorityBus buffers events in a heap so consumers always receive the highest Priority first.
+// Events with equal priority are delivered oldest Time first. Channels are strictly FIFO, so
+// this is a separate bus type rather than a MainBus strategy. SetAging makes waiting events gain
+// priority so a steady stream of urgent ones cannot starve the rest, and SetEviction makes a full
+// bus keep the most important events instead of blocking.
+type PriorityBus[T any] struct {
+	Resource string
+
+	// OnEvicted, if set, is called with each event SetEviction pushes out to make room, for
+	// example to send it to a dead-letter conveyor. It runs with the bus locked, so it must not
+	// call back into the bus.
+	OnEvicted func(ev Event[T])
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	queue    eventHeap[T]
+	capacity int
+	evict    bool          // full bus evicts its lowest-ranked event; see SetEviction
+	evicted  atomic.Uint64 // events pushed out by higher-ranked ones
+	clock    Clock         // stamps events queued without a Time; see SetClock
+	closed   bool
+}
+
+// NewPriorityBus creates a priority bus holding at most capacity events; a non-positive
+// capacity means unbounded
+func NewPriorityBus[T any](resource string, capacity int) *PriorityBus[T] {
+	pb := &PriorityBus[T]{Resource: resource, capacity: capacity, clock: realClock{}}
+	pb.notEmpty = sync.NewCond(&pb.mu)
+	pb.notFull = sync.NewCond(&pb.mu)
+	return pb
+}
+
+// ProducePriority queues an event by its Priority, blocking while the bus is full unless
+// SetEviction is on. It returns ErrBusClosed if the bus is closed.
+func (pb *PriorityBus[T]) ProducePriority(ev Event[T]) error {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	for !pb.closed && !pb.evict && pb.full() {
+		pb.notFull.Wait()
+	}
+	if pb.closed {
+		return ErrBusClosed
+	}
+	if pb.queue.aging > 0 && ev.Time.IsZero() {
+		ev.Time = pb.clock.Now() // an event must have been waiting since some time to age
+	}
+	if pb.full() {
+		if err := pb.evictFor(ev); err != nil {
+			return err
+		}
+	}
+	heap.Push(&pb.queue, ev)
+	pb.notEmpty.Signal()
+	return nil
+}
+
+// Pop removes and returns the highest-priority event, blocking while the bus is empty. It
+// reports false once the bus is closed and fully drained.
+func (pb *PriorityBus[T]) Pop() (Event[T], bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	for !pb.closed && pb.queue.Len() == 0 {
+		pb.notEmpty.Wait()
+	}
+	if pb.queue.Len() == 0 {
+		var zero Event[T]
+		return zero, false
+	}
+	ev := heap.Pop(&pb.queue).(Event[T])
+	pb.notFull.Signal()
+	return ev, true
+}
+
+// Consume pops events in priority order and passes them to handler until the bus is closed
+// and drained
+func (pb *PriorityBus[T]) Consume(wg *sync.WaitGroup, handler func(Event[T])) {
+	defer wg.Done()
+	for {
+		ev, ok := pb.Pop()
+		if !ok {
+			return
+		}
+		handler(ev)
+	}
+}
+
+// SetAging makes queued events gain perSecond priority for every second they have waited since
+// their Time, so the bus serves the highest aged priority, Priority + perSecond times the seconds
+// since Time, and a low-priority event waiting long enough overtakes any newer event,
+// guaranteeing it is eventually served. Events queued without a Time are stamped with the time
+// they are queued, on the clock set with SetClock. All events age at the same rate, so aging
+// never reorders two queued events against each other over time, only new events against old
+// ones. Zero, the default, disables aging. The queue is reordered for the new rate; events
+// already queued without a Time count as the oldest.
+func (pb *PriorityBus[T]) SetAging(perSecond float64) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.queue.aging = max(perSecond, 0)
+	heap.Init(&pb.queue)
+}
+
+// SetClock makes the bus read the current time from c instead of the wall clock when it stamps
+// events queued without a Time for SetAging, so tests can age events with a FakeClock. A nil c
+// restores the wall clock.
+func (pb *PriorityBus[T]) SetClock(c Clock) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if c == nil {
+		c = realClock{}
+	}
+	pb.clock = c
+}
+
+// SetEviction makes a full bus evict instead of blocking producers: an incoming event that
+// outranks the lowest-ranked queued one, by the order Pop serves them in, replaces it, and any
+// other is turned away with ErrPriorityTooLow. Both count as dropped for the events' OnDropped
+// callbacks, and the evicted one is passed to OnEvicted. Producers blocked on the full bus when
+// eviction is turned on retry at once. It has no effect on an unbounded bus.
+func (pb *PriorityBus[T]) SetEviction(on bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.evict = on
+	pb.notFull.Broadcast()
+}
+
+// Evicted returns how many events SetEviction has pushed out to make room
+func (pb *PriorityBus[T]) Evicted() uint64 {
+	return pb.evicted.Load()
+}
+
+// full reports whether the bus holds its capacity
+func (pb *PriorityBus[T]) full() bool {
+	return pb.capacity > 0 && pb.queue.Len() >= pb.capacity
+}
+
+// evictFor makes room for ev by evicting the lowest-ranked queued event, or reports
+// ErrPriorityTooLow if ev does not outrank it
+func (pb *PriorityBus[T]) evictFor(ev Event[T]) error {
+	i := pb.queue.lowest()
+	if !pb.queue.before(ev, pb.queue.events[i]) {
+		dropped(ev, "outranked on a full priority bus")
+		return ErrPriorityTooLow
+	}
+	out := heap.Remove(&pb.queue, i).(Event[T])
+	pb.evicted.Add(1)
+	dropped(out, "evicted by a higher priority")
+	if pb.OnEvicted != nil {
+		pb.OnEvicted(out)
+	}
+	return nil
+}
+
+// Len returns the number of queued events
+func (pb *PriorityBus[T]) Len() int {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return pb.queue.Len()
+}
+
+// Close stops accepting events; consumers still drain what is queued. Calls after the first
+// return ErrBusClosed.
+func (pb *PriorityBus[T]) Close() error {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if pb.closed {
+		return ErrBusClosed
+	}
+	pb.closed = true
+	pb.notEmpty.Broadcast()
+	pb.notFull.Broadcast()
+	return nil
+}
+
+// eventHeap orders events by descending aged priority, then ascending Time. Aged priorities are
+// compared through their difference, in which the current time cancels out, so the order of
+// queued events does not depend on when it is checked.
+type eventHeap[T any] struct {
+	events []Event[T]
+	aging  float64 // priority gained per second waited
+}
+
+func (h eventHeap[T]) Len() int { return len(h.events) }
+
+func (h eventHeap[T]) Less(i, j int) bool { return h.before(h.events[i], h.events[j]) }
+
+// before reports whether a is served before b
+func (h eventHeap[T]) before(a, b Event[T]) bool {
+	diff := float64(a.Priority - b.Priority)
+	if h.aging > 0 {
+		diff += h.aging * b.Time.Sub(a.Time).Seconds()
+	}
+	if diff != 0 {
+		return diff > 0
+	}
+	return a.Time.Before(b.Time)
+}
+
+// lowest returns the index of the event served last, which is always a leaf; the heap must not
+// be empty
+func (h eventHeap[T]) lowest() int {
+	n := len(h.events)
+	low := n / 2
+	for i := low + 1; i < n; i++ {
+		if h.Less(low, i) {
+			low = i
+		}
+	}
+	return low
+}
+
+func (h eventHeap[T]) Swap(i, j int) { h.events[i], h.events[j] = h.events[j], h.events[i] }
+
+func (h *eventHeap[T]) Push(x any) { h.events = append(h.events, x.(Event[T])) }
+
+func (h *eventHeap[T]) Pop() any {
+	old := h.events
+	n := len(old)
+	ev := old[n-1]
+	h.events = old[:n-1]
+	return ev
+}
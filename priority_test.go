@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// popUntilLow feeds pb a steady stream of priority-10 events while popping, and returns how many
+// pops it took to get the priority-0 event, or -1 if it had not come out after limit pops
+func popUntilLow(pb *PriorityBus[int], limit int) int {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if pb.Len() < 4 {
+				pb.ProducePriority(Event[int]{Priority: 10, Value: 1, Time: time.Now()})
+			} else {
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+	for n := 1; n <= limit; n++ {
+		ev, _ := pb.Pop()
+		if ev.Priority == 0 {
+			return n
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return -1
+}
+
+func TestPriorityAgingPreventsStarvation(t *testing.T) {
+	for _, aging := range []float64{0, 200} {
+		pb := NewPriorityBus[int]("iron", 0)
+		pb.SetAging(aging)
+		for range 4 {
+			pb.ProducePriority(Event[int]{Priority: 10, Value: 1, Time: time.Now()})
+		}
+		pb.ProducePriority(Event[int]{Priority: 0, Value: 0}) // stamped when queued
+		n := popUntilLow(pb, 500)
+		switch {
+		case aging == 0 && n != -1:
+			t.Errorf("without aging the low-priority event came out after %d pops", n)
+		case aging > 0 && n == -1:
+			t.Error("with aging the low-priority event starved")
+		}
+		pb.Close()
+	}
+}
+
+func TestPriorityAgingOrder(t *testing.T) {
+	pb := NewPriorityBus[int]("iron", 0)
+	pb.SetAging(1) // one priority point per second
+	now := time.Now()
+	pb.ProducePriority(Event[int]{ID: 1, Priority: 5, Time: now})
+	pb.ProducePriority(Event[int]{ID: 2, Priority: 1, Time: now.Add(-10 * time.Second)}) // aged 11
+	pb.ProducePriority(Event[int]{ID: 3, Priority: 3, Time: now.Add(-time.Second)})      // aged 4
+	pb.Close()
+	var got []int
+	for ev, ok := pb.Pop(); ok; ev, ok = pb.Pop() {
+		got = append(got, ev.ID)
+	}
+	if len(got) != 3 || got[0] != 2 || got[1] != 1 || got[2] != 3 {
+		t.Errorf("popped %v, want [2 1 3]", got)
+	}
+}
+
+func TestPriorityEviction(t *testing.T) {
+	pb := NewPriorityBus[int]("iron", 3)
+	var evicted []int
+	pb.OnEvicted = func(ev Event[int]) { evicted = append(evicted, ev.ID) }
+	pb.SetEviction(true)
+	now := time.Now()
+	for i, prio := range []int{5, 1, 3} {
+		if err := pb.ProducePriority(Event[int]{ID: i + 1, Priority: prio, Time: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// at capacity: 7 outranks the lowest (ID 2, priority 1) and replaces it
+	if err := pb.ProducePriority(Event[int]{ID: 4, Priority: 7, Time: now}); err != nil {
+		t.Fatalf("higher-priority event: %v", err)
+	}
+	// 2 does not outrank the new lowest, priority 3, so it is turned away
+	var reason string
+	rejected := Event[int]{ID: 5, Priority: 2, Time: now, OnDropped: func(r string) { reason = r }}
+	if err := pb.ProducePriority(rejected); !errors.Is(err, ErrPriorityTooLow) {
+		t.Fatalf("lower-priority event: %v, want ErrPriorityTooLow", err)
+	}
+	if reason == "" {
+		t.Error("rejected event's OnDropped was not called")
+	}
+	// an equal priority queued later does not outrank the one already there
+	if err := pb.ProducePriority(Event[int]{ID: 6, Priority: 3, Time: now.Add(time.Second)}); !errors.Is(err, ErrPriorityTooLow) {
+		t.Fatalf("equal-priority event: %v, want ErrPriorityTooLow", err)
+	}
+
+	if pb.Len() != 3 || pb.Evicted() != 1 || !slices.Equal(evicted, []int{2}) {
+		t.Fatalf("len %d, evicted %d %v; want 3 queued and ID 2 evicted", pb.Len(), pb.Evicted(), evicted)
+	}
+	pb.Close()
+	var got []int
+	for ev, ok := pb.Pop(); ok; ev, ok = pb.Pop() {
+		got = append(got, ev.ID)
+	}
+	if !slices.Equal(got, []int{4, 1, 3}) {
+		t.Errorf("popped %v, want [4 1 3]", got)
+	}
+}
+
+func TestPriorityEvictionReleasesBlockedProducers(t *testing.T) {
+	pb := NewPriorityBus[int]("iron", 1)
+	defer pb.Close()
+	pb.ProducePriority(Event[int]{ID: 1, Priority: 1})
+	errc := make(chan error, 1)
+	go func() { errc <- pb.ProducePriority(Event[int]{ID: 2, Priority: 9}) }()
+	select {
+	case err := <-errc:
+		t.Fatalf("producer did not block on a full bus: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	pb.SetEviction(true)
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("producer still blocked after SetEviction")
+	}
+	if ev, _ := pb.Pop(); ev.ID != 2 || pb.Evicted() != 1 {
+		t.Errorf("popped ID %d with %d evicted, want ID 2 after one eviction", ev.ID, pb.Evicted())
+	}
+}
+
+func TestPriorityAgingFollowsClock(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	pb := NewPriorityBus[int]("iron", 0)
+	pb.SetClock(clock)
+	pb.SetAging(1) // one priority point per second
+	// queued without a Time, so stamped by the fake clock: after 11s they rank 12, 6 and 11
+	pb.ProducePriority(Event[int]{ID: 1, Priority: 1})
+	clock.Advance(10 * time.Second)
+	pb.ProducePriority(Event[int]{ID: 2, Priority: 5})
+	clock.Advance(time.Second)
+	pb.ProducePriority(Event[int]{ID: 3, Priority: 11})
+	pb.Close()
+	var got []int
+	for ev, ok := pb.Pop(); ok; ev, ok = pb.Pop() {
+		got = append(got, ev.ID)
+		if want := epoch.Add(time.Duration([]int{0, 10, 11}[ev.ID-1]) * time.Second); !ev.Time.Equal(want) {
+			t.Errorf("event %d stamped %v, want %v from the fake clock", ev.ID, ev.Time, want)
+		}
+	}
+	if !slices.Equal(got, []int{1, 3, 2}) {
+		t.Errorf("popped %v, want [1 3 2]", got)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import "context"
+
+// Producer sends events to the one conveyor it is pinned to, skipping the strategy's choice and
+// the contention it brings when many goroutines produce at once. Obtain producers from
+// ProducerPool; each one is meant to be used by a single goroutine.
+type Producer[T any] struct {
+	bus  *MainBus[T]
+	line int
+}
+
+// ProducerPool returns n producers, pinned round-robin to the live conveyors of bus, so that n
+// goroutines can each feed their own conveyor. Events sent through one producer keep their order
+// on its conveyor, but the spread of events across conveyors now follows how busy each producer
+// is rather than the bus strategy: an idle producer leaves its conveyor empty. n below one is
+// treated as one.
+func ProducerPool[T any](bus *MainBus[T], n int) []*Producer[T] {
+	live := bus.table().live
+	pool := make([]*Producer[T], max(n, 1))
+	for i := range pool {
+		line := -1
+		if len(live) > 0 {
+			line = live[i%len(live)]
+		}
+		pool[i] = &Producer[T]{bus: bus, line: line}
+	}
+	return pool
+}
+
+// Line returns the conveyor the producer is pinned to, or -1 if the bus had none live
+func (p *Producer[T]) Line() int {
+	return p.line
+}
+
+// Send produces ev on the producer's conveyor, with the same middleware, rate limit, overflow
+// policy, persistence and results as Produce. Events keyed for StrategyHashKey still go to the
+// conveyor of their key, and once the pinned conveyor is removed events are routed by the bus
+// strategy instead.
+func (p *Producer[T]) Send(ev Event[T]) error {
+	bus := p.bus
+	return bus.chain(func(ev Event[T]) error {
+		_, err := bus.produceVia(context.Background(), ev, p.place)
+		return err
+	})(ev)
+}
+
+// place sends ev to the pinned conveyor, falling back to route
+func (p *Producer[T]) place(ctx context.Context, ev Event[T], record bool) (int, error) {
+	bus := p.bus
+	if t := bus.table(); !bus.keyed(ev) && p.line >= 0 {
+		if err := bus.send(ctx, p.line, t.belts[p.line], ev, record); err != errBeltClosed {
+			return p.line, err
+		}
+	}
+	return bus.route(ctx, ev, record)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProducerPoolPinsConveyors(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(3), WithBuffer(16))
+	defer bus.Close()
+	n := len(bus.Conveyors)
+	pool := ProducerPool(bus, n+1)
+	if len(pool) != n+1 {
+		t.Fatalf("pool of %d producers, want %d", len(pool), n+1)
+	}
+	for i, p := range pool {
+		if want := i % n; p.Line() != want {
+			t.Fatalf("producer %d pinned to %d, want %d", i, p.Line(), want)
+		}
+	}
+	for id := 1; id <= 5; id++ {
+		if err := pool[1].Send(Event[int]{ID: id}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if d := bus.Depth(1); d != 5 {
+		t.Fatalf("pinned conveyor holds %d events, want 5", d)
+	}
+	for id := 1; id <= 5; id++ {
+		if ev := <-bus.Conveyors[1]; ev.ID != id {
+			t.Fatalf("got event %d, want %d: per-producer order lost", ev.ID, id)
+		}
+	}
+	if n := len(ProducerPool(bus, 0)); n != 1 {
+		t.Fatalf("ProducerPool(bus, 0) returned %d producers, want 1", n)
+	}
+}
+
+func TestProducerFallsBackWhenConveyorRemoved(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(4))
+	defer bus.Close()
+	var seen atomic.Int32
+	bus.Use(func(next ProduceFunc[int]) ProduceFunc[int] {
+		return func(ev Event[int]) error {
+			seen.Add(1)
+			return next(ev)
+		}
+	})
+	p := ProducerPool(bus, 1)[0]
+	if err := bus.RemoveConveyor(p.Line()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Send(Event[int]{ID: 1}); err != nil {
+		t.Fatalf("Send after removal: %v", err)
+	}
+	if d := bus.Depth(1); d != 1 {
+		t.Fatalf("remaining conveyor holds %d events, want 1", d)
+	}
+	if seen.Load() != 1 {
+		t.Fatal("Send skipped the middleware")
+	}
+	bus.Close()
+	if err := p.Send(Event[int]{ID: 2}); err != ErrBusClosed {
+		t.Fatalf("Send on a closed bus: %v, want ErrBusClosed", err)
+	}
+}
+
+// benchmarkProducers runs one producing goroutine per GOMAXPROCS slot, draining each conveyor in
+// the background, with send producing one event
+func benchmarkProducers(b *testing.B, bus *MainBus[int], send func(p, id int) error) {
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	var next atomic.Int32
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		p := int(next.Add(1) - 1)
+		for id := 0; pb.Next(); id++ {
+			if err := send(p, id); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	bus.Close()
+	wg.Wait()
+}
+
+func BenchmarkProduceShared(b *testing.B) {
+	bus := NewMainBus[int]("iron", WithLines(8), WithBuffer(256))
+	benchmarkProducers(b, bus, func(_, id int) error { return bus.Produce(Event[int]{ID: id}) })
+}
+
+func BenchmarkProducerPool(b *testing.B) {
+	bus := NewMainBus[int]("iron", WithLines(8), WithBuffer(256))
+	pool := ProducerPool(bus, 64)
+	benchmarkProducers(b, bus, func(p, id int) error { return pool[p%len(pool)].Send(Event[int]{ID: id}) })
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// WithRandSource seeds the bus's own random source, used by StrategyRandom, StrategyLeastLoaded
+// ties, StrategyWeighted, StrategyHashKey events without a key, the fallback of a custom
+// RoutingStrategy and ConsumeSampled, so tests can pin those decisions: two buses built with
+// the same seed and fed the same events from one goroutine route them identically. Without
+// it every bus gets a random seed.
+func WithRandSource(seed int64) Option {
+	return func(c *busConfig) {
+		c.randSeed = &seed
+	}
+}
+
+// lockedRand is a random source safe for the concurrent producers and consumers of a bus
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// newLockedRand returns the source for cfg
+func newLockedRand(cfg busConfig) *lockedRand {
+	seed := rand.Int63()
+	if cfg.randSeed != nil {
+		seed = *cfg.randSeed
+	}
+	return &lockedRand{r: rand.New(rand.NewSource(seed))}
+}
+
+// Intn returns a random int in [0, n)
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+// Float64 returns a random float64 in [0, 1)
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket limits how many events per second may enter a bus. Tokens refill continuously
+// up to a burst of one second's worth; a non-positive rate disables limiting.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	clock  Clock // nil means the wall clock
+}
+
+// now returns the current time on the bucket clock
+func (b *tokenBucket) now() time.Time {
+	if b.clock == nil {
+		return time.Now()
+	}
+	return b.clock.Now()
+}
+
+// setRate changes the refill rate, keeping the tokens accumulated so far
+func (b *tokenBucket) setRate(eventsPerSec int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.now())
+	b.rate = float64(eventsPerSec)
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+}
+
+// refill adds the tokens earned since the last call. Callers must hold b.mu.
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() && b.rate > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+	}
+	b.last = now
+}
+
+// reserve takes a token, going into debt if none is available, and returns how long the
+// caller must wait before the token is actually earned
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return 0
+	}
+	b.refill(b.now())
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// eventsPerSec returns the refill rate
+func (b *tokenBucket) eventsPerSec() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int(b.rate)
+}
+
+// cancel returns a token taken by reserve or allow that ended up unused
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate > 0 {
+		b.tokens++
+	}
+}
+
+// allow takes a token only if one is available right now
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return true
+	}
+	b.refill(b.now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// wait blocks until a token is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// SetRate changes the bus rate limit at runtime. A non-positive value removes the limit.
+func (bus *MainBus[T]) SetRate(eventsPerSec int) {
+	bus.limiter.setRate(eventsPerSec)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateHistory is how many counter samples SampleRates keeps, bounding the longest window
+// RateStats can look back over to rateHistory sampling intervals
+const rateHistory = 512
+
+// RateSnapshot holds event rates, in events per second, over the window RateStats measured
+type RateSnapshot struct {
+	Window   time.Duration `json:"window"`   // span actually measured; 0 before any sample
+	Produced float64       `json:"produced"` // whole bus
+	Consumed float64       `json:"consumed"`
+	Lines    []LineRate    `json:"lines"` // indexed by line
+}
+
+// LineRate holds the event rates of one conveyor
+type LineRate struct {
+	Produced float64 `json:"produced"`
+	Consumed float64 `json:"consumed"`
+}
+
+// rateSample is the produced and consumed counters of every conveyor at one instant
+type rateSample struct {
+	at                 time.Time
+	produced, consumed []uint64
+}
+
+// rateSampler keeps the latest counter samples in a ring
+type rateSampler struct {
+	mu      sync.Mutex
+	samples []rateSample // oldest first once the ring is full, starting at next
+	next    int
+}
+
+// add records s, overwriting the oldest sample once the ring is full
+func (r *rateSampler) add(s rateSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.samples) < rateHistory {
+		r.samples = append(r.samples, s)
+		return
+	}
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % rateHistory
+}
+
+// since returns the newest sample taken at or before t, or the oldest one if none is that old
+func (r *rateSampler) since(t time.Time) (rateSample, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.samples) == 0 {
+		return rateSample{}, false
+	}
+	base := r.samples[r.next%len(r.samples)]
+	for i := range r.samples {
+		s := r.samples[(r.next+i)%len(r.samples)]
+		if s.at.After(t) {
+			break
+		}
+		base = s
+	}
+	return base, true
+}
+
+// sampleRates reads the produced and consumed counters of every conveyor
+func (bus *MainBus[T]) sampleRates() rateSample {
+	belts := bus.table().belts
+	s := rateSample{at: bus.now(), produced: make([]uint64, len(belts)), consumed: make([]uint64, len(belts))}
+	for i, b := range belts {
+		s.produced[i] = b.stats.produced.Load()
+		s.consumed[i] = b.stats.consumed.Load()
+	}
+	return s
+}
+
+// SampleRates samples the produced and consumed counters every interval in the background, for
+// RateStats, until the returned func is called or the bus is closed. Each sample copies two
+// counters per conveyor and only the last few hundred are kept. Calling it again replaces the
+// earlier sampler's history.
+func (bus *MainBus[T]) SampleRates(interval time.Duration) (stop func()) {
+	r := &rateSampler{}
+	r.add(bus.sampleRates())
+	bus.rates.Store(r)
+	quit := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			case <-bus.closing:
+				return
+			}
+			r.add(bus.sampleRates())
+		}
+	}()
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+// RateStats returns the produced and consumed rates of every conveyor and of the whole bus over
+// the last window, from the current counters and the newest sample taken at least window ago.
+// When the samples do not reach back that far the oldest one is used, and Window tells the span
+// actually measured. Without SampleRates every rate is 0.
+func (bus *MainBus[T]) RateStats(window time.Duration) RateSnapshot {
+	r := bus.rates.Load()
+	if r == nil {
+		return RateSnapshot{}
+	}
+	now := bus.sampleRates()
+	base, ok := r.since(now.at.Add(-window))
+	elapsed := now.at.Sub(base.at)
+	snap := RateSnapshot{Lines: make([]LineRate, len(now.produced))}
+	if !ok || elapsed <= 0 {
+		return snap
+	}
+	snap.Window = elapsed
+	// conveyors added since the base sample started from zero
+	at := func(counts []uint64, i int) uint64 {
+		if i < len(counts) {
+			return counts[i]
+		}
+		return 0
+	}
+	secs := elapsed.Seconds()
+	for i := range now.produced {
+		lr := LineRate{
+			Produced: float64(now.produced[i]-at(base.produced, i)) / secs,
+			Consumed: float64(now.consumed[i]-at(base.consumed, i)) / secs,
+		}
+		snap.Lines[i] = lr
+		snap.Produced += lr.Produced
+		snap.Consumed += lr.Consumed
+	}
+	return snap
+}
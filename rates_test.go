@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateStatsKnownRate(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(64))
+	var wg sync.WaitGroup
+	for line := range 2 {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	stop := bus.SampleRates(10 * time.Millisecond)
+	defer stop()
+
+	// 200 events per second for 600ms
+	ticker := time.NewTicker(5 * time.Millisecond)
+	for deadline := time.Now().Add(600 * time.Millisecond); time.Now().Before(deadline); {
+		<-ticker.C
+		bus.Produce(Event[int]{Value: 1})
+	}
+	ticker.Stop()
+	snap := bus.RateStats(400 * time.Millisecond)
+	bus.Close()
+	wg.Wait()
+
+	if snap.Window < 350*time.Millisecond || snap.Window > 450*time.Millisecond {
+		t.Errorf("Window = %v, want about 400ms", snap.Window)
+	}
+	if snap.Produced < 120 || snap.Produced > 240 {
+		t.Errorf("Produced = %.1f/s, want about 200", snap.Produced)
+	}
+	if snap.Consumed < 120 || snap.Consumed > 240 {
+		t.Errorf("Consumed = %.1f/s, want about 200", snap.Consumed)
+	}
+	if len(snap.Lines) != 2 || snap.Lines[0].Produced+snap.Lines[1].Produced != snap.Produced {
+		t.Errorf("line rates %+v do not add up to %.1f", snap.Lines, snap.Produced)
+	}
+}
+
+func TestRateStatsWithoutSampling(t *testing.T) {
+	bus := NewMainBus[int](Iron)
+	defer bus.Close()
+	bus.Produce(Event[int]{Value: 1})
+	if snap := bus.RateStats(time.Second); snap.Window != 0 || snap.Produced != 0 {
+		t.Errorf("RateStats without SampleRates = %+v, want zero", snap)
+	}
+}
+
+func TestRateStatsClampsToHistory(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithBuffer(64))
+	defer bus.Close()
+	stop := bus.SampleRates(time.Millisecond)
+	defer stop()
+	time.Sleep(20 * time.Millisecond)
+	if snap := bus.RateStats(time.Hour); snap.Window <= 0 || snap.Window > time.Second {
+		t.Errorf("Window = %v, want the span of the samples kept", snap.Window)
+	}
+}
+
+func TestRateSamplerRing(t *testing.T) {
+	var r rateSampler
+	base := time.Unix(0, 0)
+	for i := range rateHistory + 10 {
+		r.add(rateSample{at: base.Add(time.Duration(i) * time.Second)})
+	}
+	if s, _ := r.since(base); !s.at.Equal(base.Add(10 * time.Second)) {
+		t.Errorf("oldest sample at %v, want 10s", s.at.Sub(base))
+	}
+	if s, _ := r.since(base.Add(100*time.Second + time.Millisecond)); !s.at.Equal(base.Add(100 * time.Second)) {
+		t.Errorf("sample before 100s at %v", s.at.Sub(base))
+	}
+}
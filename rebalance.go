@@ -0,0 +1,125 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Rebalance evens out the conveyors by moving buffered events off those holding more than their
+// share of the total onto those holding less, using only non-blocking receives and sends, and
+// returns how many events it moved. The events moved are the newest on their conveyor; the rest
+// keep their place and order. Events keyed for StrategyHashKey never move, so per-key order is
+// kept. A conveyor a producer is sending to at that instant is left for the next call. Moves are
+// not counted as produced or consumed, and a closed bus is left alone.
+func (bus *MainBus[T]) Rebalance() int {
+	bus.snapMu.Lock()
+	defer bus.snapMu.Unlock()
+	// the layout and the conveyors stay open while the read lock is held
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	if bus.closed {
+		return 0
+	}
+	t := bus.table()
+	if len(t.live) < 2 {
+		return 0
+	}
+	total := 0
+	for _, line := range t.live {
+		total += t.belts[line].depth()
+	}
+	share := (total + len(t.live) - 1) / len(t.live)
+	moved := 0
+	for _, line := range t.live {
+		if t.belts[line].depth() > share {
+			moved += bus.shed(t, line, share)
+		}
+	}
+	return moved
+}
+
+// shed moves the newest unkeyed events of an over-full conveyor to conveyors below share until
+// it holds share events. The conveyor is locked for writing so no producer lands an event while
+// its buffer is taken off and put back; consumers keep receiving throughout.
+func (bus *MainBus[T]) shed(t *lineTable[T], line int, share int) int {
+	b := t.belts[line]
+	if b.buf != nil || !b.mu.TryLock() {
+		return 0
+	}
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0
+	}
+	evs := b.takeAll()
+	// nobody else sends on c while it is locked, so every event fits back
+	move := make([]bool, len(evs))
+	for i, excess := len(evs)-1, len(evs)-share; i >= 0 && excess > 0; i-- {
+		if !bus.keyed(evs[i]) {
+			move[i] = true
+			excess--
+		}
+	}
+	moved := 0
+	for i, ev := range evs {
+		if move[i] && bus.place(t, line, share, ev) {
+			moved++
+			continue
+		}
+		b.c() <- ev
+	}
+	b.trackFull(bus.clock)
+	bus.checkPressure(line)
+	return moved
+}
+
+// place offers ev to the emptiest live conveyor other than from that holds fewer than share
+// events, reporting whether one took it
+func (bus *MainBus[T]) place(t *lineTable[T], from int, share int, ev Event[T]) bool {
+	to, low := -1, share
+	for _, line := range t.live {
+		if l := t.belts[line].depth(); line != from && l < low && t.belts[line].buf == nil {
+			to, low = line, l
+		}
+	}
+	if to < 0 {
+		return false
+	}
+	b := t.belts[to]
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return false
+	}
+	select {
+	case b.c() <- ev:
+		b.trackFull(bus.clock)
+		bus.checkPressure(to)
+		return true
+	default:
+		return false
+	}
+}
+
+// AutoRebalance calls Rebalance every interval in the background, logging how many events each
+// call moved at debug level, until the returned func is called or the bus is closed
+func (bus *MainBus[T]) AutoRebalance(interval time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			case <-bus.closing:
+				return
+			}
+			if moved := bus.Rebalance(); moved > 0 {
+				bus.logger.Debug("rebalanced conveyors", "resource", bus.Resource, "moved", moved)
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(quit) }) }
+}
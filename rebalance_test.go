@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fill pins n events with IDs from first onto one conveyor
+func fill(t *testing.T, bus *MainBus[string], line, first, n int, value string) {
+	t.Helper()
+	p := &Producer[string]{bus: bus, line: line}
+	for id := first; id < first+n; id++ {
+		if err := p.Send(Event[string]{ID: id, Value: value}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+}
+
+// ids takes every event off a conveyor and returns their IDs
+func ids(bus *MainBus[string], line int) []int {
+	var got []int
+	for len(bus.Conveyors[line]) > 0 {
+		got = append(got, (<-bus.Conveyors[line]).ID)
+	}
+	return got
+}
+
+func TestRebalanceMovesNewestEvents(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(8))
+	defer bus.Close()
+	fill(t, bus, 0, 1, 8, "")
+	if moved := bus.Rebalance(); moved != 4 {
+		t.Fatalf("Rebalance moved %d events, want 4", moved)
+	}
+	if got := fmt.Sprint(ids(bus, 0), ids(bus, 1)); got != "[1 2 3 4] [5 6 7 8]" {
+		t.Fatalf("conveyors after Rebalance: %s", got)
+	}
+	if moved := bus.Rebalance(); moved != 0 {
+		t.Fatalf("Rebalance of empty conveyors moved %d", moved)
+	}
+	if m := bus.Metrics(); m.Produced[0] != 8 || m.Produced[1] != 0 {
+		t.Fatalf("moves were counted as produced: %v", m.Produced)
+	}
+}
+
+func TestRebalanceKeepsKeyedEvents(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(8), WithStrategy(StrategyHashKey),
+		WithKeyFunc(func(ev Event[string]) string { return ev.Value }))
+	defer bus.Close()
+	key := ""
+	for i := 0; key == ""; i++ {
+		if k := fmt.Sprint("key", i); hashKey(bus.table(), k) == 0 {
+			key = k
+		}
+	}
+	fill(t, bus, 0, 1, 4, "")
+	fill(t, bus, 0, 5, 4, key)
+	// the newest events are keyed, so the unkeyed ones move instead
+	if moved := bus.Rebalance(); moved != 4 {
+		t.Fatalf("Rebalance moved %d events, want 4", moved)
+	}
+	if got := fmt.Sprint(ids(bus, 0), ids(bus, 1)); got != "[5 6 7 8] [1 2 3 4]" {
+		t.Fatalf("conveyors after Rebalance: %s", got)
+	}
+	fill(t, bus, 0, 9, 6, key)
+	if moved := bus.Rebalance(); moved != 0 {
+		t.Fatalf("Rebalance moved %d keyed events", moved)
+	}
+}
+
+func TestAutoRebalance(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(8))
+	defer bus.Close()
+	stop := bus.AutoRebalance(time.Millisecond)
+	defer stop()
+	fill(t, bus, 0, 1, 6, "")
+	deadline := time.Now().Add(5 * time.Second)
+	for bus.Depth(1) != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("depths %d and %d, want 3 and 3", bus.Depth(0), bus.Depth(1))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReceiver(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin))
+	bus.Produce(Event[int]{ID: 1})
+	bus.Produce(Event[int]{ID: 2})
+	select {
+	case ev := <-bus.Receiver(1):
+		if ev.ID != 2 {
+			t.Errorf("line 1 gave event %d, want 2", ev.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing received on line 1")
+	}
+	if m := bus.Metrics(); m.Consumed[1] != 0 {
+		t.Errorf("direct read counted as consumed: %d", m.Consumed[1])
+	}
+	for _, line := range []int{-1, 2} {
+		if c := bus.Receiver(line); c != nil {
+			t.Errorf("Receiver(%d) = %v, want nil", line, c)
+		}
+	}
+	bus.Close()
+	if _, ok := <-bus.Receiver(1); ok {
+		t.Error("Receiver still open after Close")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recordedLines returns the lines of the metrics file at path
+func recordedLines(t *testing.T, path string) [][]byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+}
+
+func TestStartMetricsRecorderCadence(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("iron", WithClock(clock))
+	defer bus.Close()
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+	stop, err := bus.StartMetricsRecorder(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitLines := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			data, _ := os.ReadFile(path)
+			got := bytes.Count(data, []byte("\n"))
+			if got == n {
+				return
+			}
+			if got > n || time.Now().After(deadline) {
+				t.Fatalf("file has %d lines, want %d", got, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	time.Sleep(3 * clockPoll)
+	waitLines(0)
+	bus.Produce(Event[int]{ID: 1})
+	clock.Advance(time.Minute)
+	waitLines(1)
+	clock.Advance(30 * time.Second)
+	time.Sleep(3 * clockPoll)
+	waitLines(1)
+	clock.Advance(30 * time.Second)
+	waitLines(2)
+	// intervals slept through are skipped, not written one by one
+	clock.Advance(5 * time.Minute)
+	waitLines(3)
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	time.Sleep(3 * clockPoll)
+
+	lines := recordedLines(t, path)
+	if len(lines) != 3 {
+		t.Fatalf("file has %d lines after stop, want 3", len(lines))
+	}
+	wantTimes := []time.Time{epoch.Add(time.Minute), epoch.Add(2 * time.Minute), epoch.Add(7 * time.Minute)}
+	for i, line := range lines {
+		var rec struct {
+			Time     time.Time `json:"time"`
+			Produced []uint64  `json:"produced"`
+		}
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if !rec.Time.Equal(wantTimes[i]) {
+			t.Errorf("line %d stamped %v, want %v", i, rec.Time, wantTimes[i])
+		}
+		if rec.Produced[0]+rec.Produced[1] != 1 {
+			t.Errorf("line %d records produced %v, want one event", i, rec.Produced)
+		}
+	}
+}
+
+func TestStartMetricsRecorderErrors(t *testing.T) {
+	bus := NewMainBus[int]("iron")
+	defer bus.Close()
+	if _, err := bus.StartMetricsRecorder(filepath.Join(t.TempDir(), "m.jsonl"), 0); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("zero interval: %v, want ErrInvalidConfig", err)
+	}
+	if _, err := bus.StartMetricsRecorder(filepath.Join(t.TempDir(), "missing", "m.jsonl"), time.Second); err == nil {
+		t.Fatal("recorder started in a missing directory")
+	}
+}
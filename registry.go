@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownResource is returned when routing to a resource that has no registered bus
+var ErrUnknownResource = errors.New("no main bus registered for resource")
+
+// BusRegistry keeps one MainBus per resource and manages their lifecycle together.
+// It is safe for concurrent use.
+type BusRegistry[T any] struct {
+	mu    sync.RWMutex
+	buses map[Resource]*MainBus[T]
+}
+
+// NewBusRegistry creates an empty registry
+func NewBusRegistry[T any]() *BusRegistry[T] {
+	return &BusRegistry[T]{buses: make(map[Resource]*MainBus[T])}
+}
+
+// Register creates and tracks a new bus for resource. Each resource may only be registered
+// once; registering it again is a programming error and panics. opts enable optional features
+// as for NewMainBus.
+func (r *BusRegistry[T]) Register(resource Resource, lines, buffer int, opts ...Option) *MainBus[T] {
+	return r.RegisterDefault(resource, append([]Option{WithLines(lines), WithBuffer(buffer)}, opts...)...)
+}
+
+// RegisterDefault is Register with the layout resource was declared with by RegisterResource or
+// SetProfile, or DefaultLines conveyors of DefaultBuffer events for an undeclared resource;
+// WithLines and WithBuffer in opts override it
+func (r *BusRegistry[T]) RegisterDefault(resource Resource, opts ...Option) *MainBus[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.buses[resource]; exists {
+		panic(fmt.Sprintf("main bus: resource %q already registered", resource))
+	}
+	bus := NewMainBus[T](resource, opts...)
+	r.buses[resource] = bus
+	return bus
+}
+
+// Get returns the bus registered for resource, if any
+func (r *BusRegistry[T]) Get(resource Resource) (*MainBus[T], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bus, ok := r.buses[resource]
+	return bus, ok
+}
+
+// ProduceTo sends an event to the bus registered for resource
+func (r *BusRegistry[T]) ProduceTo(resource Resource, ev Event[T]) error {
+	bus, ok := r.Get(resource)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownResource, resource)
+	}
+	return bus.Produce(ev)
+}
+
+// CloseAll closes every registered bus. Buses that were already closed are skipped.
+func (r *BusRegistry[T]) CloseAll() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, bus := range r.buses {
+		bus.Close()
+	}
+}
+
+// ConsumeAll consumes every conveyor of every bus registered so far, one goroutine per conveyor,
+// passing each event to handler with the resource and line it came from. Buses registered after
+// ConsumeAll starts are ignored, as are conveyors added to a bus later; start another consumer
+// for those. handler runs concurrently for different conveyors, so it must be safe for
+// concurrent use. Each conveyor is consumed as ConsumeContext does, and ConsumeAll returns, then
+// marks wg done, once every bus is closed and drained or ctx is cancelled, leaving events still
+// buffered in the latter case.
+func (r *BusRegistry[T]) ConsumeAll(ctx context.Context, wg *sync.WaitGroup, handler func(resource Resource, line int, ev Event[T])) {
+	defer wg.Done()
+	r.mu.RLock()
+	var consumers sync.WaitGroup
+	for resource, bus := range r.buses {
+		for line := range bus.table().belts {
+			consumers.Add(1)
+			go bus.ConsumeContext(ctx, line, &consumers, func(ev Event[T]) { handler(resource, line, ev) })
+		}
+	}
+	r.mu.RUnlock()
+	consumers.Wait()
+}
+
+// ResourceMetrics totals the conveyors of one bus for RegistryMetrics
+type ResourceMetrics struct {
+	Produced uint64 `json:"produced"`
+	Consumed uint64 `json:"consumed"`
+	Dropped  uint64 `json:"dropped"`
+	InFlight int    `json:"in_flight"` // events buffered across the bus
+}
+
+// RegistryMetrics is a point-in-time view of every bus in a registry: the fleet-wide totals
+// and, in Resources, the share of each bus
+type RegistryMetrics struct {
+	ResourceMetrics
+	Resources map[Resource]ResourceMetrics `json:"resources"`
+}
+
+// AggregateMetrics sums the produced, consumed and dropped counts and the buffered events of
+// every registered bus, as one view of the whole bus layer. Each counter is read once, so the
+// totals are exactly the sum of the per-resource figures, and each conveyor's consumed count is
+// read before its produced count, so no conveyor shows more consumed than produced. The buses
+// keep running meanwhile, so the figures of different buses are not from the same instant.
+func (r *BusRegistry[T]) AggregateMetrics() RegistryMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := RegistryMetrics{Resources: make(map[Resource]ResourceMetrics, len(r.buses))}
+	for resource, bus := range r.buses {
+		var rm ResourceMetrics
+		for _, b := range bus.table().belts {
+			rm.InFlight += b.depth()
+			rm.Consumed += b.stats.consumed.Load()
+			rm.Produced += b.stats.produced.Load()
+			rm.Dropped += b.stats.dropped.Load()
+		}
+		m.Resources[resource] = rm
+		m.Produced += rm.Produced
+		m.Consumed += rm.Consumed
+		m.Dropped += rm.Dropped
+		m.InFlight += rm.InFlight
+	}
+	return m
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistryConsumeAll(t *testing.T) {
+	r := NewBusRegistry[int]()
+	iron := r.Register(Iron, 2, 8)
+	copper := r.Register(Copper, 4, 8)
+	for i := range 6 {
+		r.ProduceTo(Iron, Event[int]{ID: i + 1})
+		r.ProduceTo(Copper, Event[int]{ID: i + 1})
+	}
+	var mu sync.Mutex
+	seen := make(map[Resource]int)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go r.ConsumeAll(context.Background(), &wg, func(res Resource, line int, ev Event[int]) {
+		bus, _ := r.Get(res)
+		if line < 0 || line >= len(bus.Conveyors) {
+			t.Errorf("line %d out of range for %s", line, res)
+		}
+		mu.Lock()
+		seen[res]++
+		mu.Unlock()
+	})
+	for iron.Health().Consumers != 2 || copper.Health().Consumers != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	// a bus registered after the start is not consumed
+	late := r.Register("late", 2, 8)
+	late.Produce(Event[int]{ID: 1})
+	iron.Close()
+	copper.Close()
+	late.Close()
+	wg.Wait()
+	if seen[Iron] != 6 || seen[Copper] != 6 || seen["late"] != 0 {
+		t.Errorf("seen = %v, want 6 iron, 6 copper and no late events", seen)
+	}
+}
+
+func TestRegistryConsumeAllCancel(t *testing.T) {
+	r := NewBusRegistry[int]()
+	r.Register(Iron, 2, 8)
+	defer r.CloseAll()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go r.ConsumeAll(ctx, &wg, func(Resource, int, Event[int]) {})
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ConsumeAll did not return after cancel")
+	}
+}
+
+func TestRegistryAggregateMetrics(t *testing.T) {
+	r := NewBusRegistry[int]()
+	defer r.CloseAll()
+	iron := r.Register(Iron, 2, 4, WithStrategy(StrategyRoundRobin), WithOverflowPolicy(OverflowDropNewest))
+	copper := r.Register(Copper, 2, 8)
+	for i := range 10 {
+		r.ProduceTo(Iron, Event[int]{ID: i + 1}) // 8 fit, 2 are dropped
+	}
+	for i := range 5 {
+		r.ProduceTo(Copper, Event[int]{ID: i + 1})
+	}
+	iron.DrainAll()
+	copper.DrainAll()
+	for i := range 3 {
+		r.ProduceTo(Iron, Event[int]{ID: 11 + i})
+	}
+
+	m := r.AggregateMetrics()
+	want := map[Resource]ResourceMetrics{
+		Iron:   {Produced: 11, Consumed: 8, Dropped: 2, InFlight: 3},
+		Copper: {Produced: 5, Consumed: 5, InFlight: 0},
+	}
+	for res, w := range want {
+		if got := m.Resources[res]; got != w {
+			t.Fatalf("%s metrics %+v, want %+v", res, got, w)
+		}
+	}
+	if total := (ResourceMetrics{Produced: 16, Consumed: 13, Dropped: 2, InFlight: 3}); m.ResourceMetrics != total {
+		t.Fatalf("totals %+v, want %+v", m.ResourceMetrics, total)
+	}
+}
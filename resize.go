@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// ResizeBuffer changes the buffer size of a conveyor to newSize while the bus keeps running,
+// growing it to absorb a burst or shrinking it to bound memory and latency. The conveyor's channel
+// is replaced by one of the new size and the events buffered are moved over in order while
+// producers to that conveyor are held off, so the conveyor pauses momentarily: like Inspect,
+// ResizeBuffer waits for sends already under way, including ones blocked on a full conveyor until
+// a consumer makes room. Consumers started with the bus methods carry on from the new channel and
+// miss no event, while a channel taken earlier from Receiver or Conveyors is closed. Shrinking
+// below the events buffered evicts the oldest of them under OverflowDropOldest, counted as
+// dropped; under any other policy it returns an error wrapping ErrConveyorFull and leaves the
+// conveyor as it was. A line out of range or no longer live is an error wrapping ErrInvalidLine,
+// or ErrBusClosed once the bus is closed, and a negative size one wrapping ErrInvalidConfig. A
+// conveyor built WithBufferFactory keeps the size its Buffer was built with and returns an error.
+func (bus *MainBus[T]) ResizeBuffer(line int, newSize int) error {
+	if newSize < 0 {
+		return fmt.Errorf("main bus %q: %w: buffer %d is negative", bus.Resource, ErrInvalidConfig, newSize)
+	}
+	belts := bus.table().belts
+	if line < 0 || line >= len(belts) {
+		return fmt.Errorf("main bus %q: conveyor %d: %w", bus.Resource, line, ErrInvalidLine)
+	}
+	b := belts[line]
+	if b.buf != nil {
+		return fmt.Errorf("main bus %q: conveyor %d has a Buffer and cannot be resized", bus.Resource, line)
+	}
+	bus.snapMu.Lock()
+	defer bus.snapMu.Unlock()
+	if err := bus.swapChannel(line, b, newSize); err != nil {
+		return err
+	}
+	// the exported slice must show the new channel too
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if !bus.closed {
+		bus.publish(bus.table().belts)
+	}
+	return nil
+}
+
+// swapChannel moves the events buffered on a conveyor to a new channel of the given size and
+// installs it, closing the old channel so consumers blocked on it move to the new one
+func (bus *MainBus[T]) swapChannel(line int, b *belt[T], size int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		if bus.isClosed() {
+			return ErrBusClosed
+		}
+		return fmt.Errorf("main bus %q: conveyor %d: %w", bus.Resource, line, ErrInvalidLine)
+	}
+	old := b.c()
+	evs := b.takeAll()
+	if excess := len(evs) - size; excess > 0 {
+		if bus.overflowPolicy() != OverflowDropOldest {
+			// nobody else sends on c while it is locked, so every event fits back
+			for _, ev := range evs {
+				old <- ev
+			}
+			return fmt.Errorf("main bus %q: conveyor %d holds %d events, more than %d: %w", bus.Resource, line, len(evs), size, ErrConveyorFull)
+		}
+		for _, ev := range evs[:excess] {
+			bus.vacate()
+			bus.drop(slog.LevelWarn, line, ev, "evicted by resize")
+		}
+		evs = evs[excess:]
+	}
+	c := make(Conveyor[T], size)
+	for _, ev := range evs {
+		c <- ev
+	}
+	b.ch.Store(&c)
+	close(old)
+	b.trackFull(bus.clock)
+	bus.checkPressure(line)
+	return nil
+}
+
+// reopened reports whether c, which a consumer of line found closed, was replaced by
+// ResizeBuffer, in which case c is set to the new channel for the consumer to carry on
+func (bus *MainBus[T]) reopened(line int, c *Conveyor[T]) bool {
+	next := bus.conveyor(line)
+	if next == *c {
+		return false
+	}
+	*c = next
+	return true
+}
+
+// reopenedCase is reopened for a conveyor received from through a reflect.SelectCase
+func (bus *MainBus[T]) reopenedCase(line int, sc *reflect.SelectCase) bool {
+	c := sc.Chan.Interface().(Conveyor[T])
+	if !bus.reopened(line, &c) {
+		return false
+	}
+	sc.Chan = reflect.ValueOf(c)
+	return true
+}
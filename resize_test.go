@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResizeBuffer(t *testing.T) {
+	bus := NewMainBus[int]("copper", WithBuffer(2))
+	bus.RemoveConveyor(1)
+	var wg sync.WaitGroup
+	for id := 1; id <= 2; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	if err := bus.ResizeBuffer(0, 5); err != nil {
+		t.Fatalf("growing: %v", err)
+	}
+	if got := bus.Capacity(0); got != 5 {
+		t.Fatalf("capacity after growing = %d, want 5", got)
+	}
+	for id := 3; id <= 5; id++ {
+		if !bus.TryProduce(Event[int]{ID: id}) {
+			t.Fatalf("event %d refused by the grown conveyor", id)
+		}
+	}
+	if err := bus.ResizeBuffer(0, 2); !errors.Is(err, ErrConveyorFull) {
+		t.Fatalf("shrinking below the depth = %v, want ErrConveyorFull", err)
+	}
+	if got := bus.Depth(0); got != 5 {
+		t.Fatalf("depth after refused shrink = %d, want 5", got)
+	}
+
+	// a consumer waiting on the old channel carries on from the new one
+	var got []int
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(ev Event[int]) { got = append(got, ev.ID) })
+	settleDepth(t, bus, 0)
+	if err := bus.ResizeBuffer(0, 1); err != nil {
+		t.Fatalf("shrinking the empty conveyor: %v", err)
+	}
+	bus.Produce(Event[int]{ID: 6})
+	bus.Close()
+	wg.Wait()
+	want := []int{1, 2, 3, 4, 5, 6}
+	if len(got) != len(want) {
+		t.Fatalf("consumed %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("consumed %v, want %v", got, want)
+		}
+	}
+}
+
+func TestResizeBufferDropOldest(t *testing.T) {
+	bus := NewMainBus[int]("copper", WithBuffer(4), WithOverflowPolicy(OverflowDropOldest))
+	defer bus.Close()
+	bus.RemoveConveyor(1)
+	for id := 1; id <= 4; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	if err := bus.ResizeBuffer(0, 2); err != nil {
+		t.Fatalf("shrinking: %v", err)
+	}
+	if m := bus.Metrics(); m.Dropped[0] != 2 {
+		t.Fatalf("dropped = %d, want 2", m.Dropped[0])
+	}
+	evs := bus.DrainAll()
+	if len(evs) != 2 || evs[0].ID != 3 || evs[1].ID != 4 {
+		t.Fatalf("kept %v, want events 3 and 4", evs)
+	}
+}
+
+func TestResizeBufferErrors(t *testing.T) {
+	bus := NewMainBus[int]("copper")
+	if err := bus.ResizeBuffer(7, 4); !errors.Is(err, ErrInvalidLine) {
+		t.Fatalf("out of range = %v, want ErrInvalidLine", err)
+	}
+	if err := bus.ResizeBuffer(0, -1); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("negative size = %v, want ErrInvalidConfig", err)
+	}
+	bus.Close()
+	if err := bus.ResizeBuffer(0, 4); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("closed bus = %v, want ErrBusClosed", err)
+	}
+}
+
+// settleDepth waits for the consumers of line to take everything buffered there
+func settleDepth(t *testing.T, bus *MainBus[int], line int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); bus.Depth(line) > 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("conveyor %d still holds %d events", line, bus.Depth(line))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResizeBufferUnderLoad(t *testing.T) {
+	bus := NewMainBus[int]("copper", WithBuffer(4))
+	bus.RemoveConveyor(1)
+	var wg sync.WaitGroup
+	var consumed []int
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(ev Event[int]) { consumed = append(consumed, ev.ID) })
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for id := 1; id <= 500; id++ {
+			bus.Produce(Event[int]{ID: id})
+		}
+	}()
+resizing:
+	for size := 1; ; size = size%8 + 1 {
+		select {
+		case <-done:
+			break resizing
+		default:
+		}
+		if err := bus.ResizeBuffer(0, size); err != nil && !errors.Is(err, ErrConveyorFull) {
+			t.Fatalf("resizing to %d: %v", size, err)
+		}
+	}
+	bus.Close()
+	wg.Wait()
+	if len(consumed) != 500 {
+		t.Fatalf("consumed %d events, want 500", len(consumed))
+	}
+	for i, id := range consumed {
+		if id != i+1 {
+			t.Fatalf("event %d consumed at position %d", id, i)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Resource names the kind of item a bus carries. Declaring resources once, with
+// RegisterResource or as constants, keeps their names and layouts consistent across an app.
+type Resource string
+
+// Resources carried by the demo in main
+const (
+	Iron   Resource = "iron"
+	Copper Resource = "copper"
+)
+
+// resourceDefaults is the layout a bus for a registered resource starts from
+type resourceDefaults struct {
+	lines, buffer int
+}
+
+var (
+	resourcesMu sync.RWMutex
+	resources   = make(map[Resource]resourceDefaults)
+)
+
+// RegisterResource declares a resource with the conveyor count and buffer size its buses get
+// unless WithLines or WithBuffer say otherwise, and returns it for use as a package-level
+// variable. Registering a name again replaces its defaults; buses already built keep theirs.
+func RegisterResource(name string, defaultLines, defaultBuffer int) Resource {
+	r := Resource(name)
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	resources[r] = resourceDefaults{lines: defaultLines, buffer: defaultBuffer}
+	return r
+}
+
+// Sizing targets for SetProfile. Change them before declaring profiles; profiles already set
+// keep the layout they were given.
+var (
+	// ProfileLatency is the longest an event should wait buffered behind others
+	ProfileLatency = 100 * time.Millisecond
+	// ProfileLineRate is how many events per second one conveyor's consumer is expected to handle
+	ProfileLineRate = 1000
+)
+
+// SetProfile declares a resource like RegisterResource, sizing its layout for an expected rate
+// of events per second. Each conveyor is assumed to drain ProfileLineRate events per second, so
+// the resource gets enough conveyors to keep up, at least DefaultLines. By Little's law, events
+// waiting at most ProfileLatency number at most rate times ProfileLatency, so that many buffer
+// slots are spread over the conveyors, at least one each. An iron resource expecting 5000
+// events per second thus gets 6 conveyors (5 rounded up to even) of 84 slots. WithLines and
+// WithBuffer still override the profile for a single bus, and RegisterResource or another
+// SetProfile call replaces it. A non-positive rate gets the package defaults.
+func SetProfile(resource string, rate int) Resource {
+	if rate <= 0 {
+		return RegisterResource(resource, DefaultLines, DefaultBuffer)
+	}
+	lines := max(DefaultLines, ceilDiv(rate, max(ProfileLineRate, 1)))
+	lines += lines % 2 // as NewMainBus would round it
+	waiting := max(1, int(float64(rate)*ProfileLatency.Seconds()))
+	return RegisterResource(resource, lines, max(1, ceilDiv(waiting, lines)))
+}
+
+// ceilDiv returns a divided by b, rounded up, for positive a and b
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// KnownResources returns every resource registered or used to build a bus, sorted by name.
+// Resources never registered with RegisterResource are recorded with DefaultLines and
+// DefaultBuffer when their first bus is built.
+func KnownResources() []Resource {
+	resourcesMu.RLock()
+	defer resourcesMu.RUnlock()
+	known := make([]Resource, 0, len(resources))
+	for r := range resources {
+		known = append(known, r)
+	}
+	slices.Sort(known)
+	return known
+}
+
+// defaultsFor returns the registered defaults of r, registering it with the package defaults
+// if it is unknown
+func defaultsFor(r Resource) resourceDefaults {
+	resourcesMu.RLock()
+	d, ok := resources[r]
+	resourcesMu.RUnlock()
+	if ok {
+		return d
+	}
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	if d, ok := resources[r]; ok {
+		return d
+	}
+	d = resourceDefaults{lines: DefaultLines, buffer: DefaultBuffer}
+	resources[r] = d
+	return d
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegisteredResourceDefaults(t *testing.T) {
+	steel := RegisterResource("steel", 6, 3)
+	bus := NewMainBus[int](steel)
+	defer bus.Close()
+	if len(bus.Conveyors) != 6 || bus.Capacity(0) != 3 {
+		t.Fatalf("steel bus has %d conveyors of %d, want 6 of 3", len(bus.Conveyors), bus.Capacity(0))
+	}
+	override := NewMainBus[int](steel, WithBuffer(9))
+	defer override.Close()
+	if len(override.Conveyors) != 6 || override.Capacity(0) != 9 {
+		t.Fatalf("overridden bus has %d conveyors of %d, want 6 of 9", len(override.Conveyors), override.Capacity(0))
+	}
+	if bus.Resource != "steel" {
+		t.Fatalf("Resource = %q", bus.Resource)
+	}
+}
+
+func TestUnknownResourceIsRecorded(t *testing.T) {
+	bus := NewMainBus[int]("plastic")
+	defer bus.Close()
+	if len(bus.Conveyors) != DefaultLines || bus.Capacity(0) != DefaultBuffer {
+		t.Fatalf("unregistered resource got %d conveyors of %d", len(bus.Conveyors), bus.Capacity(0))
+	}
+	known := KnownResources()
+	if !slices.Contains(known, "plastic") || !slices.IsSorted(known) {
+		t.Fatalf("KnownResources() = %v, want plastic listed in sorted order", known)
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	ore := SetProfile("ore", 5000)
+	registry := NewBusRegistry[int]()
+	defer registry.CloseAll()
+	bus := registry.RegisterDefault(ore)
+	// 5000/s at 1000/s per conveyor is 5, rounded up to 6; 500 waiting events over 6 is 84 each
+	if len(bus.Conveyors) != 6 || bus.Capacity(0) != 84 {
+		t.Fatalf("ore bus has %d conveyors of %d, want 6 of 84", len(bus.Conveyors), bus.Capacity(0))
+	}
+
+	trickle := SetProfile("trickle", 5)
+	small := registry.RegisterDefault(trickle, WithBuffer(4))
+	if len(small.Conveyors) != DefaultLines || small.Capacity(0) != 4 {
+		t.Fatalf("trickle bus has %d conveyors of %d, want %d of the overridden 4", len(small.Conveyors), small.Capacity(0), DefaultLines)
+	}
+	if d := defaultsFor(trickle); d.buffer != 1 {
+		t.Errorf("trickle profile buffer %d, want the minimum of 1", d.buffer)
+	}
+}
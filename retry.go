@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RetryPolicy bounds how ConsumeWithRetry retries a failing event and ProduceWithBackoff a
+// rejected one
+type RetryPolicy struct {
+	MaxAttempts int           // calls per event, including the first; below one means one
+	BaseDelay   time.Duration // wait before the first retry
+	Multiplier  float64       // growth of the wait between retries; below one means a constant wait
+	MaxElapsed  time.Duration // give up once retrying an event has taken this long; 0 means no limit
+	Jitter      float64       // spreads each wait at random by up to this fraction either way, capped at 1
+}
+
+// delay returns the wait before retry n, counting from 1
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := float64(p.BaseDelay)
+	for i := 1; i < n && p.Multiplier > 1; i++ {
+		d *= p.Multiplier
+	}
+	return time.Duration(d)
+}
+
+// wait returns the wait before retry n with the policy jitter applied, drawn from rng
+func (p RetryPolicy) wait(n int, rng *lockedRand) time.Duration {
+	d := p.delay(n)
+	if j := min(p.Jitter, 1); j > 0 {
+		d = time.Duration(float64(d) * (1 + j*(2*rng.Float64()-1)))
+	}
+	return d
+}
+
+// ConsumeWithRetry consumes a specific conveyor like ConsumeWithReject, but calls handler again
+// with exponential backoff when it returns an error, up to policy.MaxAttempts calls in all. An
+// event still failing after the last attempt, or once the next wait would take retrying past
+// policy.MaxElapsed, is rejected onto the dead-letter conveyor with the last error as the reason
+// (and counted as dropped without one). The conveyor waits while an event is retried. Closing
+// the bus ends the retries early. Retries and final failures are counted in BusMetrics.Retried
+// and BusMetrics.Failed.
+func (bus *MainBus[T]) ConsumeWithRetry(line int, wg *sync.WaitGroup, handler func(Event[T]) error, policy RetryPolicy) {
+	b := bus.belt(line)
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		start := bus.now()
+		err := handler(ev)
+		for n := 1; err != nil && n < policy.MaxAttempts; n++ {
+			d := policy.wait(n, bus.rand)
+			if policy.MaxElapsed > 0 && bus.since(start)+d > policy.MaxElapsed {
+				break
+			}
+			if !bus.sleep(d) {
+				break
+			}
+			b.stats.retried.Add(1)
+			bus.logEvent(slog.LevelDebug, "retrying event", line, ev, slog.Int("attempt", n+1), slog.String("error", err.Error()))
+			err = handler(ev)
+		}
+		if err != nil {
+			b.stats.failed.Add(1)
+			bus.rejectOrDrop(line, ev, err.Error())
+		}
+	})
+}
+
+// ProduceWithBackoff offers ev with TryProduceErr, retrying after a refusal with the backoff of
+// policy: up to policy.MaxAttempts offers in all, waiting BaseDelay before the first retry and
+// growing it by Multiplier, spread by Jitter, and giving up once the next wait would take the
+// retries past MaxElapsed. It returns nil once the event is accepted, ctx.Err() if ctx is done
+// first, ErrBusClosed if the bus is or becomes closed, a validator's error, wrapping
+// ErrInvalidEvent, at once, and otherwise an error wrapping ErrEventDropped once the attempts
+// run out. Every offer goes through middleware and the rate
+// limit, and a refused one was never placed, so retrying never produces the event twice.
+func (bus *MainBus[T]) ProduceWithBackoff(ctx context.Context, ev Event[T], policy RetryPolicy) error {
+	start := bus.now()
+	attempts := max(policy.MaxAttempts, 1)
+	n := 1
+	for ; ; n++ {
+		if bus.isClosed() {
+			return ErrBusClosed
+		}
+		switch err := bus.TryProduceErr(ev); {
+		case err == nil:
+			return nil
+		case errors.Is(err, ErrInvalidEvent):
+			return err // no retry makes it valid
+		}
+		if n == attempts {
+			break
+		}
+		d := policy.wait(n, bus.rand)
+		if policy.MaxElapsed > 0 && bus.since(start)+d > policy.MaxElapsed {
+			break
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-bus.done:
+			t.Stop()
+			return ErrBusClosed
+		}
+		bus.logger.Debug("retrying produce", "resource", bus.Resource, "id", ev.ID, "attempt", n+1)
+	}
+	return fmt.Errorf("main bus %q: event %d refused %d times: %w", bus.Resource, ev.ID, n, ErrEventDropped)
+}
+
+// sleep waits for d, returning false if the conveyors are closed first
+func (bus *MainBus[T]) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-bus.closing:
+		return false
+	}
+}
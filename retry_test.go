@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsumeWithRetry(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithDeadLetter(4))
+	bus.RemoveConveyor(1)
+	attempts := map[int]int{}
+	var mu sync.Mutex
+	handler := func(ev Event[int]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		// event 0 recovers on its third attempt, event 1 never does
+		if attempts[ev.ID]++; ev.ID == 0 && attempts[ev.ID] == 3 {
+			return nil
+		}
+		return errors.New("transient")
+	}
+	bus.Produce(Event[int]{ID: 0})
+	bus.Produce(Event[int]{ID: 1})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWithRetry(0, &wg, handler, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, Multiplier: 2})
+
+	select {
+	case dl := <-bus.deadLetters:
+		if dl.Event.ID != 1 || dl.Reason != "transient" {
+			t.Fatalf("dead letter %+v, want event 1", dl)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("failing event was never dead-lettered")
+	}
+	bus.Close()
+	wg.Wait()
+	if attempts[0] != 3 || attempts[1] != 4 {
+		t.Fatalf("attempts = %v, want 3 for event 0 and 4 for event 1", attempts)
+	}
+	m := bus.Metrics()
+	if m.Retried[0] != 2+3 || m.Failed[0] != 1 {
+		t.Fatalf("Retried = %d, Failed = %d; want 5 and 1", m.Retried[0], m.Failed[0])
+	}
+}
+
+func TestConsumeWithRetryMaxElapsed(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1))
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[int]{})
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(1)
+	start := time.Now()
+	go bus.ConsumeWithRetry(0, &wg, func(Event[int]) error {
+		calls.Add(1)
+		return errors.New("down")
+	}, RetryPolicy{MaxAttempts: 100, BaseDelay: 10 * time.Millisecond, Multiplier: 2, MaxElapsed: 50 * time.Millisecond})
+	for bus.Metrics().Failed[0] == 0 {
+		if time.Since(start) > 2*time.Second {
+			t.Fatal("retries did not stop at MaxElapsed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	bus.Close()
+	wg.Wait()
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("%d calls, want 3 within MaxElapsed", n)
+	}
+	if d := bus.Metrics().Dropped[0]; d != 1 {
+		t.Fatalf("Dropped = %d, want 1 without a dead-letter conveyor", d)
+	}
+}
+
+func TestProduceWithBackoffWaitsForRoom(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(1), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	bus.Produce(Event[int]{ID: 1})
+	bus.Produce(Event[int]{ID: 2}) // both conveyors are now full
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-bus.Conveyors[1] // a consumer drains one event
+	}()
+	err := bus.ProduceWithBackoff(t.Context(), Event[int]{ID: 3}, RetryPolicy{MaxAttempts: 50, BaseDelay: time.Millisecond, Multiplier: 2, Jitter: 0.5, MaxElapsed: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("ProduceWithBackoff: %v", err)
+	}
+	if bus.TotalDepth() != 2 {
+		t.Fatalf("depth %d after the retried produce, want 2", bus.TotalDepth())
+	}
+}
+
+func TestProduceWithBackoffGivesUp(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(0))
+	defer bus.Close()
+	err := bus.ProduceWithBackoff(t.Context(), Event[int]{ID: 1}, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	if !errors.Is(err, ErrEventDropped) {
+		t.Fatalf("exhausted attempts: %v, want ErrEventDropped", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := bus.ProduceWithBackoff(ctx, Event[int]{ID: 2}, RetryPolicy{MaxAttempts: 1000, BaseDelay: time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("cancelled: %v, want context.DeadlineExceeded", err)
+	}
+
+	bus.Close()
+	if err := bus.ProduceWithBackoff(t.Context(), Event[int]{ID: 3}, RetryPolicy{}); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("closed bus: %v, want ErrBusClosed", err)
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	rng := newLockedRand(busConfig{})
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.25}
+	for i := 0; i < 100; i++ {
+		if d := p.wait(2, rng); d < 150*time.Millisecond || d > 250*time.Millisecond {
+			t.Fatalf("jittered wait %v outside 200ms ± 25%%", d)
+		}
+	}
+	if d := (RetryPolicy{BaseDelay: time.Second}).wait(3, rng); d != time.Second {
+		t.Errorf("unjittered constant wait %v, want 1s", d)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RoutingStrategy chooses the conveyor of each produced event, for routing the built-in
+// strategies do not cover. Select returns one of bus.LiveLines(); any other line is replaced by
+// a random live one. Select is called by every producer of the bus and so may run concurrently:
+// it must be safe for concurrent use, and it must not produce to bus itself. It runs on the
+// produce path, before the event is placed, so it should be cheap; bus.Depth and bus.Lag are
+// fine to read. When the chosen conveyor is full, TryProduce goes on to the next live ones as
+// with the built-in strategies.
+type RoutingStrategy[T any] interface {
+	Select(bus *MainBus[T], ev Event[T]) int
+}
+
+// StrategyCustom is the Strategy of a bus routed by the RoutingStrategy given to
+// WithRoutingStrategy. Set on its own it routes at random.
+const StrategyCustom SelectStrategy = -1
+
+// WithRoutingStrategy routes the bus with r, a custom strategy for the bus event type, and sets
+// its Strategy to StrategyCustom. Built-in strategies are selected with WithStrategy, or
+// wrapped with BuiltinStrategy for a custom one to delegate to.
+func WithRoutingStrategy[T any](r RoutingStrategy[T]) Option {
+	return func(c *busConfig) {
+		c.strategy = StrategyCustom
+		c.router = r
+	}
+}
+
+// BuiltinStrategy returns the built-in strategy s as a RoutingStrategy, so a custom strategy can
+// hand some events to it. StrategyWeighted uses the bus weights and StrategyHashKey its key func,
+// routing at random when the bus has none.
+func BuiltinStrategy[T any](s SelectStrategy) RoutingStrategy[T] {
+	return builtinStrategy[T](s)
+}
+
+// builtinStrategy adapts a SelectStrategy to RoutingStrategy
+type builtinStrategy[T any] SelectStrategy
+
+func (s builtinStrategy[T]) Select(bus *MainBus[T], ev Event[T]) int {
+	t := bus.table()
+	if len(t.live) == 0 {
+		return -1
+	}
+	line, _ := bus.selectBy(SelectStrategy(s), t, ev)
+	return line
+}
+
+// routerFor returns the strategy set by WithRoutingStrategy, or an error wrapping
+// ErrInvalidConfig if it was written for events of another type
+func routerFor[T any](cfg busConfig) (RoutingStrategy[T], error) {
+	if cfg.router == nil {
+		return nil, nil
+	}
+	r, ok := cfg.router.(RoutingStrategy[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: routing strategy %T does not route Event[%T]", ErrInvalidConfig, cfg.router, *new(T))
+	}
+	return r, nil
+}
+
+// LiveLines returns the lines that receive produced events, in increasing order, for custom
+// strategies and tools that must skip removed and closed conveyors
+func (bus *MainBus[T]) LiveLines() []int {
+	return slices.Clone(bus.table().live)
+}
+
+// routed asks the bus RoutingStrategy for the line of ev, falling back to a random live line
+// of t if it picks one that is not live
+func (bus *MainBus[T]) routed(t *lineTable[T], ev Event[T]) int {
+	line := bus.router.Select(bus, ev)
+	if _, ok := slices.BinarySearch(t.live, line); ok {
+		return line
+	}
+	return t.live[bus.rand.Intn(len(t.live))]
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// offPeak sends events to the last live conveyor, kept for batch work, outside business hours
+// and round robin over the others during them
+type offPeak[T any] struct {
+	now  func() time.Time
+	peak RoutingStrategy[T]
+}
+
+func (s offPeak[T]) Select(bus *MainBus[T], ev Event[T]) int {
+	live := bus.LiveLines()
+	if h := s.now().Hour(); h < 9 || h >= 17 {
+		return live[len(live)-1]
+	}
+	return s.peak.Select(bus, ev)
+}
+
+func ExampleWithRoutingStrategy() {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC))
+	bus := NewMainBus[string]("iron", WithLines(4),
+		WithRoutingStrategy[string](offPeak[string]{now: clock.Now, peak: BuiltinStrategy[string](StrategyRoundRobin)}))
+	defer bus.Close()
+
+	night, _ := bus.ProduceReturn(Event[string]{Value: "report"})
+	clock.Set(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC))
+	day1, _ := bus.ProduceReturn(Event[string]{Value: "order"})
+	day2, _ := bus.ProduceReturn(Event[string]{Value: "order"})
+	fmt.Println(night, day1, day2)
+	// Output: 3 0 1
+}
+
+// fixedLine routes every event to one line
+type fixedLine int
+
+func (l fixedLine) Select(*MainBus[int], Event[int]) int { return int(l) }
+
+func TestRoutingStrategyInvalidLineFallsBack(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithRoutingStrategy[int](fixedLine(1)))
+	defer bus.Close()
+	if bus.Strategy != StrategyCustom {
+		t.Fatalf("Strategy = %v, want StrategyCustom", bus.Strategy)
+	}
+	if line, err := bus.ProduceReturn(Event[int]{ID: 1}); err != nil || line != 1 {
+		t.Fatalf("ProduceReturn = %d, %v; want conveyor 1", line, err)
+	}
+	if err := bus.CloseConveyor(1); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := bus.ProduceReturn(Event[int]{ID: 2}); err != nil || line != 0 {
+		t.Fatalf("ProduceReturn after closing the chosen conveyor = %d, %v; want live conveyor 0", line, err)
+	}
+	if live := bus.LiveLines(); !slices.Equal(live, []int{0}) {
+		t.Fatalf("LiveLines = %v, want [0]", live)
+	}
+}
+
+// countingStrategy counts its calls while delegating to round robin
+type countingStrategy struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *countingStrategy) Select(bus *MainBus[int], ev Event[int]) int {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	return BuiltinStrategy[int](StrategyRoundRobin).Select(bus, ev)
+}
+
+func TestRoutingStrategyConcurrentProducers(t *testing.T) {
+	s := &countingStrategy{}
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(100), WithRoutingStrategy[int](s))
+	defer bus.Close()
+	var wg sync.WaitGroup
+	for p := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 25 {
+				bus.Produce(Event[int]{ID: p*25 + i + 1})
+			}
+		}()
+	}
+	wg.Wait()
+	if s.calls != 100 {
+		t.Fatalf("Select called %d times, want 100", s.calls)
+	}
+	for line := range 4 {
+		if d := bus.Depth(line); d != 25 {
+			t.Fatalf("conveyor %d holds %d events, want 25 from round robin", line, d)
+		}
+	}
+	clone := bus.CloneConfig("copper")
+	defer clone.Close()
+	if clone.router == nil {
+		t.Fatal("CloneConfig dropped the routing strategy")
+	}
+}
+
+func TestRoutingStrategyWrongType(t *testing.T) {
+	_, err := NewMainBusChecked[string]("iron", WithRoutingStrategy[int](fixedLine(0)))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewMainBusChecked: %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "sync"
+
+// ConsumeSampled consumes a specific conveyor like ConsumeWith, but hands each event to handler
+// only with probability rate (clamped to 0..1). Every event is still taken off the conveyor, so
+// a firehose belt never backs up behind a debugging consumer. Handled and skipped events are
+// counted in BusMetrics.Sampled and BusMetrics.Skipped.
+func (bus *MainBus[T]) ConsumeSampled(line int, wg *sync.WaitGroup, handler func(Event[T]), rate float64) {
+	bus.consumeSampled(line, wg, handler, func() bool { return bus.rand.Float64() < rate })
+}
+
+// ConsumeEveryNth is ConsumeSampled with a deterministic sample: the first event and every nth
+// one after it reach handler. n below one is treated as one.
+func (bus *MainBus[T]) ConsumeEveryNth(line int, wg *sync.WaitGroup, handler func(Event[T]), n int) {
+	n = max(n, 1)
+	seen := 0
+	bus.consumeSampled(line, wg, handler, func() bool {
+		keep := seen%n == 0
+		seen++
+		return keep
+	})
+}
+
+// consumeSampled passes the events for which keep returns true to handler, counting the rest
+func (bus *MainBus[T]) consumeSampled(line int, wg *sync.WaitGroup, handler func(Event[T]), keep func() bool) {
+	st := &bus.belt(line).stats
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		if !keep() {
+			st.skipped.Add(1)
+			return
+		}
+		st.sampled.Add(1)
+		handler(ev)
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConsumeEveryNth(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(10))
+	bus.RemoveConveyor(1)
+	for i := 0; i < 10; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	bus.Close()
+	var got []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeEveryNth(0, &wg, func(ev Event[int]) { got = append(got, ev.ID) }, 4)
+	if len(got) != 3 || got[0] != 0 || got[1] != 4 || got[2] != 8 {
+		t.Fatalf("sampled %v, want [0 4 8]", got)
+	}
+	if m := bus.Metrics(); m.Sampled[0] != 3 || m.Skipped[0] != 7 || m.Depth[0] != 0 {
+		t.Fatalf("sampled %d, skipped %d, depth %d; want 3, 7, 0", m.Sampled[0], m.Skipped[0], m.Depth[0])
+	}
+}
+
+func TestConsumeSampled(t *testing.T) {
+	const n = 4000
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(n))
+	bus.RemoveConveyor(1)
+	for i := 0; i < n; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	bus.Close()
+	handled := 0
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeSampled(0, &wg, func(Event[int]) { handled++ }, 0.25)
+	m := bus.Metrics()
+	if share := float64(handled) / n; share < 0.2 || share > 0.3 {
+		t.Fatalf("handled %.2f of events, want about 0.25", share)
+	}
+	if m.Sampled[0] != uint64(handled) || m.Sampled[0]+m.Skipped[0] != n || m.Consumed[0] != n {
+		t.Fatalf("metrics %+v after handling %d", m, handled)
+	}
+}
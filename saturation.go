@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// saturationSamples is how many fill samples the saturation monitor takes per sustain period
+// unless WithSaturationInterval says otherwise
+const saturationSamples = 5
+
+// saturationConfig holds the WithSaturationAlert settings
+type saturationConfig struct {
+	threshold        float64
+	sustain          time.Duration
+	onAlert, onClear func(line int)
+}
+
+// WithSaturationAlert runs a monitor that samples the fill of every live conveyor, its buffered
+// events over its capacity, and calls onAlert with a conveyor that has been above threshold at
+// every sample for sustain, then onClear once it has been at or below threshold for sustain
+// again. Each callback fires once per change of state, never again while the conveyor stays
+// where it is, and onClear also fires for an alerted conveyor that is removed. Unbuffered
+// conveyors are never saturated. The threshold must satisfy 0 < threshold <= 1; either callback
+// may be nil. The callbacks run on the monitor goroutine, which stops when the bus closes.
+func WithSaturationAlert(threshold float64, sustain time.Duration, onAlert, onClear func(line int)) Option {
+	return func(c *busConfig) {
+		c.saturation = &saturationConfig{threshold: threshold, sustain: sustain, onAlert: onAlert, onClear: onClear}
+	}
+}
+
+// WithSaturationInterval sets how often the WithSaturationAlert monitor samples the conveyors;
+// by default it samples saturationSamples times per sustain period
+func WithSaturationInterval(d time.Duration) Option {
+	return func(c *busConfig) {
+		c.saturationInterval = d
+	}
+}
+
+// validateSaturation checks the WithSaturationAlert settings
+func validateSaturation(cfg busConfig) error {
+	s := cfg.saturation
+	if s == nil {
+		return nil
+	}
+	switch {
+	case s.threshold <= 0 || s.threshold > 1:
+		return fmt.Errorf("%w: saturation threshold %v", ErrInvalidConfig, s.threshold)
+	case s.sustain < 0:
+		return fmt.Errorf("%w: saturation sustain %v is negative", ErrInvalidConfig, s.sustain)
+	case cfg.saturationInterval < 0:
+		return fmt.Errorf("%w: saturation interval %v is negative", ErrInvalidConfig, cfg.saturationInterval)
+	}
+	return nil
+}
+
+// saturationState is what the monitor knows of one conveyor
+type saturationState struct {
+	alerted bool      // onAlert fired and onClear has not since
+	since   time.Time // when the fill last crossed to the other side of alerted; zero if it has not
+}
+
+// startSaturationAlert starts the monitor if the bus was built WithSaturationAlert
+func (bus *MainBus[T]) startSaturationAlert(cfg busConfig) {
+	s := cfg.saturation
+	if s == nil {
+		return
+	}
+	interval := cfg.saturationInterval
+	if interval == 0 {
+		interval = s.sustain / saturationSamples
+	}
+	go bus.runSaturationAlert(*s, max(interval, time.Millisecond))
+}
+
+// runSaturationAlert samples the conveyors every interval until the bus closes
+func (bus *MainBus[T]) runSaturationAlert(s saturationConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	states := make(map[int]*saturationState)
+	for {
+		select {
+		case <-ticker.C:
+		case <-bus.closing:
+			return
+		}
+		now := bus.now()
+		t := bus.table()
+		for line, st := range states {
+			if t.belts[line].removed {
+				if st.alerted && s.onClear != nil {
+					s.onClear(line)
+				}
+				delete(states, line)
+			}
+		}
+		for _, line := range t.live {
+			st := states[line]
+			if st == nil {
+				st = &saturationState{}
+				states[line] = st
+			}
+			b := t.belts[line]
+			above := b.capacity() > 0 && float64(b.depth())/float64(b.capacity()) > s.threshold
+			if above == st.alerted {
+				st.since = time.Time{}
+				continue
+			}
+			if st.since.IsZero() {
+				st.since = now
+			}
+			if now.Sub(st.since) < s.sustain {
+				continue
+			}
+			st.alerted, st.since = above, time.Time{}
+			cb := s.onClear
+			if above {
+				cb = s.onAlert
+				bus.logger.Warn("conveyor saturated", "resource", bus.Resource, "line", line, "threshold", s.threshold)
+			} else {
+				bus.logger.Info("conveyor saturation cleared", "resource", bus.Resource, "line", line)
+			}
+			if cb != nil {
+				cb(line)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSaturationAlertHysteresis(t *testing.T) {
+	var mu sync.Mutex
+	var alerts, clears []int
+	alerted, cleared := make(chan int, 8), make(chan int, 8)
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin),
+		WithSaturationAlert(0.5, 20*time.Millisecond,
+			func(line int) { mu.Lock(); alerts = append(alerts, line); mu.Unlock(); alerted <- line },
+			func(line int) { mu.Lock(); clears = append(clears, line); mu.Unlock(); cleared <- line }),
+		WithSaturationInterval(time.Millisecond))
+	defer bus.Close()
+	for i := 0; i < 6; i++ { // 3 of 4 slots on each conveyor
+		bus.Produce(Event[int]{ID: i})
+	}
+	for seen := map[int]bool{}; len(seen) < 2; {
+		select {
+		case line := <-alerted:
+			seen[line] = true
+		case <-time.After(time.Second):
+			t.Fatalf("alerts %v, want both conveyors", alerts)
+		}
+	}
+	if _, err := CollectN(bus, 0, 3, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-cleared:
+		if line != 0 {
+			t.Fatalf("cleared conveyor %d, want 0", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("conveyor 0 never cleared")
+	}
+	time.Sleep(50 * time.Millisecond) // many more samples in the same states
+	mu.Lock()
+	defer mu.Unlock()
+	if len(alerts) != 2 || len(clears) != 1 {
+		t.Fatalf("alerts %v and clears %v, want each state change reported once", alerts, clears)
+	}
+}
+
+func TestSaturationAlertIgnoresBlips(t *testing.T) {
+	alerted := make(chan int, 1)
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2), WithStrategy(StrategyRoundRobin),
+		WithSaturationAlert(0.5, time.Hour, func(line int) { alerted <- line }, nil),
+		WithSaturationInterval(time.Millisecond))
+	defer bus.Close()
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	select {
+	case line := <-alerted:
+		t.Fatalf("conveyor %d alerted before the sustain period", line)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
+
+func TestSaturationAlertValidation(t *testing.T) {
+	for _, opt := range []Option{
+		WithSaturationAlert(0, time.Second, nil, nil),
+		WithSaturationAlert(1.5, time.Second, nil, nil),
+		WithSaturationAlert(0.8, -time.Second, nil, nil),
+	} {
+		if _, err := NewMainBusChecked[int]("iron", opt); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("NewMainBusChecked: %v, want ErrInvalidConfig", err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+// scheduled is an event waiting in the delay queue
+type scheduled[T any] struct {
+	ev  Event[T]
+	at  time.Time
+	seq uint64 // scheduling order, breaking ties between equal release times
+}
+
+// scheduleHeap orders scheduled events by release time
+type scheduleHeap[T any] []scheduled[T]
+
+func (h scheduleHeap[T]) Len() int { return len(h) }
+func (h scheduleHeap[T]) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h scheduleHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *scheduleHeap[T]) Push(x any)   { *h = append(*h, x.(scheduled[T])) }
+func (h *scheduleHeap[T]) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// delayQueue holds the events of ProduceAt until they are due, released by one goroutine
+type delayQueue[T any] struct {
+	once    sync.Once
+	mu      sync.Mutex
+	queue   scheduleHeap[T]
+	seq     uint64
+	stopped bool          // the bus closed and the queue was emptied; guarded by mu
+	wake    chan struct{} // nudges the goroutine when an earlier event is queued
+}
+
+// ProduceAt holds ev until at on the bus clock and then produces it, as Produce would, for
+// retry-after delays and scheduled tasks. Events wait in a queue ordered by release time, events
+// due at the same time in the order they were scheduled, and a single goroutine the first call
+// starts releases them; an at already past releases ev at once. Middleware, the rate limit and
+// the overflow policy apply at release, and a release waiting for a full conveyor holds back the
+// ones due after it. ev is checked with the bus validators when scheduled, returning an error
+// wrapping ErrInvalidEvent, and an event that cannot be produced when due is logged and counts as
+// dropped for its OnDropped callback. The wait runs on the wall clock, re-reading a clock set with
+// WithClock every few milliseconds, so advancing a FakeClock releases what became due. Events
+// still waiting when the bus stops accepting produces are dropped. A closed bus returns
+// ErrBusClosed.
+func (bus *MainBus[T]) ProduceAt(ev Event[T], at time.Time) error {
+	if err := bus.validate(ev); err != nil {
+		return err
+	}
+	q := &bus.delays
+	q.once.Do(func() {
+		q.wake = make(chan struct{}, 1)
+		go bus.runSchedule()
+	})
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped || bus.isClosed() {
+		return ErrBusClosed
+	}
+	q.seq++
+	heap.Push(&q.queue, scheduled[T]{ev: ev, at: at, seq: q.seq})
+	if q.queue[0].seq == q.seq {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// ProduceAfter is ProduceAt for d from now on the bus clock
+func (bus *MainBus[T]) ProduceAfter(ev Event[T], d time.Duration) error {
+	return bus.ProduceAt(ev, bus.now().Add(d))
+}
+
+// runSchedule releases the scheduled events as they fall due, until the bus stops accepting
+// produces
+func (bus *MainBus[T]) runSchedule() {
+	q := &bus.delays
+	_, wall := bus.clock.(realClock)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		now := bus.now()
+		var due []Event[T]
+		for len(q.queue) > 0 && !q.queue[0].at.After(now) {
+			due = append(due, heap.Pop(&q.queue).(scheduled[T]).ev)
+		}
+		wait := time.Duration(-1)
+		if len(q.queue) > 0 {
+			wait = q.queue[0].at.Sub(now)
+			if !wall {
+				wait = min(wait, clockPoll)
+			}
+		}
+		q.mu.Unlock()
+		for _, ev := range due {
+			if err := bus.Produce(ev); err != nil && !errors.Is(err, ErrEventDropped) {
+				bus.logger.Warn("scheduled event not produced", "resource", bus.Resource, "id", ev.ID, "error", err)
+				dropped(ev, "not produced when due")
+			}
+		}
+		if len(due) > 0 {
+			continue // time has passed while producing
+		}
+		var tick <-chan time.Time
+		if wait >= 0 {
+			timer.Reset(wait)
+			tick = timer.C
+		}
+		select {
+		case <-tick:
+		case <-q.wake:
+			timer.Stop()
+		case <-bus.done:
+			q.mu.Lock()
+			q.stopped = true
+			left := q.queue
+			q.queue = nil
+			q.mu.Unlock()
+			for _, s := range left {
+				dropped(s.ev, "bus closed before release")
+			}
+			return
+		}
+	}
+}
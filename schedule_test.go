@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProduceAtReleasesInOrder(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	bus := NewMainBus[int]("copper", WithClock(clock))
+	defer bus.Close()
+	bus.RemoveConveyor(1)
+	// scheduled out of order; 4 and 5 are due together and keep their scheduling order
+	for _, s := range []struct {
+		id    int
+		delay time.Duration
+	}{{3, 3 * time.Minute}, {1, time.Minute}, {4, 5 * time.Minute}, {5, 5 * time.Minute}, {2, 2 * time.Minute}} {
+		if err := bus.ProduceAfter(Event[int]{ID: s.id}, s.delay); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bus.ProduceAt(Event[int]{ID: 6}, epoch.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	released := func(want ...int) {
+		t.Helper()
+		evs, err := CollectN(bus, 0, len(want), time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, ev := range evs {
+			got = append(got, ev.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("released %v, want %v", got, want)
+		}
+		time.Sleep(3 * clockPoll)
+		if n := bus.Depth(0); n != 0 {
+			t.Fatalf("%d more events released early", n)
+		}
+	}
+	time.Sleep(3 * clockPoll)
+	if n := bus.Depth(0); n != 0 {
+		t.Fatalf("%d events released before they were due", n)
+	}
+	clock.Advance(2 * time.Minute)
+	released(1, 2)
+	clock.Advance(4 * time.Minute)
+	released(3, 4, 5)
+	clock.Advance(time.Hour)
+	released(6)
+}
+
+func TestProduceAtClosedBus(t *testing.T) {
+	bus := NewMainBus[int]("copper")
+	reasons := make(chan string, 1)
+	if err := bus.ProduceAfter(Event[int]{ID: 1, OnDropped: func(r string) { reasons <- r }}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	bus.Close()
+	select {
+	case r := <-reasons:
+		if r != "bus closed before release" {
+			t.Fatalf("dropped for %q", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event still held after Close")
+	}
+	if err := bus.ProduceAfter(Event[int]{ID: 2}, time.Minute); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("ProduceAfter on a closed bus = %v, want ErrBusClosed", err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ConsumeAll consumes every conveyor from a single goroutine using reflect.Select, passing the
+// source line to handler with each event. It suits many-belt, low-rate buses where a goroutine
+// per conveyor is wasteful. It returns once all conveyors are closed and drained. Conveyors
+// paused with Pause are not held back by this consumer.
+func (bus *MainBus[T]) ConsumeAll(wg *sync.WaitGroup, handler func(line int, ev Event[T])) {
+	defer wg.Done()
+	belts := bus.table().belts
+	cases := make([]reflect.SelectCase, len(belts))
+	selves := make([]*consumer, len(belts))
+	for i, b := range belts {
+		selves[i] = bus.attach(i)
+		defer selves[i].detach()
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(b.out())}
+	}
+	for open := len(cases); open > 0; {
+		line, v, ok := reflect.Select(cases)
+		if !ok && bus.reopenedCase(line, &cases[line]) {
+			continue
+		}
+		if !ok {
+			cases[line].Chan = reflect.Value{} // a zero Chan is never selected again
+			open--
+			continue
+		}
+		bus.deliver(line, v.Interface().(Event[T]), func(ev Event[T]) { handler(line, ev) })
+		selves[line].done()
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ProducerSession produces events that are consumed in the order they were produced, by
+// pinning all of them to one conveyor chosen when the session starts. Random or load-based
+// strategies can otherwise place two events from the same producer on different conveyors and
+// have them consumed out of order. Obtain sessions from Session.
+type ProducerSession[T any] struct {
+	bus  *MainBus[T]
+	line atomic.Int64
+}
+
+// Session starts a producer session, pinned to the next live conveyor in round-robin order so
+// that sessions spread across the conveyors for parallelism. A session ties up its conveyor's
+// ordering: everything it produces, keyed events included, skips the bus strategy, so a busy
+// session loads one conveyor where the strategy would have spread its events. The order only
+// holds with a single consumer on the conveyor; concurrent consumers may still interleave.
+func (bus *MainBus[T]) Session() *ProducerSession[T] {
+	s := &ProducerSession[T]{bus: bus}
+	s.line.Store(-1)
+	if live := bus.table().live; len(live) > 0 {
+		s.line.Store(int64(live[(bus.sessions.Add(1)-1)%uint64(len(live))]))
+	}
+	return s
+}
+
+// Line returns the conveyor the session is pinned to, or -1 if the bus had none live
+func (s *ProducerSession[T]) Line() int {
+	return int(s.line.Load())
+}
+
+// Produce sends ev on the session's conveyor, with the same middleware, rate limit, overflow
+// policy, persistence and results as Produce. Events are kept in order between calls made one
+// after another; calls from several goroutines at once have no order to keep. Once the pinned
+// conveyor is removed the session moves to the conveyor the bus strategy picks for its next
+// event, and keeps the order from there.
+func (s *ProducerSession[T]) Produce(ev Event[T]) error {
+	return s.ProduceContext(context.Background(), ev)
+}
+
+// ProduceContext is Produce giving up when ctx is done, like the bus ProduceContext
+func (s *ProducerSession[T]) ProduceContext(ctx context.Context, ev Event[T]) error {
+	bus := s.bus
+	return bus.chain(func(ev Event[T]) error {
+		_, err := bus.produceVia(ctx, ev, s.place)
+		return err
+	})(ev)
+}
+
+// place sends ev to the pinned conveyor, re-pinning the session wherever route puts it once that
+// conveyor is gone
+func (s *ProducerSession[T]) place(ctx context.Context, ev Event[T], record bool) (int, error) {
+	bus := s.bus
+	if line := s.Line(); line >= 0 {
+		if err := bus.send(ctx, line, bus.table().belts[line], ev, record); err != errBeltClosed {
+			return line, err
+		}
+	}
+	line, err := bus.route(ctx, ev, record)
+	if err == nil && line >= 0 {
+		s.line.Store(int64(line))
+	}
+	return line, err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSessionKeepsProduceOrder(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8), WithStrategy(StrategyRandom))
+	sessions := []*ProducerSession[int]{bus.Session(), bus.Session(), bus.Session()}
+	if a, b := sessions[0].Line(), sessions[1].Line(); a == b {
+		t.Fatalf("first two sessions both pinned to conveyor %d, want them spread", a)
+	}
+
+	var mu sync.Mutex
+	got := make(map[int][]int) // session index to the sequence numbers consumed, in order
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(ev Event[int]) {
+			mu.Lock()
+			got[ev.Value] = append(got[ev.Value], ev.ID)
+			mu.Unlock()
+		})
+	}
+	const n = 200
+	var producers sync.WaitGroup
+	for i, s := range sessions {
+		producers.Add(1)
+		go func() {
+			defer producers.Done()
+			for seq := 1; seq <= n; seq++ {
+				if err := s.Produce(Event[int]{ID: seq, Value: i}); err != nil {
+					t.Errorf("session %d: Produce: %v", i, err)
+					return
+				}
+			}
+		}()
+	}
+	producers.Wait()
+	bus.Close()
+	wg.Wait()
+
+	for i := range sessions {
+		seqs := got[i]
+		if len(seqs) != n {
+			t.Fatalf("session %d: consumed %d events, want %d", i, len(seqs), n)
+		}
+		for j, seq := range seqs {
+			if seq != j+1 {
+				t.Fatalf("session %d: event %d consumed at position %d: out of produce order", i, seq, j)
+			}
+		}
+	}
+}
+
+func TestSessionMovesOffRemovedConveyor(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(4))
+	defer bus.Close()
+	s := bus.Session()
+	old := s.Line()
+	if err := bus.RemoveConveyor(old); err != nil {
+		t.Fatal(err)
+	}
+	for id := 1; id <= 3; id++ {
+		if err := s.Produce(Event[int]{ID: id}); err != nil {
+			t.Fatalf("Produce after removal: %v", err)
+		}
+	}
+	if s.Line() == old || bus.Depth(s.Line()) != 3 {
+		t.Fatalf("session on conveyor %d holding %d events, want all 3 off removed conveyor %d", s.Line(), bus.Depth(s.Line()), old)
+	}
+	for id := 1; id <= 3; id++ {
+		if ev := <-bus.Conveyors[s.Line()]; ev.ID != id {
+			t.Fatalf("got event %d, want %d", ev.ID, id)
+		}
+	}
+}
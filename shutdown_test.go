@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrains(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithBuffer(8))
+	var wg sync.WaitGroup
+	var handled int
+	var mu sync.Mutex
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {
+			mu.Lock()
+			handled++
+			mu.Unlock()
+		})
+	}
+	for i := range 10 {
+		bus.Produce(Event[int]{ID: i + 1})
+	}
+	if err := bus.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if handled != 10 {
+		t.Errorf("handled %d events, want 10", handled)
+	}
+	if err := bus.Produce(Event[int]{ID: 11}); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Produce after Shutdown = %v, want ErrBusClosed", err)
+	}
+	if err := bus.Shutdown(context.Background()); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("second Shutdown = %v, want ErrBusClosed", err)
+	}
+}
+
+func TestShutdownTimeoutDropsBuffered(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	gate, started := make(chan struct{}), make(chan struct{}, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	// line 0 has a stuck consumer and line 1 none
+	go bus.ConsumeWith(0, &wg, func(Event[int]) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-gate
+	})
+	for i := range 10 {
+		bus.Produce(Event[int]{ID: i + 1})
+	}
+	<-started
+	buffered := bus.TotalDepth()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := bus.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want a deadline error", err)
+	}
+	var dropped uint64
+	for _, d := range bus.Metrics().Dropped {
+		dropped += d
+	}
+	if dropped != uint64(buffered) || !strings.Contains(err.Error(), "dropped "+strconv.Itoa(buffered)) {
+		t.Errorf("Shutdown dropped %d events (%v), want the %d buffered", dropped, err, buffered)
+	}
+	if bus.TotalDepth() != 0 {
+		t.Errorf("%d events left buffered", bus.TotalDepth())
+	}
+	close(gate)
+	wg.Wait()
+	if err := bus.Shutdown(context.Background()); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("second Shutdown = %v, want ErrBusClosed", err)
+	}
+}
+
+func TestShutdownAfterClose(t *testing.T) {
+	bus := NewMainBus[int](Iron)
+	bus.Close()
+	if err := bus.Shutdown(context.Background()); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Shutdown after Close = %v, want ErrBusClosed", err)
+	}
+}
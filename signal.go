@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	ossignal "os/signal"
+	"syscall"
+	"time"
+)
+
+// SignalDrainTimeout bounds how long InstallSignalHandler waits for each bus to drain
+var SignalDrainTimeout = 10 * time.Second
+
+// Drainer is a bus that can be shut down gracefully; every *MainBus is one, whatever its event
+// type
+type Drainer interface {
+	Drain(ctx context.Context) error
+	Close() error
+}
+
+// InstallSignalHandler shuts buses down when the process gets SIGINT or SIGTERM and returns a
+// context derived from ctx that is cancelled once they are. Each bus is drained in turn, in the
+// order given so upstream buses can be listed first, for at most SignalDrainTimeout; a bus still
+// holding events then is closed anyway. Its consumers go on handling the events left on the
+// closed conveyors for as long as the process runs, so events not handled by exit are lost
+// unless the bus persists them for ReplayFile. The handler acts on the first signal only and
+// then stops listening, so a second signal kills the process as usual. Buses already closed are
+// skipped, so installing several handlers over the same buses is harmless. If ctx is cancelled
+// first the handler stops listening without touching the buses.
+func InstallSignalHandler(ctx context.Context, buses ...Drainer) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+	ossignal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer cancel()
+		defer ossignal.Stop(signals)
+		select {
+		case sig := <-signals:
+			slog.Info("main bus: shutting down", "signal", sig.String())
+			shutdownBuses(SignalDrainTimeout, buses...)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx
+}
+
+// shutdownBuses drains each bus for at most timeout, closing it if it does not empty in time
+func shutdownBuses(timeout time.Duration, buses ...Drainer) {
+	for _, bus := range buses {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := bus.Drain(ctx)
+		cancel()
+		if err != nil && !errors.Is(err, ErrBusClosed) {
+			slog.Warn("main bus: drain timed out, closing with events buffered", "error", err)
+			bus.Close()
+		}
+	}
+}
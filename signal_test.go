@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitDone fails the test unless ctx is cancelled within a few seconds
+func waitDone(t *testing.T, ctx context.Context) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled after the signal")
+	}
+}
+
+func TestSignalHandlerDrainsBuses(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	var handled sync.WaitGroup
+	handled.Add(5)
+	for id := 1; id <= 5; id++ {
+		bus.Produce(Event[int]{ID: id})
+	}
+	// two handlers over the same bus: the second finds it closed and skips it
+	first := InstallSignalHandler(context.Background(), bus)
+	second := InstallSignalHandler(context.Background(), bus, bus)
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {
+			time.Sleep(time.Millisecond)
+			handled.Done()
+		})
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	waitDone(t, first)
+	waitDone(t, second)
+	wg.Wait()
+	handled.Wait()
+	if err := bus.Produce(Event[int]{ID: 6}); err != ErrBusClosed {
+		t.Fatalf("Produce after shutdown: %v, want ErrBusClosed", err)
+	}
+}
+
+func TestSignalHandlerClosesAfterTimeout(t *testing.T) {
+	defer func(d time.Duration) { SignalDrainTimeout = d }(SignalDrainTimeout)
+	SignalDrainTimeout = 20 * time.Millisecond
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	bus.Produce(Event[int]{ID: 1})
+	ctx := InstallSignalHandler(context.Background(), bus)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	waitDone(t, ctx)
+	if err := bus.Produce(Event[int]{ID: 2}); err != ErrBusClosed {
+		t.Fatalf("Produce after shutdown: %v, want ErrBusClosed", err)
+	}
+	if d := bus.TotalDepth(); d != 1 {
+		t.Fatalf("depth %d after a timed-out drain, want the unconsumed event left", d)
+	}
+}
+
+func TestSignalHandlerStopsWithContext(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1))
+	defer bus.Close()
+	parent, cancel := context.WithCancel(context.Background())
+	ctx := InstallSignalHandler(parent, bus)
+	cancel()
+	waitDone(t, ctx)
+	if err := bus.Produce(Event[int]{ID: 1}); err != nil {
+		t.Fatalf("bus shut down without a signal: %v", err)
+	}
+}
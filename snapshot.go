@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync/atomic"
+)
+
+// produceHold lets Snapshot pause every producer while it empties the conveyors. Producers
+// enter it before sending on a conveyor and leave once the send is over.
+type produceHold struct {
+	inflight atomic.Int64                  // producers between enter and leave
+	held     atomic.Pointer[chan struct{}] // closed when the hold is released; nil if not held
+}
+
+// enter waits while the hold is taken. It returns ctx.Err() or ErrBusClosed if the wait is cut
+// short, in which case the caller must not call leave.
+func (h *produceHold) enter(ctx context.Context, done <-chan struct{}) error {
+	for {
+		h.inflight.Add(1)
+		p := h.held.Load()
+		if p == nil {
+			return nil
+		}
+		h.inflight.Add(-1)
+		select {
+		case <-*p:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return ErrBusClosed
+		}
+	}
+}
+
+// tryEnter is enter for non-blocking producers: it reports false while the hold is taken
+func (h *produceHold) tryEnter() bool {
+	h.inflight.Add(1)
+	if h.held.Load() != nil {
+		h.inflight.Add(-1)
+		return false
+	}
+	return true
+}
+
+// leave ends a send started with enter or tryEnter
+func (h *produceHold) leave() {
+	h.inflight.Add(-1)
+}
+
+// pause takes the hold and returns the func releasing it
+func (h *produceHold) pause() func() {
+	release := make(chan struct{})
+	h.held.Store(&release)
+	return func() {
+		h.held.Store(nil)
+		close(release)
+	}
+}
+
+// Snapshot takes every event buffered on the bus off its conveyor and returns them, pausing
+// produces until it is done; producers that were already blocked on a full conveyor complete
+// and their events are included. Consumers keep running, so an event is either in the snapshot
+// or delivered, never both. Events are grouped by conveyor, each conveyor's in FIFO order;
+// ordering across conveyors is not preserved. Snapshot never waits for consumers and returns
+// ErrBusClosed on a closed bus.
+func (bus *MainBus[T]) Snapshot() ([]Event[T], error) {
+	bus.snapMu.Lock()
+	defer bus.snapMu.Unlock()
+	// the layout and the conveyors stay open while the read lock is held
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	if bus.closed {
+		return nil, ErrBusClosed
+	}
+	release := bus.hold.pause()
+	defer release()
+
+	t := bus.table()
+	byLine := make([][]Event[T], len(t.belts))
+	for {
+		for line, b := range t.belts {
+			byLine[line] = bus.drainLine(line, b, byLine[line])
+		}
+		if bus.hold.inflight.Load() == 0 && bus.TotalDepth() == 0 {
+			break
+		}
+		runtime.Gosched() // let in-flight producers land their events
+	}
+	var events []Event[T]
+	for _, evs := range byLine {
+		events = append(events, evs...)
+	}
+	return events, nil
+}
+
+// DrainAll takes every event buffered on the bus off its conveyor and returns them, for batch or
+// cron-style jobs that sweep the bus periodically and process the events synchronously instead
+// of running consumers. Unlike Snapshot it never holds off producers: it takes what each
+// conveyor holds when it gets there, so events produced meanwhile may or may not be included,
+// and the bus stays open for more produces. Events are grouped by conveyor, each conveyor's in
+// the order its consumers would get them. A running consumer competes for the same events, each
+// going to one or the other.
+func (bus *MainBus[T]) DrainAll() []Event[T] {
+	// the layout and the conveyors stay open while the read lock is held
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	var events []Event[T]
+	for line, b := range bus.table().belts {
+		if b.buf == nil {
+			events = bus.drainLine(line, b, events)
+		} else {
+			for _, ev := range b.sweep() {
+				bus.onConsumed(line)
+				if !internal(ev) {
+					events = append(events, ev)
+				}
+			}
+		}
+		b.finish()
+	}
+	return events
+}
+
+// Inspect returns a copy of the events buffered on a conveyor, oldest first, leaving them in
+// place: they are taken off and put back in order while producers to that conveyor are held
+// off, so Inspect waits for sends already under way, including ones blocked on a full conveyor
+// until a consumer makes room. Consumers keep running, and the copy is a point-in-time view that
+// may be stale as soon as it is returned. A closed conveyor cannot be refilled and yields nil, as
+// does one built WithBufferFactory, whose Buffer cannot be read in place.
+// Inspect panics if line is out of range.
+func (bus *MainBus[T]) Inspect(line int) []Event[T] {
+	b := bus.belt(line)
+	bus.snapMu.Lock()
+	defer bus.snapMu.Unlock()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.buf != nil {
+		return nil
+	}
+	evs := b.takeAll()
+	// nobody else sends on c while it is locked, so every event fits back
+	for _, ev := range evs {
+		b.c() <- ev
+	}
+	return slices.DeleteFunc(evs, func(ev Event[T]) bool { return ev.poison || ev.barrier != nil })
+}
+
+// takeAll takes every event buffered on the conveyor without blocking or counting them as
+// consumed, for callers that put them back
+func (b *belt[T]) takeAll() []Event[T] {
+	var evs []Event[T]
+	for len(b.c()) > 0 {
+		select {
+		case ev := <-b.c():
+			evs = append(evs, ev)
+		default:
+		}
+	}
+	return evs
+}
+
+// drainLine appends the events buffered on one conveyor to evs without blocking
+func (bus *MainBus[T]) drainLine(line int, b *belt[T], evs []Event[T]) []Event[T] {
+	for {
+		select {
+		case ev, ok := <-b.out():
+			if !ok {
+				return evs
+			}
+			bus.onConsumed(line)
+			if !internal(ev) {
+				evs = append(evs, ev)
+			}
+		default:
+			return evs
+		}
+	}
+}
+
+// Restore re-enqueues events across the conveyors using the bus strategy, in slice order, so
+// each conveyor's share keeps its relative order. The events skip middleware and the rate limit
+// but are written to the persistence log like produced ones. Restore blocks while the chosen
+// conveyor is full and stops at the first event that cannot be placed, reporting how many were.
+func (bus *MainBus[T]) Restore(events []Event[T]) error {
+	for i, ev := range events {
+		if _, err := bus.route(context.Background(), ev, true); err != nil {
+			return fmt.Errorf("main bus %q: restored %d of %d events: %w", bus.Resource, i, len(events), err)
+		}
+		bus.occupy(1)
+	}
+	return nil
+}
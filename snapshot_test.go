@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(50), WithStrategy(StrategyRoundRobin))
+	for i := 0; i < 60; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	events, err := bus.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if len(events) != 60 || bus.TotalDepth() != 0 {
+		t.Fatalf("snapshot took %d events, %d left buffered", len(events), bus.TotalDepth())
+	}
+	// round robin put even IDs on line 0 and odd on line 1; each conveyor's run stays in order
+	for i, ev := range events {
+		want := 2 * i
+		if i >= 30 {
+			want = 2*(i-30) + 1
+		}
+		if ev.ID != want {
+			t.Fatalf("events[%d].ID = %d, want %d", i, ev.ID, want)
+		}
+	}
+	bus.Close()
+
+	next := NewMainBus[int]("iron", WithLines(2), WithBuffer(50))
+	if err := next.Restore(events); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if next.TotalDepth() != 60 {
+		t.Fatalf("restored %d events, want 60", next.TotalDepth())
+	}
+	next.Close()
+	if _, err := next.Snapshot(); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Snapshot on closed bus: %v, want ErrBusClosed", err)
+	}
+	if err := next.Restore(events); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Restore on closed bus: %v, want ErrBusClosed", err)
+	}
+}
+
+func TestSnapshotWithConcurrentProducers(t *testing.T) {
+	const producers, each = 4, 500
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(8))
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				if err := bus.Produce(Event[int]{ID: p*each + i}); err != nil {
+					t.Errorf("Produce: %v", err)
+					return
+				}
+			}
+		}(p)
+	}
+	seen := map[int]bool{}
+	record := func(events []Event[int]) {
+		for _, ev := range events {
+			if seen[ev.ID] {
+				t.Fatalf("event %d seen twice", ev.ID)
+			}
+			seen[ev.ID] = true
+		}
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		events, err := bus.Snapshot()
+		if err != nil {
+			t.Fatalf("Snapshot: %v", err)
+		}
+		record(events)
+		time.Sleep(time.Millisecond)
+	}
+	events, _ := bus.Snapshot()
+	record(events)
+	bus.Close()
+	if len(seen) != producers*each {
+		t.Fatalf("snapshots held %d events, want %d", len(seen), producers*each)
+	}
+}
+
+func TestInspectLeavesEventsInPlace(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(4))
+	p := ProducerPool(bus, 1)[0]
+	for id := 1; id <= 3; id++ {
+		p.Send(Event[int]{ID: id})
+	}
+	for range 2 {
+		got := bus.Inspect(0)
+		if len(got) != 3 || got[0].ID != 1 || got[2].ID != 3 {
+			t.Fatalf("Inspect = %v, want events 1 to 3", got)
+		}
+	}
+	if d := bus.Depth(0); d != 3 {
+		t.Fatalf("depth after Inspect = %d, want 3", d)
+	}
+	if m := bus.Metrics(); m.Consumed[0] != 0 {
+		t.Fatalf("Inspect counted %d events as consumed", m.Consumed[0])
+	}
+	if got := bus.Inspect(1); len(got) != 0 {
+		t.Fatalf("Inspect of an empty conveyor = %v", got)
+	}
+	bus.Close()
+	if got := bus.Inspect(0); got != nil {
+		t.Fatalf("Inspect of a closed conveyor = %v, want nil", got)
+	}
+	for id := 1; id <= 3; id++ {
+		if ev := <-bus.Conveyors[0]; ev.ID != id {
+			t.Fatalf("consumed %d, want %d", ev.ID, id)
+		}
+	}
+}
+
+func TestInspectWhileProducingAndConsuming(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	p := ProducerPool(bus, 1)[0]
+	const n = 2000
+	var wg sync.WaitGroup
+	wg.Add(1)
+	last := 0
+	go bus.ConsumeWith(0, &wg, func(ev Event[int]) {
+		if ev.ID != last+1 {
+			t.Errorf("consumed %d after %d", ev.ID, last)
+		}
+		last = ev.ID
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for id := 1; id <= n; id++ {
+			p.Send(Event[int]{ID: id})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			bus.Close()
+			wg.Wait()
+			if last != n {
+				t.Fatalf("consumed up to %d, want %d", last, n)
+			}
+			return
+		default:
+		}
+		evs := bus.Inspect(0)
+		for i := 1; i < len(evs); i++ {
+			if evs[i].ID != evs[i-1].ID+1 {
+				t.Fatalf("Inspect out of order: %v", evs)
+			}
+		}
+	}
+}
+
+func TestDrainAll(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"channel", nil},
+		{"buffer factory", []Option{WithBufferFactory[int](NewChannelBuffer[int])}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bus := NewMainBus[int]("iron", append([]Option{WithLines(2), WithBuffer(50), WithStrategy(StrategyRoundRobin)}, tc.opts...)...)
+			defer bus.Close()
+			for i := 0; i < 60; i++ {
+				bus.Produce(Event[int]{ID: i})
+			}
+			events := bus.DrainAll()
+			if len(events) != 60 || bus.TotalDepth() != 0 {
+				t.Fatalf("DrainAll took %d events, %d left buffered; want 60 and 0", len(events), bus.TotalDepth())
+			}
+			seen := make(map[int]bool)
+			for _, ev := range events {
+				seen[ev.ID] = true
+			}
+			if len(seen) != 60 {
+				t.Fatalf("DrainAll returned %d distinct events, want 60", len(seen))
+			}
+			if got := bus.DrainAll(); len(got) != 0 {
+				t.Fatalf("second DrainAll took %d events, want 0", len(got))
+			}
+			// the bus stays open for more produces
+			if err := bus.Produce(Event[int]{ID: 100}); err != nil {
+				t.Fatalf("Produce after DrainAll: %v", err)
+			}
+			if got := bus.DrainAll(); len(got) != 1 || got[0].ID != 100 {
+				t.Fatalf("DrainAll after Produce = %v, want event 100", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Splitter reads a single conveyor and sorts its events onto several destination buses,
+// like a Factorio splitter feeding downstream lines
+type Splitter[T any] struct {
+	src     Conveyor[T]
+	dests   []*MainBus[T]
+	route   func(Event[T]) int
+	done    chan struct{}
+	dropped atomic.Uint64 // events lost on the way, see Dropped
+}
+
+// NewSplitter creates a splitter that sends each event from src to dests[route(ev)].
+// Events routed to an index outside dests are dropped.
+func NewSplitter[T any](src Conveyor[T], dests []*MainBus[T], route func(Event[T]) int) *Splitter[T] {
+	return &Splitter[T]{src: src, dests: dests, route: route, done: make(chan struct{})}
+}
+
+// Start runs the splitter on its own goroutine until src is closed or ctx is cancelled. An event
+// a destination refuses, whether invalid or rejected by middleware, is logged to that bus's
+// logger and dropped, and the splitter goes on; one the destination's overflow policy discards
+// is counted by that bus as usual. A closed destination stops the splitter, dropping the event
+// it was given.
+func (s *Splitter[T]) Start(ctx context.Context) {
+	go func() {
+		defer close(s.done)
+		for {
+			select {
+			case ev, ok := <-s.src:
+				if !ok {
+					return
+				}
+				idx := s.route(ev)
+				if idx < 0 || idx >= len(s.dests) {
+					s.drop(ev, "splitter route out of range")
+					continue
+				}
+				dst := s.dests[idx]
+				err := dst.ProduceContext(ctx, ev)
+				switch {
+				case err == nil:
+				case ctx.Err() != nil:
+					return
+				case errors.Is(err, ErrEventDropped):
+					s.dropped.Add(1)
+				case errors.Is(err, ErrBusClosed):
+					dst.logEvent(slog.LevelWarn, "event dropped", -1, ev, slog.String("reason", "splitter destination closed"))
+					s.drop(ev, "splitter destination closed")
+					return
+				default:
+					dst.logEvent(slog.LevelWarn, "event dropped", -1, ev, slog.String("reason", "splitter destination refused it"), slog.Any("error", err))
+					s.drop(ev, "splitter destination refused it")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// drop counts ev as lost by the splitter
+func (s *Splitter[T]) drop(ev Event[T], reason string) {
+	s.dropped.Add(1)
+	dropped(ev, reason)
+}
+
+// Dropped returns how many events the splitter has lost: routed outside dests, refused by their
+// destination or discarded by its overflow policy
+func (s *Splitter[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Done is closed once the splitter goroutine has stopped
+func (s *Splitter[T]) Done() <-chan struct{} {
+	return s.done
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSplitterCountsLostEvents(t *testing.T) {
+	kept := NewMainBus[int]("iron", WithBuffer(8))
+	full := NewMainBus[int]("iron", WithBuffer(1), WithOverflowPolicy(OverflowDropNewest))
+	full.RemoveConveyor(1)
+	strict := NewMainBus[int]("iron", WithBuffer(8), WithValidators(ValidateResource[int]))
+	defer kept.Close()
+	defer full.Close()
+	defer strict.Close()
+	src := make(Conveyor[int], 8)
+	s := NewSplitter(src, []*MainBus[int]{kept, full, strict}, func(ev Event[int]) int { return ev.Value })
+	s.Start(context.Background())
+	src <- Event[int]{ID: 1, Resource: "iron", Value: 0}
+	src <- Event[int]{ID: 2, Resource: "iron", Value: 1}
+	src <- Event[int]{ID: 3, Resource: "iron", Value: 1} // overflows the full destination
+	src <- Event[int]{ID: 4, Value: 2}                   // refused by the validator
+	src <- Event[int]{ID: 5, Resource: "iron", Value: 7} // routed outside dests
+	src <- Event[int]{ID: 6, Resource: "iron", Value: 0} // still delivered after the losses
+	close(src)
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("splitter still running after its source closed")
+	}
+	if kept.TotalDepth() != 2 || full.TotalDepth() != 1 || strict.TotalDepth() != 0 {
+		t.Fatalf("depths %d, %d and %d, want 2, 1 and 0", kept.TotalDepth(), full.TotalDepth(), strict.TotalDepth())
+	}
+	if n := s.Dropped(); n != 3 {
+		t.Fatalf("Dropped = %d, want 3", n)
+	}
+}
+
+func TestSplitterStopsOnClosedDestination(t *testing.T) {
+	dst := NewMainBus[int]("iron")
+	dst.Close()
+	src := make(Conveyor[int], 2)
+	s := NewSplitter(src, []*MainBus[int]{dst}, func(Event[int]) int { return 0 })
+	s.Start(context.Background())
+	var fate string
+	src <- Event[int]{ID: 1, Resource: "iron", OnDropped: func(reason string) { fate = reason }}
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("splitter still running after its destination closed")
+	}
+	if s.Dropped() != 1 || fate != "splitter destination closed" {
+		t.Fatalf("Dropped = %d with fate %q, want the event dropped for the closed destination", s.Dropped(), fate)
+	}
+}
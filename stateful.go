@@ -0,0 +1,21 @@
+package main
+
+import "sync"
+
+// ConsumeStateful consumes a specific conveyor like ConsumeWith, threading a state value of its
+// own through every handler call, so per-conveyor aggregators such as running totals keep their
+// state without closing over shared variables. The state starts as initial and belongs to this
+// consumer alone: handler runs on the consumer goroutine, one event at a time, so it needs no
+// locking, and two consumers of the same conveyor each keep their own. Changes a handler makes
+// before panicking are kept. Once the conveyor is closed and drained, or the consumer takes a
+// pill, final is called with the last state, unless it is nil, before wg is marked done.
+func ConsumeStateful[T, S any](bus *MainBus[T], line int, wg *sync.WaitGroup, initial S, handler func(state *S, ev Event[T]), final func(state S)) {
+	defer wg.Done()
+	state := initial
+	var consumer sync.WaitGroup
+	consumer.Add(1)
+	bus.ConsumeWith(line, &consumer, func(ev Event[T]) { handler(&state, ev) })
+	if final != nil {
+		final(state)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConsumeStatefulRunningSum(t *testing.T) {
+	type totals struct {
+		sum, count int
+		max        int
+	}
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin))
+	for id := 1; id <= 10; id++ {
+		bus.Produce(Event[int]{ID: id, Value: id * 10})
+	}
+	bus.Close()
+	finals := make([]totals, 2)
+	var wg sync.WaitGroup
+	for line := range 2 {
+		wg.Add(1)
+		go ConsumeStateful(bus, line, &wg, totals{max: -1}, func(s *totals, ev Event[int]) {
+			s.sum += ev.Value
+			s.count++
+			s.max = max(s.max, ev.Value)
+		}, func(s totals) { finals[line] = s })
+	}
+	wg.Wait()
+	// round-robin puts the odd IDs on conveyor 0 and the even ones on conveyor 1
+	want := []totals{{sum: 250, count: 5, max: 90}, {sum: 300, count: 5, max: 100}}
+	for line := range 2 {
+		if finals[line] != want[line] {
+			t.Errorf("conveyor %d final state %+v, want %+v", line, finals[line], want[line])
+		}
+	}
+}
+
+func TestConsumeStatefulWithoutFinal(t *testing.T) {
+	bus := NewMainBus[int]("iron")
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[int]{ID: 1, Value: 3})
+	bus.Close()
+	var seen []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	ConsumeStateful(bus, 0, &wg, 0, func(n *int, ev Event[int]) {
+		*n += ev.Value
+		seen = append(seen, *n)
+	}, nil)
+	if len(seen) != 1 || seen[0] != 3 {
+		t.Fatalf("states %v, want [3]", seen)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// SelectStrategy decides which conveyor a produced event is placed on
+type SelectStrategy int
+
+const (
+	// StrategyRandom picks a conveyor uniformly at random (the default)
+	StrategyRandom SelectStrategy = iota
+	// StrategyRoundRobin cycles through the conveyors in order
+	StrategyRoundRobin
+	// StrategyLeastLoaded picks the conveyor with the fewest buffered events, breaking ties at random
+	StrategyLeastLoaded
+	// StrategyWeighted picks a conveyor at random in proportion to the weights given with
+	// WithWeights or SetWeights
+	StrategyWeighted
+	// StrategyHashKey sends every event with the same key, as given by WithKeyFunc, to the same
+	// conveyor, preserving per-key order. Events with an empty key are routed at random.
+	StrategyHashKey
+	// StrategyPriorityAware routes by tier: events whose Priority is at least the bus priority
+	// threshold, DefaultPriorityThreshold unless set with WithPriorityThreshold, go to the
+	// conveyor with the fewest buffered events, as with StrategyLeastLoaded, so urgent items land
+	// on the belt that drains soonest; the rest are spread round-robin
+	StrategyPriorityAware
+	// StrategyConsistentHash pins keys like StrategyHashKey, with the same WithKeyFunc keys, but
+	// places them with a hash ring, WithVirtualNodes points per conveyor, instead of the key hash
+	// modulo the conveyor count. When a conveyor is added or removed, for instance by
+	// WithAutoscale, modulo sends most keys to a different conveyor, while the ring only moves
+	// the keys taken over by the new conveyor, or those of the one removed: about 1/n of them
+	// with n conveyors. The price is a lookup that searches the ring instead of one division, a
+	// ring rebuilt on the first produce after each change, and a spread of keys that is only as
+	// even as the virtual nodes make it.
+	StrategyConsistentHash
+)
+
+// DefaultPriorityThreshold is the lowest Priority StrategyPriorityAware routes as high priority:
+// any event given a positive Priority
+const DefaultPriorityThreshold = 1
+
+// KeyFunc extracts the routing key of an event for StrategyHashKey and StrategyConsistentHash; ""
+// means the event has no key
+type KeyFunc[T any] func(Event[T]) string
+
+// selectLine returns the line ev should go to, chosen among the live lines of t, and whether it
+// was pinned there by its key. t must have at least one live line.
+func (bus *MainBus[T]) selectLine(t *lineTable[T], ev Event[T]) (int, bool) {
+	if bus.Strategy == StrategyCustom && bus.router != nil {
+		return bus.routed(t, ev), false
+	}
+	return bus.selectBy(bus.Strategy, t, ev)
+}
+
+// selectBy is selectLine for the built-in strategy s
+func (bus *MainBus[T]) selectBy(s SelectStrategy, t *lineTable[T], ev Event[T]) (int, bool) {
+	if (s == StrategyHashKey || s == StrategyConsistentHash) && bus.keyFunc != nil {
+		if key := bus.keyFunc(ev); key != "" {
+			if s == StrategyConsistentHash {
+				return bus.ringOf(t).lookup(key), true
+			}
+			return hashKey(t, key), true
+		}
+	}
+	if s == StrategyPriorityAware {
+		if ev.Priority >= bus.urgent {
+			return leastLoaded(t, bus.rand), false
+		}
+		s = StrategyRoundRobin
+	}
+	return bus.pickLine(s, t), false
+}
+
+// keyed reports whether ev is pinned to the conveyor of its key by StrategyHashKey or
+// StrategyConsistentHash
+func (bus *MainBus[T]) keyed(ev Event[T]) bool {
+	pinned := bus.Strategy == StrategyHashKey || bus.Strategy == StrategyConsistentHash
+	return pinned && bus.keyFunc != nil && bus.keyFunc(ev) != ""
+}
+
+// pickLine applies the built-in strategy s to an event without a key
+func (bus *MainBus[T]) pickLine(s SelectStrategy, t *lineTable[T]) int {
+	n := len(t.live)
+	switch s {
+	case StrategyRoundRobin:
+		return t.live[(bus.next.Add(1)-1)%uint64(n)]
+	case StrategyLeastLoaded:
+		return leastLoaded(t, bus.rand)
+	case StrategyWeighted:
+		return weighted(t, bus.weights.Load(), bus.rand)
+	default:
+		return t.live[bus.rand.Intn(n)]
+	}
+}
+
+// leastLoaded scans the live conveyors for the smallest buffered length. Ties are broken uniformly
+// at random using reservoir sampling so no extra slice is allocated.
+func leastLoaded[T any](t *lineTable[T], rng *lockedRand) int {
+	best, low, ties := 0, -1, 0
+	for _, i := range t.live {
+		switch l := t.belts[i].depth(); {
+		case low < 0 || l < low:
+			best, low, ties = i, l, 1
+		case l == low:
+			ties++
+			if rng.Intn(ties) == 0 {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// hashKey maps key onto a live line. The mapping is stable while the live lines stay the same.
+func hashKey[T any](t *lineTable[T], key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return t.live[h.Sum32()%uint32(len(t.live))]
+}
+
+// weighted picks a live line at random in proportion to its weight. Lines beyond the end of
+// weights (added after the weights were set) weigh 1; if every live line weighs 0 the pick is
+// uniform.
+func weighted[T any](t *lineTable[T], weights *[]int, rng *lockedRand) int {
+	weight := func(line int) int {
+		if weights == nil || line >= len(*weights) {
+			return 1
+		}
+		return (*weights)[line]
+	}
+	total := 0
+	for _, line := range t.live {
+		total += weight(line)
+	}
+	if total == 0 {
+		return t.live[rng.Intn(len(t.live))]
+	}
+	r := rng.Intn(total)
+	for _, line := range t.live {
+		if r -= weight(line); r < 0 {
+			return line
+		}
+	}
+	return t.live[len(t.live)-1]
+}
+
+// SetWeights replaces the per-conveyor weights used by StrategyWeighted. There must be one
+// non-negative weight per line, including removed ones. It returns an error wrapping
+// ErrInvalidConfig otherwise, leaving the current weights in place.
+func (bus *MainBus[T]) SetWeights(weights []int) error {
+	if err := validateWeights(weights, len(bus.table().belts)); err != nil {
+		return fmt.Errorf("main bus %q: %w", bus.Resource, err)
+	}
+	w := append([]int(nil), weights...)
+	bus.weights.Store(&w)
+	return nil
+}
+
+// validateWeights checks weights against a bus with the given number of lines
+func validateWeights(weights []int, lines int) error {
+	if len(weights) != lines {
+		return fmt.Errorf("%w: %d weights for %d conveyors", ErrInvalidConfig, len(weights), lines)
+	}
+	for line, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("%w: negative weight %d for conveyor %d", ErrInvalidConfig, w, line)
+		}
+	}
+	return nil
+}
+
+// keyFuncFor returns the key func set by WithKeyFunc, or an error wrapping ErrInvalidConfig if
+// it was written for events of another type
+func keyFuncFor[T any](cfg busConfig) (KeyFunc[T], error) {
+	if cfg.keyFunc == nil {
+		return nil, nil
+	}
+	f, ok := cfg.keyFunc.(KeyFunc[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: key func %T does not take Event[%T]", ErrInvalidConfig, cfg.keyFunc, *new(T))
+	}
+	return f, nil
+}
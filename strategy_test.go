@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// benchStrategy produces b.N events onto a bus whose first conveyor is drained much more slowly
+// than the others, reporting the deepest buffer seen on that conveyor
+func benchStrategy(b *testing.B, s SelectStrategy) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(64), WithStrategy(s))
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		delay := time.Microsecond
+		if line == 0 {
+			delay = 50 * time.Microsecond
+		}
+		go bus.ConsumeWith(line, &wg, func(Event[int]) { time.Sleep(delay) })
+	}
+
+	maxDepth := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bus.Produce(Event[int]{ID: i})
+		maxDepth = max(maxDepth, bus.Depth(0))
+	}
+	b.StopTimer()
+	bus.Close()
+	wg.Wait()
+	b.ReportMetric(float64(maxDepth), "slow-depth")
+}
+
+func BenchmarkStrategyRandom(b *testing.B) {
+	benchStrategy(b, StrategyRandom)
+}
+
+func BenchmarkStrategyRoundRobin(b *testing.B) {
+	benchStrategy(b, StrategyRoundRobin)
+}
+
+func BenchmarkStrategyLeastLoaded(b *testing.B) {
+	benchStrategy(b, StrategyLeastLoaded)
+}
+
+func TestStrategyWeighted(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(4000), WithWeights([]int{1, 0, 3, 0}))
+	defer bus.Close()
+	for i := 0; i < 4000; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	m := bus.Metrics()
+	if m.Produced[1] != 0 || m.Produced[3] != 0 {
+		t.Fatalf("zero-weight conveyors got events: %v", m.Produced)
+	}
+	if share := float64(m.Produced[2]) / 4000; share < 0.7 || share > 0.8 {
+		t.Fatalf("weight-3 conveyor got %.2f of events, want about 0.75", share)
+	}
+
+	if err := bus.SetWeights([]int{0, 1, 0, 0}); err != nil {
+		t.Fatalf("SetWeights: %v", err)
+	}
+	bus.Produce(Event[int]{})
+	if bus.Metrics().Produced[1] != 1 {
+		t.Fatal("SetWeights did not take effect")
+	}
+}
+
+func TestStrategyWeightedValidation(t *testing.T) {
+	if _, err := NewMainBusChecked[int]("iron", WithLines(2), WithWeights([]int{1})); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("short weights: %v, want ErrInvalidConfig", err)
+	}
+	bus := NewMainBus[int]("iron", WithLines(2))
+	defer bus.Close()
+	if err := bus.SetWeights([]int{1, -1}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("negative weight: %v, want ErrInvalidConfig", err)
+	}
+	if err := bus.SetWeights([]int{1, 2, 3}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("long weights: %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestStrategyHashKey(t *testing.T) {
+	keys := []string{"press-1", "press-2", "smelter-7", "assembler-3", "drill-9"}
+	bus := NewMainBus[string]("iron", WithLines(4), WithBuffer(1000),
+		WithKeyFunc(func(ev Event[string]) string { return ev.Value }))
+	for i := 0; i < 500; i++ {
+		bus.Produce(Event[string]{ID: i, Value: keys[i%len(keys)]})
+	}
+	bus.Close()
+	lineOf := map[string]int{}
+	last := map[string]int{}
+	for line, c := range bus.Conveyors {
+		for ev := range c {
+			if l, ok := lineOf[ev.Value]; ok && l != line {
+				t.Fatalf("key %q on conveyors %d and %d", ev.Value, l, line)
+			}
+			if id, ok := last[ev.Value]; ok && ev.ID < id {
+				t.Fatalf("key %q out of order: %d after %d", ev.Value, ev.ID, id)
+			}
+			lineOf[ev.Value], last[ev.Value] = line, ev.ID
+		}
+	}
+	if len(lineOf) != len(keys) {
+		t.Fatalf("saw %d keys, want %d", len(lineOf), len(keys))
+	}
+}
+
+func TestStrategyHashKeyUnkeyedAndMismatch(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(4), WithBuffer(1000),
+		WithKeyFunc(func(ev Event[string]) string { return ev.Value }))
+	defer bus.Close()
+	for i := 0; i < 400; i++ {
+		bus.Produce(Event[string]{ID: i})
+	}
+	for line, n := range bus.Metrics().Produced {
+		if n == 0 {
+			t.Fatalf("unkeyed events never reached conveyor %d", line)
+		}
+	}
+	if _, err := NewMainBusChecked[int]("iron", WithKeyFunc(func(ev Event[string]) string { return "" })); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched key func: %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestStrategyPriorityAware(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(4), WithBuffer(10), WithPriorityThreshold(5))
+	defer bus.Close()
+	if bus.Strategy != StrategyPriorityAware {
+		t.Fatalf("strategy %v, want StrategyPriorityAware", bus.Strategy)
+	}
+	// low priority events go round-robin, leaving conveyor 3 one event short
+	for id := range 7 {
+		line, err := bus.ProduceReturn(Event[int]{ID: id, Priority: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != id%4 {
+			t.Fatalf("low priority event %d on conveyor %d, want %d", id, line, id%4)
+		}
+	}
+	line, err := bus.ProduceReturn(Event[int]{ID: 7, Priority: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != 3 {
+		t.Fatalf("high priority event on conveyor %d, want the emptiest, 3", line)
+	}
+	bus.Produce(Event[int]{ID: 8, Priority: 5})
+	bus.Produce(Event[int]{ID: 9, Priority: 9})
+	// both go to emptiest conveyors, which are all level now, so no conveyor gets both
+	if depths := bus.Metrics().Depth; slices.Max(depths) != 3 || slices.Min(depths) != 2 {
+		t.Fatalf("depths %v after two more high priority events, want 2 or 3 each", depths)
+	}
+	if clone := bus.CloneConfig("copper"); clone.Strategy != StrategyPriorityAware || clone.urgent != 5 {
+		t.Fatalf("clone strategy %v threshold %d, want StrategyPriorityAware at 5", clone.Strategy, clone.urgent)
+	}
+	if d := NewMainBus[int]("iron", WithStrategy(StrategyPriorityAware)); d.urgent != DefaultPriorityThreshold {
+		t.Fatalf("default threshold %d, want %d", d.urgent, DefaultPriorityThreshold)
+	}
+}
+
+func TestStrategyConsistentHashKeepsKeysOnResize(t *testing.T) {
+	byValue := func(ev Event[string]) string { return ev.Value }
+	bus := NewMainBus[string]("iron", WithLines(4), WithBuffer(4096), WithKeyFunc(byValue), WithVirtualNodes(64))
+	defer bus.Close()
+	if bus.Strategy != StrategyConsistentHash || bus.vnodes != 64 {
+		t.Fatalf("strategy %v with %d virtual nodes, want StrategyConsistentHash with 64", bus.Strategy, bus.vnodes)
+	}
+	const keys = 2000
+	assign := func() []int {
+		lines := make([]int, keys)
+		for i := range lines {
+			line, err := bus.ProduceReturn(Event[string]{ID: i, Value: fmt.Sprintf("press-%d", i)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines[i] = line
+		}
+		return lines
+	}
+	before := assign()
+	perLine := map[int]int{}
+	for _, line := range before {
+		perLine[line]++
+	}
+	for line := range 4 {
+		if n := perLine[line]; n < keys/4/2 {
+			t.Errorf("conveyor %d got %d of %d keys", line, n, keys)
+		}
+	}
+	if again := assign(); !slices.Equal(again, before) {
+		t.Fatal("keys changed conveyor without a resize")
+	}
+
+	added := bus.AddConveyor(4096)
+	after := assign()
+	moved := 0
+	for i := range after {
+		if after[i] != before[i] {
+			moved++
+			if after[i] != added {
+				t.Fatalf("key %d moved from conveyor %d to %d, not to the new conveyor", i, before[i], after[i])
+			}
+		}
+	}
+	// the new conveyor takes about a fifth of the keys; modulo would move about four fifths
+	if moved > keys*35/100 || moved < keys*5/100 {
+		t.Fatalf("%d of %d keys moved to the new conveyor", moved, keys)
+	}
+
+	bus.RemoveConveyor(added)
+	if back := assign(); !slices.Equal(back, before) {
+		t.Fatal("removing the added conveyor did not restore the earlier assignment")
+	}
+}
+
+func TestStrategyConsistentHashOptionOrder(t *testing.T) {
+	byValue := func(ev Event[string]) string { return ev.Value }
+	bus := NewMainBus[string]("iron", WithVirtualNodes(0), WithKeyFunc(byValue))
+	defer bus.Close()
+	if bus.Strategy != StrategyConsistentHash {
+		t.Fatalf("strategy %v, want StrategyConsistentHash", bus.Strategy)
+	}
+	if got := len(bus.ringOf(bus.table()).points); got != 2*DefaultVirtualNodes {
+		t.Fatalf("ring has %d points, want %d", got, 2*DefaultVirtualNodes)
+	}
+	clone := bus.CloneConfig("copper")
+	defer clone.Close()
+	if clone.Strategy != StrategyConsistentHash || clone.keyFunc == nil {
+		t.Fatalf("clone strategy %v, key func set %v", clone.Strategy, clone.keyFunc != nil)
+	}
+}
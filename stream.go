@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// busWriter is the io.Writer returned by BusWriter
+type busWriter[T any] struct {
+	bus      *MainBus[T]
+	resource string
+	pending  []byte // the start of a frame not yet complete
+	err      error
+}
+
+// BusWriter returns an io.Writer producing the events written to it, so a bus can sit behind
+// io.Copy, a pipe or a TCP connection. The byte stream is a sequence of frames as ExportTo
+// writes them, each a 4-byte big-endian length followed by one event encoded with the bus codec,
+// compression and key, without the header frame; empty frames are skipped. Frames may be split
+// across Write calls in any way. Events are produced with Produce, so Write blocks while the
+// chosen conveyor is full, and those with no Resource get resource. The first frame that cannot
+// be decoded or produced fails that Write and every later one. The writer is not safe for
+// concurrent use.
+func BusWriter[T any](bus *MainBus[T], resource string) io.Writer {
+	return &busWriter[T]{bus: bus, resource: resource}
+}
+
+// Write implements io.Writer. On error, n counts the bytes of p that completed produced frames.
+func (w *busWriter[T]) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	held := len(w.pending)
+	w.pending = append(w.pending, p...)
+	used := 0
+	for len(w.pending)-used >= 4 {
+		size := binary.BigEndian.Uint32(w.pending[used:])
+		if size > maxHandoffFrame {
+			w.err = fmt.Errorf("main bus %q: writer: frame of %d bytes exceeds the %d byte limit", w.bus.Resource, size, maxHandoffFrame)
+			break
+		}
+		end := used + 4 + int(size)
+		if end > len(w.pending) {
+			break
+		}
+		if size > 0 {
+			if w.err = w.produce(w.pending[used+4 : end]); w.err != nil {
+				break
+			}
+		}
+		used = end
+	}
+	w.pending = append(w.pending[:0], w.pending[used:]...)
+	if w.err != nil {
+		return max(used-held, 0), w.err
+	}
+	return len(p), nil
+}
+
+// produce decodes one frame and produces its event
+func (w *busWriter[T]) produce(data []byte) error {
+	ev, err := w.bus.decodeFrame(data)
+	if err != nil {
+		return fmt.Errorf("main bus %q: writer: %w", w.bus.Resource, err)
+	}
+	if ev.Resource == "" {
+		ev.Resource = w.resource
+	}
+	if err := w.bus.Produce(ev); err != nil {
+		return fmt.Errorf("main bus %q: writer: event %d: %w", w.bus.Resource, ev.ID, err)
+	}
+	return nil
+}
+
+// busReader is the io.Reader returned by BusReader
+type busReader[T any] struct {
+	bus  *MainBus[T]
+	line int
+	c    Conveyor[T]
+	self *consumer
+	buf  bytes.Buffer // encoded frames not yet read
+	err  error
+}
+
+// BusReader returns an io.Reader consuming a specific conveyor and yielding its events in the
+// frame format BusWriter reads, so io.Copy(BusWriter(dst, resource), BusReader(src, line)) moves
+// events from one bus to another. The reader counts as a consumer of the conveyor from the
+// start: each event goes through the same pipeline as ConsumeWith when a Read needs more bytes,
+// and Read blocks until one arrives. An event that cannot be encoded is counted as dropped.
+// Once the conveyor is closed and its events are read, Read returns io.EOF. The reader is not
+// safe for concurrent use.
+func BusReader[T any](bus *MainBus[T], line int) io.Reader {
+	return &busReader[T]{bus: bus, line: line, c: bus.conveyor(line), self: bus.attach(line)}
+}
+
+// Read implements io.Reader
+func (r *busReader[T]) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 && r.err == nil {
+		if r.bus.Paused(r.line) {
+			r.bus.waitResumed(context.Background(), r.line)
+		}
+		ev, ok := <-r.c
+		if !ok && r.bus.reopened(r.line, &r.c) {
+			continue
+		}
+		if !ok || r.bus.poisoned(r.line, ev) {
+			r.err = io.EOF
+			r.self.detach()
+			break
+		}
+		r.bus.deliver(r.line, ev, r.encode)
+		r.self.done()
+	}
+	if r.buf.Len() > 0 {
+		return r.buf.Read(p)
+	}
+	return 0, r.err
+}
+
+// encode appends the frame of ev to the read buffer
+func (r *busReader[T]) encode(ev Event[T]) {
+	data, err := r.bus.encodeFrame(ev)
+	if err != nil {
+		r.bus.drop(slog.LevelWarn, r.line, ev, "not encoded", slog.Any("error", err))
+		return
+	}
+	writeFrame(&r.buf, data)
+}
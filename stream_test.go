@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBusReaderToBusWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+		opts []Option
+	}{
+		{"json", func(r io.Reader) io.Reader { return r }, nil},
+		// one byte per Write splits every frame across calls
+		{"gob one byte at a time", iotest.OneByteReader, []Option{WithCodec[string](GobCodec[string]{}), WithCompression(CompressionGzip)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := NewMainBus[string]("iron", append([]Option{WithLines(1), WithBuffer(8)}, tc.opts...)...)
+			src.RemoveConveyor(1)
+			dst := NewMainBus[string]("iron", append([]Option{WithLines(1), WithBuffer(8)}, tc.opts...)...)
+			dst.RemoveConveyor(1)
+			defer dst.Close()
+			src.Produce(Event[string]{ID: 1, Resource: "iron", Value: "plate"})
+			src.Produce(Event[string]{ID: 2, Value: "gear"})
+			src.Produce(Event[string]{ID: 3, Resource: "iron", Value: "rod", CorrelationID: "order-7"})
+			src.Close()
+
+			n, err := io.Copy(BusWriter(dst, "copper"), tc.wrap(BusReader(src, 0)))
+			if err != nil || n == 0 {
+				t.Fatalf("io.Copy = %d, %v", n, err)
+			}
+			got := dst.DrainAll()
+			if len(got) != 3 {
+				t.Fatalf("destination holds %d events, want 3", len(got))
+			}
+			want := []Event[string]{
+				{ID: 1, Resource: "iron", Value: "plate"},
+				{ID: 2, Resource: "copper", Value: "gear"},
+				{ID: 3, Resource: "iron", Value: "rod", CorrelationID: "order-7"},
+			}
+			for i, ev := range got {
+				w := want[i]
+				if ev.ID != w.ID || ev.Resource != w.Resource || ev.Value != w.Value || ev.CorrelationID != w.CorrelationID {
+					t.Fatalf("event %d = %+v, want %+v", i, ev, w)
+				}
+			}
+			if m := src.Metrics(); m.Consumed[0] != 3 {
+				t.Fatalf("source consumed %d events, want 3", m.Consumed[0])
+			}
+		})
+	}
+}
+
+func TestBusWriterBadFrame(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(8))
+	defer bus.Close()
+	good, err := bus.encodeFrame(Event[string]{ID: 1, Value: "plate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stream bytes.Buffer
+	writeFrame(&stream, good)
+	writeFrame(&stream, nil) // skipped
+	writeFrame(&stream, []byte("not an event"))
+	writeFrame(&stream, good)
+
+	w := BusWriter(bus, "iron")
+	n, err := w.Write(stream.Bytes())
+	if err == nil {
+		t.Fatal("Write of a corrupt frame succeeded")
+	}
+	if want := 4 + len(good) + 4; n != want {
+		t.Fatalf("Write consumed %d bytes, want %d for the frames before the bad one", n, want)
+	}
+	if d := bus.TotalDepth(); d != 1 {
+		t.Fatalf("bus holds %d events, want only the one before the bad frame", d)
+	}
+	if _, again := w.Write(stream.Bytes()); again != err {
+		t.Fatalf("Write after a failure = %v, want the first error %v", again, err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "sync"
+
+// ConsumeTee consumes a specific conveyor like ConsumeWith, handing every event to each of
+// handlers in order on the consumer goroutine, such as one logging it, one persisting it and one
+// recording a metric. Each handler is isolated: a panic is recovered, logged and reported to
+// OnPanic as for any consumer, and the other handlers still run. The event counts as delivered
+// once every handler has returned or panicked.
+func (bus *MainBus[T]) ConsumeTee(line int, wg *sync.WaitGroup, handlers ...func(Event[T])) {
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		for _, h := range handlers {
+			bus.handle(line, ev, h)
+		}
+	})
+}
+
+// ConsumeTeeParallel is ConsumeTee running an event's handlers concurrently; it waits for all of
+// them before taking the next event, so events stay in conveyor order
+func (bus *MainBus[T]) ConsumeTeeParallel(line int, wg *sync.WaitGroup, handlers ...func(Event[T])) {
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		var running sync.WaitGroup
+		running.Add(len(handlers))
+		for _, h := range handlers {
+			go func() {
+				defer running.Done()
+				bus.handle(line, ev, h)
+			}()
+		}
+		running.Wait()
+	})
+}
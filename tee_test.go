@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsumeTeeSequential(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	var calls []string
+	on := func(name string) func(Event[int]) {
+		return func(ev Event[int]) { calls = append(calls, name) }
+	}
+	var panics atomic.Int32
+	bus.OnPanic = func(Event[int], any) { panics.Add(1) }
+	bus.Produce(Event[int]{ID: 1})
+	bus.Produce(Event[int]{ID: 2})
+	bus.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeTee(0, &wg, on("log"), func(Event[int]) { panic("boom") }, on("persist"))
+	if want := []string{"log", "persist"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if panics.Load() != 1 {
+		t.Errorf("OnPanic ran %d times, want 1", panics.Load())
+	}
+}
+
+func TestConsumeTeeParallel(t *testing.T) {
+	bus := NewMainBus[int](Iron, WithLines(2), WithBuffer(8), WithStrategy(StrategyRoundRobin))
+	for i := range 6 {
+		bus.Produce(Event[int]{ID: i + 1})
+	}
+	bus.Close()
+	var running, peak, calls atomic.Int32
+	slow := func(Event[int]) {
+		now := running.Add(1)
+		for p := peak.Load(); now > p && !peak.CompareAndSwap(p, now); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		calls.Add(1)
+	}
+	var order []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeTeeParallel(0, &wg, slow, slow, slow, func(ev Event[int]) {
+		order = append(order, ev.ID)
+	}, func(Event[int]) { panic("boom") })
+	if calls.Load() != 9 {
+		t.Errorf("slow handlers ran %d times, want 9", calls.Load())
+	}
+	if peak.Load() != 3 {
+		t.Errorf("peak concurrency %d, want the 3 slow handlers together", peak.Load())
+	}
+	if !slices.Equal(order, []int{1, 3, 5}) {
+		t.Errorf("events handled in order %v, want [1 3 5]", order)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The helpers below take the goroutine and WaitGroup boilerplate out of tests against a bus.
+// They live in this package rather than a testutil one because the bus is part of package
+// main, which other packages cannot import.
+
+// CollectN consumes exactly n events from a conveyor, through the same pipeline as ConsumeWith,
+// and returns them for assertions. It runs on the calling goroutine, so nothing is left behind
+// when it gives up: after timeout it returns the events collected so far with an error wrapping
+// context.DeadlineExceeded, and if the conveyor closes first with an error wrapping
+// ErrBusClosed. Events that expire under the bus TTL are not collected.
+func CollectN[T any](bus *MainBus[T], line, n int, timeout time.Duration) ([]Event[T], error) {
+	self := bus.attach(line)
+	defer self.detach()
+	c := bus.conveyor(line)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	evs := make([]Event[T], 0, n)
+	collect := func(ev Event[T]) { evs = append(evs, ev) }
+	for len(evs) < n {
+		select {
+		case ev, ok := <-c:
+			if !ok && bus.reopened(line, &c) {
+				continue
+			}
+			if !ok {
+				return evs, fmt.Errorf("main bus %q: conveyor %d closed after %d of %d events: %w", bus.Resource, line, len(evs), n, ErrBusClosed)
+			}
+			bus.deliver(line, ev, collect)
+			self.done()
+		case <-timer.C:
+			return evs, fmt.Errorf("main bus %q: collected %d of %d events from conveyor %d: %w", bus.Resource, len(evs), n, line, context.DeadlineExceeded)
+		}
+	}
+	return evs, nil
+}
+
+// ProduceAll produces evs in order with Produce, stopping at the first error, which it returns
+// along with how many events went in before it
+func ProduceAll[T any](bus *MainBus[T], evs []Event[T]) error {
+	for i, ev := range evs {
+		if err := bus.Produce(ev); err != nil {
+			return fmt.Errorf("main bus %q: produced %d of %d events: %w", bus.Resource, i, len(evs), err)
+		}
+	}
+	return nil
+}
+
+// FakeClock is a Clock that only moves when told to, for deterministic tests of TTL expiry,
+// latency, windows and the other time-dependent behaviour of a bus built WithClock. It is safe
+// for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock reading t
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the clock's current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t, backwards or forwards
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleCollectN() {
+	bus := NewMainBus[string]("iron", WithLines(1), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	ProduceAll(bus, []Event[string]{{ID: 1, Value: "plate"}, {ID: 2, Value: "gear"}, {ID: 3, Value: "rod"}})
+
+	evs, err := CollectN(bus, 0, 2, time.Second)
+	fmt.Println(len(evs), evs[0].Value, evs[1].Value, err)
+	// Output: 2 plate rod <nil>
+}
+
+func TestCollectNTimesOut(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1))
+	defer bus.Close()
+	bus.Produce(Event[int]{ID: 1})
+	evs, err := CollectN(bus, 0, 3, 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CollectN: %v, want a deadline error", err)
+	}
+	// the single event is on one of two conveyors; collect from whichever holds it
+	if len(evs) == 0 {
+		evs, err = CollectN(bus, 1, 3, 20*time.Millisecond)
+	}
+	if len(evs) != 1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("collected %v, %v; want the one event", evs, err)
+	}
+	if c := bus.Health().Conveyors[0].Consumers; c != 0 {
+		t.Fatalf("%d consumers still attached after CollectN returned", c)
+	}
+}
+
+func TestCollectNAndProduceAllOnClosedBus(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1))
+	bus.Close()
+	if _, err := CollectN(bus, 0, 1, time.Second); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("CollectN on a closed conveyor: %v, want ErrBusClosed", err)
+	}
+	if err := ProduceAll(bus, []Event[int]{{ID: 1}}); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("ProduceAll on a closed bus: %v, want ErrBusClosed", err)
+	}
+}
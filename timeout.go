@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConsumeWithHandlerTimeout consumes a specific conveyor like ConsumeWithReject, but gives
+// handler at most timeout per event, through a context that is cancelled when the time is up.
+// An event whose handler has not returned by then, or returned an error with ctx done, is
+// rejected onto the dead-letter conveyor (or counted as dropped without one) and counted in
+// BusMetrics.TimedOut, and the consumer moves on to the next event. Go cannot stop a goroutine,
+// so the handler must honor ctx: one that ignores it keeps running on its own goroutine, counted
+// in BusMetrics.StuckHandlers until it returns, and whatever it does after the timeout is
+// ignored. Any other handler error or panic rejects the event like ConsumeWithReject. A
+// non-positive timeout panics.
+func (bus *MainBus[T]) ConsumeWithHandlerTimeout(line int, wg *sync.WaitGroup, handler func(context.Context, Event[T]) error, timeout time.Duration) {
+	if timeout <= 0 {
+		panic("main bus: ConsumeWithHandlerTimeout needs a positive timeout")
+	}
+	b := bus.belt(line)
+	bus.ConsumeWith(line, wg, func(ev Event[T]) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			var err error
+			if r := bus.handle(line, ev, func(ev Event[T]) { err = handler(ctx, ev) }); r != nil {
+				err = fmt.Errorf("handler panicked: %v", r)
+			}
+			done <- err
+		}()
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			select {
+			case err = <-done:
+			default:
+				bus.stuckHandlers.Add(1)
+				go func() {
+					<-done
+					bus.stuckHandlers.Add(-1)
+				}()
+				err = ctx.Err()
+			}
+		}
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			// a handler giving up on its cancelled context timed out all the same
+			b.stats.timedOut.Add(1)
+			bus.rejectOrDrop(line, ev, fmt.Sprintf("handler timed out after %v", timeout))
+		default:
+			bus.rejectOrDrop(line, ev, err.Error())
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsumeWithHandlerTimeoutIgnoringCancellation(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin), WithDeadLetter(4))
+	release := make(chan struct{})
+	var handled []int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWithHandlerTimeout(0, &wg, func(ctx context.Context, ev Event[int]) error {
+		if ev.ID == 1 {
+			<-release // hangs without looking at ctx
+		}
+		mu.Lock()
+		handled = append(handled, ev.ID)
+		mu.Unlock()
+		return nil
+	}, 20*time.Millisecond)
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[int]{ID: 1})
+	bus.Produce(Event[int]{ID: 2})
+
+	var dl DeadLetter[int]
+	select {
+	case dl = <-bus.deadLetters:
+	case <-time.After(time.Second):
+		t.Fatal("timed-out event was not dead-lettered")
+	}
+	if dl.Event.ID != 1 || dl.Reason != "handler timed out after 20ms" {
+		t.Fatalf("dead letter %d %q, want event 1 timed out", dl.Event.ID, dl.Reason)
+	}
+	// the consumer moved on while the first handler is still stuck
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(handled)
+		mu.Unlock()
+		if n == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m := bus.Metrics()
+	if m.TimedOut[0] != 1 || m.StuckHandlers != 1 {
+		t.Fatalf("timed out %d, stuck %d; want 1 and 1", m.TimedOut[0], m.StuckHandlers)
+	}
+	mu.Lock()
+	if len(handled) != 1 || handled[0] != 2 {
+		t.Fatalf("handled %v while the first handler was stuck, want [2]", handled)
+	}
+	mu.Unlock()
+
+	close(release)
+	for deadline := time.Now().Add(time.Second); bus.Metrics().StuckHandlers != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("stuck handler still counted after it returned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	bus.Close()
+	wg.Wait()
+}
+
+func TestConsumeWithHandlerTimeoutHonoringContext(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithStrategy(StrategyRoundRobin), WithDeadLetter(4))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWithHandlerTimeout(0, &wg, func(ctx context.Context, ev Event[int]) error {
+		switch ev.ID {
+		case 1:
+			<-ctx.Done()
+			return ctx.Err()
+		case 2:
+			return errors.New("bad ore")
+		}
+		return nil
+	}, 10*time.Millisecond)
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[int]{ID: 1})
+	bus.Produce(Event[int]{ID: 2})
+	bus.Produce(Event[int]{ID: 3})
+	for _, want := range []string{"handler timed out after 10ms", "bad ore"} {
+		select {
+		case dl := <-bus.deadLetters:
+			if dl.Reason != want {
+				t.Errorf("dead letter %d reason %q, want %q", dl.Event.ID, dl.Reason, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no dead letter for %q", want)
+		}
+	}
+	bus.Close()
+	wg.Wait()
+	if m := bus.Metrics(); m.TimedOut[0] != 1 || m.StuckHandlers != 0 {
+		t.Errorf("timed out %d, stuck %d; want 1 and 0", m.TimedOut[0], m.StuckHandlers)
+	}
+}
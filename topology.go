@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Topology declares a network of buses joined by splitters, pipes and merges, to be built and
+// started in one go:
+//
+//	NewTopology[Item]().
+//		Bus("iron", WithLines(4)).
+//		Split(isGear, "gears", "plates").
+//		Pipe("gears", assemble, "motors").
+//		Build()
+//
+// Buses named as a stage destination without a Bus call are built with the default options.
+// Mistakes are recorded as they are made and reported by Build.
+type Topology[T any] struct {
+	buses    map[string][]Option // every bus and its options
+	explicit map[string]bool     // buses declared with Bus rather than only as a destination
+	order    []string            // bus names in declaration order
+	stages   []topologyStage[T]
+	current  string // the bus the next Split applies to
+	err      error
+}
+
+// topologyStage is one declared processing step
+type topologyStage[T any] struct {
+	kind  string // "split", "pipe" or "merge", for error messages
+	src   string
+	dests []string
+	route func(Event[T]) (int, Event[T], bool) // destination index and output for an event
+}
+
+// NewTopology starts an empty topology declaration
+func NewTopology[T any]() *Topology[T] {
+	return &Topology[T]{buses: make(map[string][]Option), explicit: make(map[string]bool)}
+}
+
+// Bus declares a bus for resource, built with opts, and makes it the one the next Split applies
+// to. Declaring the same bus twice is an error.
+func (t *Topology[T]) Bus(resource string, opts ...Option) *Topology[T] {
+	if t.explicit[resource] {
+		t.fail("bus %q declared twice", resource)
+		return t
+	}
+	t.declare(resource, opts)
+	t.explicit[resource] = true
+	t.current = resource
+	return t
+}
+
+// Split sorts the events of the bus last declared with Bus onto two buses: those for which
+// predicate is true go to match and the others to rest
+func (t *Topology[T]) Split(predicate func(Event[T]) bool, match, rest string) *Topology[T] {
+	if t.current == "" {
+		t.fail("Split to %q and %q has no source: declare a Bus first", match, rest)
+		return t
+	}
+	return t.stage("split", t.current, []string{match, rest}, func(ev Event[T]) (int, Event[T], bool) {
+		if predicate(ev) {
+			return 0, ev, true
+		}
+		return 1, ev, true
+	})
+}
+
+// Pipe transforms the events of src onto dst, dropping those for which transform returns false,
+// as the Pipe function does for two buses
+func (t *Topology[T]) Pipe(src string, transform func(Event[T]) (Event[T], bool), dst string) *Topology[T] {
+	return t.stage("pipe", src, []string{dst}, func(ev Event[T]) (int, Event[T], bool) {
+		out, ok := transform(ev)
+		return 0, out, ok
+	})
+}
+
+// Merge sends every event of the srcs buses, unchanged, to dst
+func (t *Topology[T]) Merge(dst string, srcs ...string) *Topology[T] {
+	for _, src := range srcs {
+		t.stage("merge", src, []string{dst}, func(ev Event[T]) (int, Event[T], bool) {
+			return 0, ev, true
+		})
+	}
+	return t
+}
+
+// stage records a stage, declaring its destinations
+func (t *Topology[T]) stage(kind, src string, dests []string, route func(Event[T]) (int, Event[T], bool)) *Topology[T] {
+	for _, dst := range dests {
+		if _, ok := t.buses[dst]; !ok {
+			t.declare(dst, nil)
+		}
+	}
+	t.stages = append(t.stages, topologyStage[T]{kind: kind, src: src, dests: dests, route: route})
+	return t
+}
+
+// declare adds a bus, keeping declaration order
+func (t *Topology[T]) declare(resource string, opts []Option) {
+	if _, ok := t.buses[resource]; !ok {
+		t.order = append(t.order, resource)
+	}
+	t.buses[resource] = opts
+}
+
+// fail records the first declaration mistake
+func (t *Topology[T]) fail(format string, args ...any) {
+	if t.err == nil {
+		t.err = fmt.Errorf("topology: %w: "+format, append([]any{ErrInvalidConfig}, args...)...)
+	}
+}
+
+// validate checks for stages reading undeclared buses, buses read by two stages, and cycles
+func (t *Topology[T]) validate() error {
+	if t.err != nil {
+		return t.err
+	}
+	readers := make(map[string]string)
+	for _, s := range t.stages {
+		if _, ok := t.buses[s.src]; !ok {
+			return fmt.Errorf("topology: %w: %s reads bus %q, which is never declared", ErrInvalidConfig, s.kind, s.src)
+		}
+		if other, ok := readers[s.src]; ok {
+			return fmt.Errorf("topology: %w: bus %q feeds both a %s and a %s", ErrInvalidConfig, s.src, other, s.kind)
+		}
+		readers[s.src] = s.kind
+	}
+	if cycle := t.cycle(); cycle != nil {
+		return fmt.Errorf("topology: %w: cycle %s", ErrInvalidConfig, strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// cycle returns the buses of a cycle in the graph, first one repeated at the end, or nil
+func (t *Topology[T]) cycle() []string {
+	next := make(map[string][]string)
+	for _, s := range t.stages {
+		next[s.src] = append(next[s.src], s.dests...)
+	}
+	const (
+		unseen = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(string) []string
+	visit = func(bus string) []string {
+		state[bus] = visiting
+		path = append(path, bus)
+		for _, dst := range next[bus] {
+			switch state[dst] {
+			case visiting:
+				for i, b := range path {
+					if b == dst {
+						return append(append([]string{}, path[i:]...), dst)
+					}
+				}
+			case unseen:
+				if c := visit(dst); c != nil {
+					return c
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[bus] = done
+		return nil
+	}
+	for _, bus := range t.order {
+		if state[bus] == unseen {
+			if c := visit(bus); c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// Build validates the declaration, then builds every bus and starts every stage. Unknown stage
+// sources, a bus read by more than one stage, cycles and invalid bus options return an error
+// wrapping ErrInvalidConfig, with nothing left running.
+func (t *Topology[T]) Build() (*Factory[T], error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	f := &Factory[T]{buses: make(map[string]*MainBus[T]), feeds: make(map[string][]*Stage)}
+	for _, name := range t.order {
+		bus, err := NewMainBusChecked[T](Resource(name), t.buses[name]...)
+		if err != nil {
+			for _, b := range f.buses {
+				b.Close()
+			}
+			return nil, fmt.Errorf("topology: %w", err)
+		}
+		f.buses[name] = bus
+	}
+	f.order = t.sorted()
+	for _, s := range t.stages {
+		dests := make([]*MainBus[T], len(s.dests))
+		for i, name := range s.dests {
+			dests[i] = f.buses[name]
+		}
+		route := s.route
+		stage := pipeRoute(f.buses[s.src], func(ev Event[T]) (*MainBus[T], Event[T], bool) {
+			i, out, ok := route(ev)
+			return dests[i], out, ok
+		})
+		f.stages = append(f.stages, stage)
+		for _, name := range s.dests {
+			f.feeds[name] = append(f.feeds[name], stage)
+		}
+	}
+	return f, nil
+}
+
+// sorted returns the bus names so that every bus comes after the buses feeding it
+func (t *Topology[T]) sorted() []string {
+	upstream := make(map[string]int)
+	next := make(map[string][]string)
+	for _, s := range t.stages {
+		for _, dst := range s.dests {
+			upstream[dst]++
+			next[s.src] = append(next[s.src], dst)
+		}
+	}
+	var order, ready []string
+	for _, name := range t.order {
+		if upstream[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dst := range next[name] {
+			if upstream[dst]--; upstream[dst] == 0 {
+				ready = append(ready, dst)
+			}
+		}
+	}
+	return order
+}
+
+// DOT renders the declared topology in the Graphviz DOT format, for documenting or debugging a
+// network of buses: `dot -Tsvg` draws it. Every bus is a box labelled with its resource, conveyor
+// count and buffer size as they will be built, every split and pipe an ellipse between its source
+// and destinations, with the split edges labelled match and rest, and the merges into a bus one
+// ellipse feeding it. DOT reflects the declaration as it stands and needs neither Build nor a
+// valid topology, so it also helps to see a mistake Build reports.
+func (t *Topology[T]) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n\trankdir=LR;\n")
+	for _, name := range t.order {
+		cfg := newBusConfig(Resource(name), t.buses[name])
+		fmt.Fprintf(&b, "\t%s [shape=box, label=%s];\n", dotID("bus", name),
+			dotQuote(fmt.Sprintf("%s\n%d conveyors, buffer %d", name, busLines(cfg.lines), cfg.buffer)))
+	}
+	merges := make(map[string]bool)
+	for i, s := range t.stages {
+		if s.kind == "merge" {
+			id := dotID("merge", s.dests[0])
+			if !merges[s.dests[0]] {
+				merges[s.dests[0]] = true
+				fmt.Fprintf(&b, "\t%s [shape=ellipse, label=\"merge\"];\n", id)
+				fmt.Fprintf(&b, "\t%s -> %s;\n", id, dotID("bus", s.dests[0]))
+			}
+			fmt.Fprintf(&b, "\t%s -> %s;\n", dotID("bus", s.src), id)
+			continue
+		}
+		id := dotID("stage", strconv.Itoa(i))
+		fmt.Fprintf(&b, "\t%s [shape=ellipse, label=%s];\n", id, dotQuote(s.kind))
+		fmt.Fprintf(&b, "\t%s -> %s;\n", dotID("bus", s.src), id)
+		if s.kind == "split" {
+			fmt.Fprintf(&b, "\t%s -> %s [label=\"match\"];\n", id, dotID("bus", s.dests[0]))
+			fmt.Fprintf(&b, "\t%s -> %s [label=\"rest\"];\n", id, dotID("bus", s.dests[1]))
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s -> %s;\n", id, dotID("bus", s.dests[0]))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotID returns the quoted DOT node ID of a node of the given kind, kept apart from other kinds
+func dotID(kind, name string) string {
+	return dotQuote(kind + ":" + name)
+}
+
+// dotQuote quotes s as a DOT string, in which \n breaks a label line
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// Factory is a running topology
+type Factory[T any] struct {
+	buses  map[string]*MainBus[T]
+	order  []string            // buses, upstream first
+	stages []*Stage            // every running stage
+	feeds  map[string][]*Stage // stages producing onto each bus
+}
+
+// Bus returns the bus built for resource, or nil if the topology has none, so events can be
+// produced onto the sources and consumed from the sinks
+func (f *Factory[T]) Bus(resource string) *MainBus[T] {
+	return f.buses[resource]
+}
+
+// Shutdown drains the topology in dependency order: each bus is drained and closed once every
+// stage feeding it has finished, so events already produced flow through to the sinks, which
+// are drained like the rest and so need consumers. If ctx is done first, every bus still open
+// is closed, the stages are stopped with events possibly left on their sources, and the error
+// is returned.
+func (f *Factory[T]) Shutdown(ctx context.Context) error {
+	for _, name := range f.order {
+		for _, stage := range f.feeds[name] {
+			select {
+			case <-stage.Done():
+			case <-ctx.Done():
+				return f.abort(ctx.Err())
+			}
+		}
+		if err := f.buses[name].Drain(ctx); err != nil && !errors.Is(err, ErrBusClosed) {
+			return f.abort(fmt.Errorf("topology: bus %q: %w", name, err))
+		}
+	}
+	return nil
+}
+
+// abort closes every bus and stops every stage, returning err
+func (f *Factory[T]) abort(err error) error {
+	for _, bus := range f.buses {
+		bus.Close()
+	}
+	for _, stage := range f.stages {
+		stage.Stop()
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopologyBuildAndShutdown(t *testing.T) {
+	f, err := NewTopology[string]().
+		Bus("ore", WithLines(2), WithBuffer(8)).
+		Split(func(ev Event[string]) bool { return ev.ID%2 == 0 }, "gears", "plates").
+		Pipe("gears", func(ev Event[string]) (Event[string], bool) {
+			ev.Value = "motor from " + ev.Value
+			return ev, true
+		}, "motors").
+		Merge("output", "motors", "plates").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var mu sync.Mutex
+	var got []string
+	var wg sync.WaitGroup
+	out := f.Bus("output")
+	for line := range out.Conveyors {
+		wg.Add(1)
+		go out.ConsumeWith(line, &wg, func(ev Event[string]) {
+			mu.Lock()
+			got = append(got, ev.Value)
+			mu.Unlock()
+		})
+	}
+	ore := f.Bus("ore")
+	for id := 1; id <= 4; id++ {
+		ore.Produce(Event[string]{ID: id, Value: "ore"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := f.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	wg.Wait()
+	sort.Strings(got)
+	if strings.Join(got, ",") != "motor from ore,motor from ore,ore,ore" {
+		t.Fatalf("output got %q", got)
+	}
+	for _, name := range []string{"ore", "gears", "plates", "motors", "output"} {
+		if err := f.Bus(name).Produce(Event[string]{}); err != ErrBusClosed {
+			t.Fatalf("bus %s still open after Shutdown: %v", name, err)
+		}
+	}
+	if f.Bus("nowhere") != nil {
+		t.Fatal("Bus returned a bus the topology does not have")
+	}
+}
+
+func TestTopologyValidation(t *testing.T) {
+	keep := func(ev Event[int]) (Event[int], bool) { return ev, true }
+	even := func(ev Event[int]) bool { return ev.ID%2 == 0 }
+	for name, topo := range map[string]*Topology[int]{
+		"cycle":          NewTopology[int]().Bus("a").Pipe("a", keep, "b").Pipe("b", keep, "c").Pipe("c", keep, "a"),
+		"dangling":       NewTopology[int]().Bus("a").Pipe("ghost", keep, "b"),
+		"declared twice": NewTopology[int]().Bus("a").Bus("a"),
+		"split first":    NewTopology[int]().Split(even, "x", "y"),
+		"two readers":    NewTopology[int]().Bus("a").Pipe("a", keep, "b").Pipe("a", keep, "c"),
+		"bad options":    NewTopology[int]().Bus("a", WithBuffer(-1)),
+	} {
+		if _, err := topo.Build(); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("%s: Build = %v, want ErrInvalidConfig", name, err)
+		}
+	}
+}
+
+func TestTopologyShutdownTimeout(t *testing.T) {
+	f, err := NewTopology[int]().Bus("a", WithBuffer(4)).Pipe("a", func(ev Event[int]) (Event[int], bool) { return ev, true }, "b").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Bus("a").Produce(Event[int]{ID: 1})
+	// nobody consumes b, so it cannot drain
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := f.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want a deadline error", err)
+	}
+	if err := f.Bus("b").Produce(Event[int]{}); err != ErrBusClosed {
+		t.Fatalf("b still open after a failed Shutdown: %v", err)
+	}
+}
+
+func TestTopologyDOT(t *testing.T) {
+	dot := NewTopology[int]().
+		Bus("ore", WithLines(4), WithBuffer(8)).
+		Split(func(ev Event[int]) bool { return ev.ID%2 == 0 }, "gears", "plates").
+		Pipe("gears", func(ev Event[int]) (Event[int], bool) { return ev, true }, "motors").
+		Merge("output", "motors", "plates").
+		DOT()
+	for _, want := range []string{
+		"digraph topology {",
+		`"bus:ore" [shape=box, label="ore\n4 conveyors, buffer 8"];`,
+		`"bus:gears" [shape=box, label="gears\n2 conveyors, buffer 16"];`,
+		`"stage:0" [shape=ellipse, label="split"];`,
+		`"bus:ore" -> "stage:0";`,
+		`"stage:0" -> "bus:gears" [label="match"];`,
+		`"stage:0" -> "bus:plates" [label="rest"];`,
+		`"stage:1" [shape=ellipse, label="pipe"];`,
+		`"bus:gears" -> "stage:1";`,
+		`"stage:1" -> "bus:motors";`,
+		`"merge:output" -> "bus:output";`,
+		`"bus:motors" -> "merge:output";`,
+		`"bus:plates" -> "merge:output";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output lacks %s:\n%s", want, dot)
+		}
+	}
+	if n := strings.Count(dot, `[shape=ellipse, label="merge"]`); n != 1 {
+		t.Errorf("merge into output drawn %d times, want once", n)
+	}
+}
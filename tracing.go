@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"maps"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracePropagator carries span context between producer and consumer in Event.Carrier
+var tracePropagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// WithTracing instruments the bus with OpenTelemetry: Produce and ProduceContext start a producer
+// span and inject its context into Event.Carrier, and consumers start a child consumer span around
+// each handler call. Spans record the resource, the line and, for produce, the buffer depth.
+func WithTracing(tracer trace.Tracer) Option {
+	return func(c *busConfig) {
+		c.tracer = tracer
+	}
+}
+
+// noopSpan is returned when tracing is disabled
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// startProduceSpan starts a producer span as a child of any span in ctx and returns ev with the
+// span context injected. The carrier is copied so events sharing a map are not affected.
+func (bus *MainBus[T]) startProduceSpan(ctx context.Context, ev Event[T]) (Event[T], trace.Span) {
+	if bus.tracer == nil {
+		return ev, noopSpan
+	}
+	ctx, span := bus.tracer.Start(ctx, "mainbus produce "+bus.Resource,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("mainbus.resource", bus.Resource)))
+	carrier := make(map[string]string, len(ev.Carrier)+2)
+	maps.Copy(carrier, ev.Carrier)
+	tracePropagator.Inject(ctx, propagation.MapCarrier(carrier))
+	ev.Carrier = carrier
+	return ev, span
+}
+
+// endProduceSpan records where the event went and ends the span
+func (bus *MainBus[T]) endProduceSpan(span trace.Span, line int, err error) {
+	if bus.tracer == nil {
+		return
+	}
+	if line >= 0 {
+		span.SetAttributes(attribute.Int("mainbus.line", line), attribute.Int("mainbus.depth", bus.Depth(line)))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startConsumeSpan starts a consumer span for ev, parented by the context in its carrier
+func (bus *MainBus[T]) startConsumeSpan(line int, ev Event[T]) trace.Span {
+	if bus.tracer == nil {
+		return noopSpan
+	}
+	ctx := tracePropagator.Extract(context.Background(), propagation.MapCarrier(ev.Carrier))
+	_, span := bus.tracer.Start(ctx, "mainbus consume "+bus.Resource,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("mainbus.resource", bus.Resource), attribute.Int("mainbus.line", line)))
+	return span
+}
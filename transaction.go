@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// ErrTransactionRefused is wrapped by the errors ProduceTransaction returns when it could not
+// place every event, in which case it placed none
+var ErrTransactionRefused = errors.New("main bus: transaction refused")
+
+// transactionSettle is how long ProduceTransaction waits for producers already sending to land
+// their events before giving up
+const transactionSettle = 10 * time.Millisecond
+
+// ProduceTransaction produces evs all or nothing, for batches that form one logical
+// transaction. It holds off other producers as Snapshot does, lets sends already under way land,
+// then chooses a conveyor for every event with the bus strategy and checks there is room for
+// all of them; an event whose conveyor lacks room moves to another one that has it, unless
+// StrategyHashKey pins it to its key's conveyor. Only then does it send them, which cannot
+// block, in slice order. If any event is invalid, there is not enough room, WithMaxTotalInFlight
+// would be exceeded, or producers blocked on a full conveyor keep sending, nothing is enqueued
+// and the error wraps ErrTransactionRefused (or the validation error). Room is checked at one
+// instant, so a transaction can be refused under concurrent producers even though space seemed
+// free a moment before; callers should retry with backoff, as ProduceWithBackoff does for single
+// events. The events skip middleware and the rate limit but are persisted, mirrored and have
+// their fates tracked like produced ones. A closed bus returns ErrBusClosed.
+func (bus *MainBus[T]) ProduceTransaction(evs []Event[T]) error {
+	for i, ev := range evs {
+		if err := bus.validate(ev); err != nil {
+			return fmt.Errorf("main bus %q: transaction event %d: %w", bus.Resource, i, err)
+		}
+	}
+	bus.snapMu.Lock()
+	defer bus.snapMu.Unlock()
+	// the layout and the conveyors stay open while the read lock is held
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	if bus.closed {
+		return ErrBusClosed
+	}
+	release := bus.hold.pause()
+	defer release()
+	// the settle bound is on the wall clock, not the bus clock: it waits for goroutines to finish
+	// a send, which takes real time, and a FakeClock that never advances would spin here forever
+	for deadline := time.Now().Add(transactionSettle); bus.hold.inflight.Load() > 0; runtime.Gosched() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("main bus %q: producers still sending: %w", bus.Resource, ErrTransactionRefused)
+		}
+	}
+
+	if bus.maxInFlight > 0 && !bus.reserve(len(evs)) {
+		return fmt.Errorf("main bus %q: %d events would exceed the in-flight cap of %d: %w", bus.Resource, len(evs), bus.maxInFlight, ErrTransactionRefused)
+	}
+	t := bus.table()
+	lines, err := bus.planTransaction(t, evs)
+	if err != nil {
+		for range evs {
+			bus.vacate()
+		}
+		return err
+	}
+	for i, ev := range evs {
+		ev = withFate(ev)
+		// producers are held and consumers only make room, so the planned slot is still free
+		t.belts[lines[i]].c() <- ev
+		bus.accept(lines[i], ev, true)
+		bus.mirror(ev)
+	}
+	return nil
+}
+
+// planTransaction chooses the conveyor of every event, checking each has room for its share
+func (bus *MainBus[T]) planTransaction(t *lineTable[T], evs []Event[T]) ([]int, error) {
+	if len(t.live) == 0 {
+		return nil, fmt.Errorf("main bus %q: no live conveyor: %w", bus.Resource, ErrTransactionRefused)
+	}
+	free := make(map[int]int, len(t.live))
+	for _, line := range t.live {
+		b := t.belts[line]
+		free[line] = b.capacity() - b.depth()
+	}
+	lines := make([]int, len(evs))
+	for i, ev := range evs {
+		line, pinned := bus.selectLine(t, ev)
+		if free[line] <= 0 && !pinned {
+			for _, other := range t.live {
+				if free[other] > 0 {
+					line = other
+					break
+				}
+			}
+		}
+		if free[line] <= 0 {
+			return nil, fmt.Errorf("main bus %q: no room for transaction event %d of %d: %w", bus.Resource, i, len(evs), ErrTransactionRefused)
+		}
+		free[line]--
+		lines[i] = line
+	}
+	return lines, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestProduceTransactionAllOrNothing(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(3), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	if err := bus.ProduceTransaction(prefillEvents(4)); err != nil {
+		t.Fatalf("transaction within capacity: %v", err)
+	}
+	if bus.Depth(0) != 2 || bus.Depth(1) != 2 {
+		t.Fatalf("depths %d and %d, want 2 and 2", bus.Depth(0), bus.Depth(1))
+	}
+
+	// two free slots are left; a three-event transaction must leave the bus untouched
+	var dropped []string
+	evs := prefillEvents(3)
+	for i := range evs {
+		evs[i].OnDropped = func(reason string) { dropped = append(dropped, reason) }
+	}
+	if err := bus.ProduceTransaction(evs); !errors.Is(err, ErrTransactionRefused) {
+		t.Fatalf("oversized transaction: %v, want ErrTransactionRefused", err)
+	}
+	if d := bus.TotalDepth(); d != 4 || len(dropped) != 0 {
+		t.Fatalf("refused transaction left depth %d and dropped %v, want 4 and none", d, dropped)
+	}
+	if m := bus.Metrics(); m.Produced[0]+m.Produced[1] != 4 {
+		t.Fatalf("produced %v, want only the first transaction counted", m.Produced)
+	}
+}
+
+func TestProduceTransactionMovesToRoom(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2), WithRoutingStrategy[int](firstLine[int]{}))
+	defer bus.Close()
+	// every event is routed to line 0, which only has room for two
+	if err := bus.ProduceTransaction(prefillEvents(3)); err != nil {
+		t.Fatal(err)
+	}
+	if bus.Depth(0) != 2 || bus.Depth(1) != 1 {
+		t.Fatalf("depths %d and %d, want the overflow moved to line 1", bus.Depth(0), bus.Depth(1))
+	}
+}
+
+func TestProduceTransactionPinnedKeys(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(2), WithKeyFunc(func(Event[int]) string { return "order-1" }))
+	defer bus.Close()
+	// keyed events may not leave their conveyor, so three cannot fit in two slots
+	if err := bus.ProduceTransaction(prefillEvents(3)); !errors.Is(err, ErrTransactionRefused) {
+		t.Fatalf("pinned overflow: %v, want ErrTransactionRefused", err)
+	}
+	if d := bus.TotalDepth(); d != 0 {
+		t.Fatalf("refused transaction enqueued %d events", d)
+	}
+}
+
+func TestProduceTransactionInvalidEvent(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithValidators(ValidateTime[int]))
+	defer bus.Close()
+	evs := prefillEvents(2)
+	evs[0].Time = epoch
+	if err := bus.ProduceTransaction(evs); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("invalid event: %v, want ErrInvalidEvent", err)
+	}
+	if d := bus.TotalDepth(); d != 0 {
+		t.Fatalf("transaction with an invalid event enqueued %d", d)
+	}
+}
+
+func TestProduceTransactionConcurrent(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(10))
+	defer bus.Close()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	committed := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if bus.ProduceTransaction(prefillEvents(3)) == nil {
+				mu.Lock()
+				committed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	// 20 slots hold six whole transactions; every transaction landed whole or not at all
+	if committed != 6 || bus.TotalDepth() != 3*committed {
+		t.Fatalf("%d transactions committed with %d events buffered, want 6 and 18", committed, bus.TotalDepth())
+	}
+}
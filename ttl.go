@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log/slog"
+)
+
+// expired reports whether ev has outlived the bus TTL. Events without a Time never expire.
+func (bus *MainBus[T]) expired(ev Event[T]) bool {
+	return bus.ttl > 0 && !ev.Time.IsZero() && bus.since(ev.Time) > bus.ttl
+}
+
+// expire diverts a stale event to the dead-letter conveyor, or drops it if there is none
+func (bus *MainBus[T]) expire(line int, ev Event[T]) {
+	bus.belt(line).stats.expired.Add(1)
+	bus.logEvent(slog.LevelDebug, "event expired", line, ev)
+	if bus.deadLetters == nil || bus.Reject(ev, "expired") != nil {
+		dropped(ev, "expired")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTTLExpiresStaleEvents(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithTTL(20*time.Millisecond), WithDeadLetter(8))
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i, Time: time.Now()})
+	}
+	time.Sleep(40 * time.Millisecond)
+	bus.Produce(Event[int]{ID: 4, Time: time.Now()}) // fresh
+	bus.Produce(Event[int]{ID: 5})                   // no timestamp, never expires
+
+	var mu sync.Mutex
+	var handled []int
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(ev Event[int]) {
+			mu.Lock()
+			handled = append(handled, ev.ID)
+			mu.Unlock()
+		})
+	}
+
+	expired := map[int]bool{}
+	for len(expired) < 4 {
+		select {
+		case dl := <-bus.deadLetters:
+			if dl.Reason != "expired" || dl.Event.ID > 3 {
+				t.Fatalf("unexpected dead letter %+v", dl)
+			}
+			expired[dl.Event.ID] = true
+		case <-time.After(time.Second):
+			t.Fatalf("only %d events expired, want 4", len(expired))
+		}
+	}
+	if err := bus.Drain(t.Context()); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	wg.Wait()
+
+	sort.Ints(handled)
+	if len(handled) != 2 || handled[0] != 4 || handled[1] != 5 {
+		t.Fatalf("handled %v, want [4 5]", handled)
+	}
+	var n uint64
+	for _, e := range bus.Metrics().Expired {
+		n += e
+	}
+	if n != 4 {
+		t.Fatalf("Expired metric = %d, want 4", n)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"maps"
+	"reflect"
+	"sync"
+)
+
+// typeCounts counts the events consumed off one conveyor by the dynamic type of their Value
+type typeCounts struct {
+	mu sync.Mutex
+	n  map[string]uint64
+}
+
+// WithTypeStats makes the bus count the events consumed off each conveyor by the Go type of
+// their Value, found with reflection unless it is a string, bool, int, int64, float64 or []byte,
+// for TypeStats. It is off by default to spare every consumed event a locked map update.
+func WithTypeStats() Option {
+	return func(c *busConfig) {
+		c.typeStats = true
+	}
+}
+
+// TypeStats returns how many events consumers have taken off a conveyor, keyed by the
+// Go type of their Value as reflect prints it ("int", "*main.Order", "map[string]interface {}"),
+// with "<nil>" for a nil interface Value, for spotting unexpected types polluting a conveyor of an
+// interface event type. Events count once they go through the consume pipeline, including those
+// it then skips or expires; pills and events read from Receiver do not count. The map is a copy.
+// It is nil unless the bus was built WithTypeStats, and TypeStats panics if line is out of range.
+func (bus *MainBus[T]) TypeStats(line int) map[string]uint64 {
+	b := bus.belt(line)
+	if !bus.typeStats {
+		return nil
+	}
+	b.types.mu.Lock()
+	defer b.types.mu.Unlock()
+	return maps.Clone(b.types.n)
+}
+
+// count counts one event holding v
+func (tc *typeCounts) count(v any) {
+	name, ok := fastTypeName(v)
+	if !ok {
+		name = reflectTypeName(v)
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.n == nil {
+		tc.n = make(map[string]uint64)
+	}
+	tc.n[name]++
+}
+
+// reflectTypeName names the type of v as reflect prints it, "<nil>" for nil
+func reflectTypeName(v any) string {
+	if t := reflect.TypeOf(v); t != nil {
+		return t.String()
+	}
+	return "<nil>"
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"maps"
+	"sync"
+	"testing"
+)
+
+func TestTypeStats(t *testing.T) {
+	type reading struct{ celsius float64 }
+	bus := NewMainBus[any]("copper", WithTypeStats())
+	bus.RemoveConveyor(1)
+	for i, v := range []any{1, "two", 3, reading{4}, nil, &reading{6}, 7} {
+		bus.Produce(Event[any]{ID: i + 1, Value: v})
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWith(0, &wg, func(Event[any]) {})
+	bus.Close()
+	wg.Wait()
+	want := map[string]uint64{"int": 3, "string": 1, "main.reading": 1, "*main.reading": 1, "<nil>": 1}
+	if got := bus.TypeStats(0); !maps.Equal(got, want) {
+		t.Fatalf("TypeStats = %v, want %v", got, want)
+	}
+}
+
+func TestTypeStatsOff(t *testing.T) {
+	bus := NewMainBus[int]("copper")
+	bus.Produce(Event[int]{ID: 1})
+	var wg sync.WaitGroup
+	for line := range bus.Conveyors {
+		wg.Add(1)
+		go bus.ConsumeWith(line, &wg, func(Event[int]) {})
+	}
+	bus.Close()
+	wg.Wait()
+	if got := bus.TypeStats(0); got != nil {
+		t.Fatalf("TypeStats without WithTypeStats = %v, want nil", got)
+	}
+}
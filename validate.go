@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidEvent is wrapped, together with the validator's own error, by the errors produce
+// calls return for an event a Validator rejected
+var ErrInvalidEvent = errors.New("main bus: invalid event")
+
+// ErrValidation is ErrInvalidEvent under the name it is also known by, so errors.Is matches
+// either for an event a Validator rejected
+var ErrValidation = ErrInvalidEvent
+
+// Validator checks an event before it is enqueued, returning an error to reject it
+type Validator[T any] func(Event[T]) error
+
+// WithValidators adds validators run, in order, on every event produced by Produce,
+// ProduceContext, TryProduce, ProduceBatch and Producer.Send. They run after middleware, so they
+// see the event as it would be enqueued, and before the rate limit; the first error rejects the
+// event, which is never enqueued. Calls accumulate, and the validators must take the bus event
+// type.
+func WithValidators[T any](vs ...Validator[T]) Option {
+	return func(c *busConfig) {
+		for _, v := range vs {
+			c.validators = append(c.validators, v)
+		}
+	}
+}
+
+// validatorsFor returns the validators added by WithValidators, or an error wrapping
+// ErrInvalidConfig if one was written for events of another type
+func validatorsFor[T any](cfg busConfig) ([]Validator[T], error) {
+	var vs []Validator[T]
+	for _, v := range cfg.validators {
+		f, ok := v.(Validator[T])
+		if !ok {
+			return nil, fmt.Errorf("%w: validator %T does not take Event[%T]", ErrInvalidConfig, v, *new(T))
+		}
+		vs = append(vs, f)
+	}
+	return vs, nil
+}
+
+// validate runs the bus validators on ev
+func (bus *MainBus[T]) validate(ev Event[T]) error {
+	for _, v := range bus.validators {
+		if err := v(ev); err != nil {
+			return fmt.Errorf("main bus %q: %w: %w", bus.Resource, ErrInvalidEvent, err)
+		}
+	}
+	return nil
+}
+
+// ValidateResource rejects events without a Resource
+func ValidateResource[T any](ev Event[T]) error {
+	if ev.Resource == "" {
+		return errors.New("empty resource")
+	}
+	return nil
+}
+
+// ValidateValue rejects events whose Value is nil: a nil interface, pointer, map, slice,
+// channel or func
+func ValidateValue[T any](ev Event[T]) error {
+	v := reflect.ValueOf(any(ev.Value))
+	if !v.IsValid() {
+		return errors.New("nil value")
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		if v.IsNil() {
+			return errors.New("nil value")
+		}
+	}
+	return nil
+}
+
+// ValidateTime rejects events with a zero Time
+func ValidateTime[T any](ev Event[T]) error {
+	if ev.Time.IsZero() {
+		return errors.New("zero time")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatorsRejectWithoutEnqueuing(t *testing.T) {
+	var order []string
+	tracking := func(name string, err error) Validator[*string] {
+		return func(Event[*string]) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	errOdd := errors.New("odd ID")
+	bus := NewMainBus[*string]("iron", WithLines(1), WithBuffer(4),
+		WithValidators(tracking("first", nil)),
+		WithValidators(tracking("second", errOdd), tracking("third", nil)))
+	defer bus.Close()
+
+	err := bus.Produce(Event[*string]{ID: 1})
+	if !errors.Is(err, ErrInvalidEvent) || !errors.Is(err, errOdd) {
+		t.Fatalf("Produce: %v, want ErrInvalidEvent wrapping the validator error", err)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Fatalf("validators ran as %v, want first,second", order)
+	}
+	if bus.TryProduce(Event[*string]{ID: 2}) {
+		t.Fatal("TryProduce accepted an invalid event")
+	}
+	if n, err := bus.ProduceBatch([]Event[*string]{{ID: 3}}); n != 0 || !errors.Is(err, errOdd) {
+		t.Fatalf("ProduceBatch = %d, %v", n, err)
+	}
+	if d := bus.TotalDepth(); d != 0 {
+		t.Fatalf("%d invalid events were enqueued", d)
+	}
+	if m := bus.Metrics(); m.Produced[0]+m.Produced[1] != 0 {
+		t.Fatalf("invalid events counted as produced: %v", m.Produced)
+	}
+}
+
+func TestBuiltinValidators(t *testing.T) {
+	bus := NewMainBus[*string]("iron", WithLines(1), WithBuffer(4),
+		WithValidators(ValidateResource[*string], ValidateValue[*string], ValidateTime[*string]))
+	defer bus.Close()
+	plate := "plate"
+	good := Event[*string]{Resource: "iron", Value: &plate, Time: time.Now()}
+	if err := bus.Produce(good); err != nil {
+		t.Fatalf("valid event: %v", err)
+	}
+	for name, ev := range map[string]Event[*string]{
+		"resource": {Value: &plate, Time: good.Time},
+		"value":    {Resource: "iron", Time: good.Time},
+		"time":     {Resource: "iron", Value: &plate},
+	} {
+		if err := bus.Produce(ev); !errors.Is(err, ErrInvalidEvent) {
+			t.Fatalf("event without %s: %v, want ErrInvalidEvent", name, err)
+		}
+	}
+	if d := bus.TotalDepth(); d != 1 {
+		t.Fatalf("depth %d, want only the valid event", d)
+	}
+	if err := ValidateValue(Event[int]{}); err != nil {
+		t.Fatalf("a zero int is not nil: %v", err)
+	}
+	if err := ValidateValue(Event[any]{}); err == nil {
+		t.Fatal("a nil any value passed")
+	}
+}
+
+func TestWithValidatorsMismatch(t *testing.T) {
+	if _, err := NewMainBusChecked[int]("iron", WithValidators(ValidateTime[string])); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched validator: %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// StallReport describes a bus on which nothing has moved for the watchdog timeout
+type StallReport struct {
+	Resource  string
+	Since     time.Time       // when the last progress was seen
+	Conveyors []StuckConveyor // the live conveyors still holding events
+}
+
+// StuckConveyor is a conveyor holding events that nobody is taking
+type StuckConveyor struct {
+	Line      int
+	Label     string // "" for a conveyor without one
+	Depth     int
+	Consumers int // handler-based consumers attached; 0 often means a missing consumer
+}
+
+// Watchdog watches the bus for stalls: if no event is produced or consumed for timeout while
+// events are buffered, onStall is called with the conveyors that are stuck. It fires once per
+// stall and re-arms when events move again, which catches forgotten Close calls and stuck
+// consumers in development. The watchdog samples the bus counters a few times per timeout and
+// costs nothing else; it stops when the returned func is called or the bus is closed.
+func (bus *MainBus[T]) Watchdog(timeout time.Duration, onStall func(report StallReport)) (stop func()) {
+	quit := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(max(timeout/4, time.Millisecond))
+		defer ticker.Stop()
+		last, since, fired := bus.progress(), bus.now(), false
+		for {
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			case <-bus.closing:
+				return
+			}
+			if p := bus.progress(); p != last {
+				last, since, fired = p, bus.now(), false
+				continue
+			}
+			if fired || bus.since(since) < timeout {
+				continue
+			}
+			if report := bus.stallReport(since); len(report.Conveyors) > 0 {
+				fired = true
+				onStall(report)
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+// progress sums the produced and consumed counters of every conveyor; it changes whenever an
+// event moves
+func (bus *MainBus[T]) progress() uint64 {
+	var n uint64
+	for _, b := range bus.table().belts {
+		n += b.stats.produced.Load() + b.stats.consumed.Load()
+	}
+	return n
+}
+
+// stallReport lists the live conveyors that hold events
+func (bus *MainBus[T]) stallReport(since time.Time) StallReport {
+	r := StallReport{Resource: bus.Resource, Since: since}
+	t := bus.table()
+	for _, line := range t.live {
+		b := t.belts[line]
+		if depth := b.depth(); depth > 0 {
+			r.Conveyors = append(r.Conveyors, StuckConveyor{Line: line, Label: b.label, Depth: depth, Consumers: int(b.consumers.Load())})
+		}
+	}
+	return r
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdogReportsStall(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(2), WithBuffer(4), WithStrategy(StrategyRoundRobin))
+	defer bus.Close()
+	reports := make(chan StallReport, 4)
+	stop := bus.Watchdog(20*time.Millisecond, func(r StallReport) { reports <- r })
+	defer stop()
+
+	// line 1 is consumed, line 0 has no consumer
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWith(1, &wg, func(Event[int]) {})
+	for i := 0; i < 4; i++ {
+		bus.Produce(Event[int]{ID: i})
+	}
+	select {
+	case r := <-reports:
+		if r.Resource != "iron" || len(r.Conveyors) != 1 {
+			t.Fatalf("report %+v, want conveyor 0 only", r)
+		}
+		if c := r.Conveyors[0]; c.Line != 0 || c.Depth != 2 || c.Consumers != 0 {
+			t.Fatalf("stuck conveyor %+v", c)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchdog never fired")
+	}
+	select {
+	case r := <-reports:
+		t.Fatalf("watchdog fired twice for one stall: %+v", r)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestWatchdogQuietWhenEmpty(t *testing.T) {
+	bus := NewMainBus[int]("iron")
+	defer bus.Close()
+	stop := bus.Watchdog(5*time.Millisecond, func(r StallReport) { t.Errorf("idle bus reported %+v", r) })
+	time.Sleep(30 * time.Millisecond)
+	stop()
+	stop()
+}
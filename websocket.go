@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/coder/websocket"
+)
+
+// StreamOption configures a BusWebSocketHandler
+type StreamOption func(*streamConfig)
+
+// streamConfig holds the backpressure settings of a WebSocket stream
+type streamConfig struct {
+	buffer int
+	drop   bool
+}
+
+// WithStreamBuffer lets up to n consumed events queue for a client while earlier ones are
+// still being written
+func WithStreamBuffer(n int) StreamOption {
+	return func(c *streamConfig) {
+		c.buffer = n
+	}
+}
+
+// WithStreamDrop discards events for a client whose queue is full instead of holding up the
+// conveyor. Discarded events are counted in the line's Dropped metric.
+func WithStreamDrop() StreamOption {
+	return func(c *streamConfig) {
+		c.drop = true
+	}
+}
+
+// BusWebSocketHandler upgrades each request to a WebSocket and streams the events consumed from
+// line to it, one message per event encoded with the bus codec and compression: text messages
+// for the default uncompressed JSON, binary ones otherwise. Each connection runs its own consumer of that conveyor,
+// competing with any other consumers of the line, and stops it when the client disconnects. By
+// default a slow client blocks the consumer, so the conveyor backs up just as it would with a
+// slow handler; WithStreamBuffer adds slack and WithStreamDrop drops events rather than block.
+// Events still queued when a client disconnects are lost. The connection is closed normally
+// once the conveyor is closed.
+func BusWebSocketHandler[T any](bus *MainBus[T], line int, opts ...StreamOption) http.Handler {
+	bus.conveyor(line) // fail fast on a bad line
+	var cfg streamConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return // Accept has already written the error response
+		}
+		defer conn.CloseNow()
+		// CloseRead discards client messages and cancels ctx when the client goes away
+		ctx, cancel := context.WithCancel(conn.CloseRead(r.Context()))
+		defer cancel()
+
+		out := make(chan Event[T], cfg.buffer)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			bus.ConsumeContext(ctx, line, &wg, func(ev Event[T]) {
+				if cfg.drop {
+					select {
+					case out <- ev:
+					default:
+						bus.drop(slog.LevelWarn, line, ev, "websocket client too slow")
+					}
+					return
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+				}
+			})
+			close(out)
+		}()
+
+		for ev := range out {
+			if err := writeEvent(ctx, conn, bus.codec, bus.compression, ev); err != nil {
+				cancel()
+				for range out {
+				}
+				return
+			}
+		}
+		if ctx.Err() == nil {
+			conn.Close(websocket.StatusNormalClosure, "conveyor closed")
+		}
+	})
+}
+
+// writeEvent sends ev as one message encoded with codec and compressed with comp: a text message
+// for uncompressed JSON, binary otherwise
+func writeEvent[T any](ctx context.Context, conn *websocket.Conn, codec Codec[T], comp Compression, ev Event[T]) error {
+	data, err := encodeEvent(codec, comp, ev)
+	if err != nil {
+		return err
+	}
+	typ := websocket.MessageBinary
+	if textCodec(codec) && comp == CompressionNone {
+		typ = websocket.MessageText
+	}
+	return conn.Write(ctx, typ, data)
+}
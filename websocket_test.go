@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// serveStream serves h and returns a WebSocket URL for it plus a channel closed each time a
+// handler call returns
+func serveStream(t *testing.T, h http.Handler) (string, <-chan struct{}) {
+	t.Helper()
+	returned := make(chan struct{}, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		returned <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), returned
+}
+
+// put enqueues ev on line 0 regardless of the bus strategy
+func put(t *testing.T, bus *MainBus[string], ev Event[string]) {
+	t.Helper()
+	if err := bus.send(context.Background(), 0, bus.belt(0), ev, false); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+}
+
+func TestWebSocketStreamsEvents(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithBuffer(4))
+	url, returned := serveStream(t, BusWebSocketHandler(bus, 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.CloseNow()
+	put(t, bus, Event[string]{ID: 1, Value: "a"})
+	put(t, bus, Event[string]{ID: 2, Value: "b"})
+	for want := 1; want <= 2; want++ {
+		var ev Event[string]
+		if err := wsjson.Read(ctx, conn, &ev); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if ev.ID != want {
+			t.Fatalf("got event %d, want %d", ev.ID, want)
+		}
+	}
+
+	bus.Close()
+	var ev Event[string]
+	err = wsjson.Read(ctx, conn, &ev)
+	if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+		t.Fatalf("read after close: %v, want normal closure", err)
+	}
+	<-returned
+}
+
+func TestWebSocketDisconnectStopsConsumer(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithBuffer(4))
+	defer bus.Close()
+	url, returned := serveStream(t, BusWebSocketHandler(bus, 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+	select {
+	case <-returned:
+	case <-ctx.Done():
+		t.Fatal("handler still running after the client disconnected")
+	}
+
+	put(t, bus, Event[string]{ID: 1})
+	time.Sleep(20 * time.Millisecond)
+	if d := bus.Depth(0); d != 1 {
+		t.Fatalf("depth = %d, want the event left on the conveyor", d)
+	}
+}
+
+// stall connects a client that never reads, so the server's writes eventually block
+func stall(t *testing.T, url string) {
+	t.Helper()
+	conn, _, err := websocket.Dial(context.Background(), url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+}
+
+func TestWebSocketSlowClientBlocks(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithBuffer(4))
+	defer bus.Close()
+	url, _ := serveStream(t, BusWebSocketHandler(bus, 0, WithStreamBuffer(8)))
+	stall(t, url)
+
+	payload := strings.Repeat("x", 64<<10)
+	deadline := time.Now().Add(10 * time.Second)
+	for bus.offer(0, bus.belt(0), Event[string]{Value: payload}, false) {
+		if time.Now().After(deadline) {
+			t.Fatal("conveyor never backed up behind a stalled client")
+		}
+	}
+	if d := bus.Metrics().Dropped[0]; d != 0 {
+		t.Fatalf("dropped = %d, want 0 when blocking", d)
+	}
+}
+
+func TestWebSocketSlowClientDrops(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithBuffer(4))
+	defer bus.Close()
+	url, _ := serveStream(t, BusWebSocketHandler(bus, 0, WithStreamBuffer(8), WithStreamDrop()))
+	stall(t, url)
+
+	payload := strings.Repeat("x", 64<<10)
+	deadline := time.Now().Add(10 * time.Second)
+	for bus.Metrics().Dropped[0] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no events dropped for a stalled client")
+		}
+		put(t, bus, Event[string]{Value: payload})
+	}
+}
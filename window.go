@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithEventTime makes ConsumeWindow place events in windows by Event.Time instead of the time
+// they are consumed. Events without a timestamp still use the consume time.
+func WithEventTime() ConsumeOption {
+	return func(c *consumeConfig) {
+		c.eventTime = true
+	}
+}
+
+// ConsumeWindow consumes a specific conveyor, folding events into tumbling windows of the given
+// length aligned to the wall clock. reduce is called with the window's aggregate so far (nil for
+// its first event) and returns the new aggregate; emit receives each window's start and final
+// aggregate once the window ends, and for the last partial window when the conveyor is closed.
+// Windows without events are not emitted. Windowing uses the consume time unless WithEventTime
+// is given; then the open window is closed by the first event past its end (or the conveyor
+// closing) rather than by the clock, and a late event is folded into the open window. A
+// non-positive window panics.
+func (bus *MainBus[T]) ConsumeWindow(line int, wg *sync.WaitGroup, window time.Duration, reduce func(agg any, ev Event[T]) any, emit func(windowStart time.Time, agg any), opts ...ConsumeOption) {
+	if window <= 0 {
+		panic("main bus: ConsumeWindow needs a positive window")
+	}
+	defer wg.Done()
+	cfg := newConsumeConfig(opts)
+	self := bus.attachAs(line, cfg.name)
+	defer self.detach()
+	c := bus.conveyor(line)
+
+	var start time.Time // of the open window; zero while none is open
+	var agg any
+	timer := time.NewTimer(window)
+	timer.Stop()
+	defer timer.Stop()
+	flush := func() {
+		if !start.IsZero() {
+			emit(start, agg)
+			start, agg = time.Time{}, nil
+		}
+	}
+	add := func(ev Event[T]) {
+		at := bus.now()
+		if cfg.eventTime && !ev.Time.IsZero() {
+			at = ev.Time
+		}
+		if !start.IsZero() && !at.Before(start.Add(window)) {
+			flush()
+		}
+		if start.IsZero() {
+			start = at.Truncate(window)
+			if !cfg.eventTime {
+				timer.Reset(start.Add(window).Sub(bus.now()))
+			}
+		}
+		agg = reduce(agg, ev)
+	}
+	for {
+		if bus.Paused(line) {
+			bus.waitResumed(context.Background(), line)
+		}
+		select {
+		case ev, ok := <-c:
+			if !ok && bus.reopened(line, &c) {
+				continue
+			}
+			if !ok || bus.poisoned(line, ev) {
+				flush()
+				return
+			}
+			if ev.barrier != nil {
+				flush() // the barrier is reached once the events before it are handled
+			}
+			bus.deliver(line, ev, add)
+			self.done()
+		case <-timer.C:
+			flush()
+		}
+	}
+}
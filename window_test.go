@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// count is a ConsumeWindow reducer counting events
+func count(agg any, _ Event[int]) any {
+	n, _ := agg.(int)
+	return n + 1
+}
+
+type windowed struct {
+	start time.Time
+	count int
+}
+
+func TestConsumeWindowConsumeTime(t *testing.T) {
+	const window = 40 * time.Millisecond
+	bus := NewMainBus[int]("iron", WithLines(1))
+	bus.RemoveConveyor(1)
+	out := make(chan windowed, 10)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go bus.ConsumeWindow(0, &wg, window, count, func(start time.Time, agg any) { out <- windowed{start, agg.(int)} })
+
+	// start just after a boundary so both events share a window
+	time.Sleep(time.Until(time.Now().Truncate(window).Add(window + 2*time.Millisecond)))
+	bus.Produce(Event[int]{})
+	bus.Produce(Event[int]{})
+	select {
+	case w := <-out:
+		if w.count != 2 || !w.start.Equal(w.start.Truncate(window)) || time.Since(w.start) < window {
+			t.Fatalf("first window %+v emitted at %v", w, time.Now())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("window never emitted at its boundary")
+	}
+	bus.Produce(Event[int]{})
+	bus.Close()
+	wg.Wait()
+	if w := <-out; w.count != 1 {
+		t.Fatalf("final partial window %+v, want 1 event", w)
+	}
+}
+
+func TestConsumeWindowEventTime(t *testing.T) {
+	base := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	bus.RemoveConveyor(1)
+	for _, s := range []int{1, 59, 61, 30, 130} { // 30 is late and joins the open window
+		bus.Produce(Event[int]{Time: base.Add(time.Duration(s) * time.Second)})
+	}
+	bus.Close()
+	var got []windowed
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.ConsumeWindow(0, &wg, time.Minute, count, func(start time.Time, agg any) {
+		got = append(got, windowed{start, agg.(int)})
+	}, WithEventTime())
+	want := []windowed{{base, 2}, {base.Add(time.Minute), 2}, {base.Add(2 * time.Minute), 1}}
+	if len(got) != len(want) {
+		t.Fatalf("windows %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].start.Equal(want[i].start) || got[i].count != want[i].count {
+			t.Fatalf("windows %+v, want %+v", got, want)
+		}
+	}
+}
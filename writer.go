@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// ConsumeToWriter consumes a specific conveyor, writing format(ev) for each event to w, such as
+// stdout, a file or a socket; with JSONLines it writes a newline-delimited JSON stream other
+// tools can read. Writes are buffered and flushed whenever the conveyor has nothing more
+// buffered, and once more when it is closed; w itself is not closed. An event format returns nil
+// for is counted as dropped. At the first write error the consumer stops: the event that failed
+// is counted as dropped, the rest stay buffered for other consumers, and the error is returned.
+func (bus *MainBus[T]) ConsumeToWriter(line int, wg *sync.WaitGroup, w io.Writer, format func(Event[T]) []byte) error {
+	bw := bufio.NewWriter(w)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var werr error
+	bus.ConsumeContext(ctx, line, wg, func(ev Event[T]) {
+		data := format(ev)
+		if data == nil {
+			bus.drop(slog.LevelWarn, line, ev, "not formatted")
+			return
+		}
+		if _, werr = bw.Write(data); werr == nil && len(bus.conveyor(line)) == 0 {
+			werr = bw.Flush()
+		}
+		if werr != nil {
+			bus.drop(slog.LevelWarn, line, ev, "write failed", slog.Any("error", werr))
+			cancel()
+		}
+	})
+	if werr == nil {
+		werr = bw.Flush()
+	}
+	if werr != nil {
+		return fmt.Errorf("main bus %q: writing conveyor %d: %w", bus.Resource, line, werr)
+	}
+	return nil
+}
+
+// JSONLines formats an event for ConsumeToWriter as one line of JSON, or nil if its Value cannot
+// be encoded
+func JSONLines[T any](ev Event[T]) []byte {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return nil
+	}
+	return append(data, '\n')
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsumeToWriterJSONLines(t *testing.T) {
+	bus := NewMainBus[string]("iron", WithLines(1), WithBuffer(4))
+	bus.RemoveConveyor(1)
+	bus.Produce(Event[string]{ID: 1, Resource: "iron", Value: "plate"})
+	bus.Produce(Event[string]{ID: 2, Resource: "iron", Value: "gear"})
+	bus.Close()
+
+	var buf bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+	if err := bus.ConsumeToWriter(0, &wg, &buf, JSONLines[string]); err != nil {
+		t.Fatalf("ConsumeToWriter: %v", err)
+	}
+	var got []Event[string]
+	for sc := bufio.NewScanner(&buf); sc.Scan(); {
+		var ev Event[string]
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		got = append(got, ev)
+	}
+	if len(got) != 2 || got[0].Value != "plate" || got[1].ID != 2 {
+		t.Fatalf("read back %+v, want events 1 and 2", got)
+	}
+}
+
+// failAfter accepts n writes, reporting each on wrote, then fails
+type failAfter struct {
+	n     int
+	wrote chan struct{}
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (w *failAfter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errDiskFull
+	}
+	w.n--
+	w.wrote <- struct{}{}
+	return len(p), nil
+}
+
+func TestConsumeToWriterStopsOnWriteError(t *testing.T) {
+	bus := NewMainBus[int]("iron", WithLines(1), WithBuffer(8))
+	defer bus.Close()
+	bus.RemoveConveyor(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	done := make(chan error, 1)
+	w := &failAfter{n: 1, wrote: make(chan struct{}, 1)}
+	go func() {
+		done <- bus.ConsumeToWriter(0, &wg, w, func(Event[int]) []byte { return []byte("event\n") })
+	}()
+	bus.Produce(Event[int]{ID: 1})
+	<-w.wrote
+	bus.Produce(Event[int]{ID: 2}) // fails
+	select {
+	case err := <-done:
+		if !errors.Is(err, errDiskFull) {
+			t.Fatalf("ConsumeToWriter = %v, want the write error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ConsumeToWriter did not stop after a write error")
+	}
+	bus.Produce(Event[int]{ID: 3})
+	time.Sleep(10 * time.Millisecond)
+	if d := bus.Depth(0); d != 1 {
+		t.Fatalf("Depth = %d, want event 3 left for another consumer", d)
+	}
+	if n := bus.Metrics().Dropped[0]; n != 1 {
+		t.Fatalf("Dropped = %d, want the failed event", n)
+	}
+}